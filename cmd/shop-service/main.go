@@ -3,51 +3,173 @@ package main
 import (
 	"avito-shop-service/config"
 	"avito-shop-service/internal/handlers"
-	"avito-shop-service/internal/middleware"
+	"avito-shop-service/internal/logging"
+	"avito-shop-service/internal/metrics"
+	"avito-shop-service/internal/ratelimit"
 	"avito-shop-service/internal/repository"
-	"avito-shop-service/internal/service"
-	"log"
+	"avito-shop-service/internal/tracing"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
 	"net/http"
-
-	"github.com/gorilla/mux"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
 )
 
+// Срок отправки накопленных трасс при остановке, отдельный от ожидания текущих запросов
+const tracingFlushTimeout = 5 * time.Second
+
 func main() {
+	os.Exit(run())
+}
+
+// run запускает сервис и возвращает код завершения процесса. Выход через return,
+// а не os.Exit, чтобы отложенные вызовы успели выполниться
+func run() int {
 	cfg := config.LoadConfig()
-	db := repository.ConnectDB(cfg)
-	defer db.Close()
 
-	// Инициализируем репозитории
-	userRepo := repository.NewUserRepository(db)
-	walletRepo := repository.NewPostgresWalletRepository(db)
+	if err := logging.Setup(os.Stdout, cfg.LogLevel, cfg.LogFormat); err != nil {
+		return fail("failed to set up logging", err)
+	}
+
+	shutdownTracing, err := tracing.Setup(context.Background(), cfg.TracesExporter, cfg.ServiceName)
+	if err != nil {
+		return fail("failed to set up tracing", err)
+	}
 
-	// Инициализируем сервисы
-	authService := service.NewAuthService(userRepo, cfg.JWTSecret)
-	walletService := service.NewWalletService(walletRepo)
+	// Пока база недоступна, подключение повторяется; SIGTERM прерывает ожидание
+	connectCtx, stopConnect := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	db, err := repository.ConnectDB(connectCtx, cfg)
+	stopConnect()
+	if err != nil {
+		return fail("failed to connect to the database", err)
+	}
+	if err := metrics.RegisterDB(db); err != nil {
+		return fail("failed to register DB metrics", err)
+	}
 
-	// Инициализируем обработчики
-	authHandler := handlers.NewAuthHandler(authService)
-	walletHandler := handlers.NewWalletHandler(walletService)
+	// Контекст фоновых задач отменяется при остановке сервиса
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
 
-	router := mux.NewRouter()
+	services, err := handlers.NewServices(db, cfg)
+	if err != nil {
+		return fail("failed to initialize services", err)
+	}
 
-	// Применяем middleware для защищенных маршрутов
-	// Регистрация и вход не требуют аутентификации
-	router.HandleFunc("/api/auth", authHandler.Auth).Methods("POST")
+	// Хранилище лимитов частоты запросов: в памяти экземпляра или общее в БД
+	var rateLimitStore ratelimit.Store
+	var sharedRateLimitStore *ratelimit.PostgresStore
+	switch cfg.RateLimitStore {
+	case "memory":
+		rateLimitStore = ratelimit.NewMemoryStore()
+	case "postgres":
+		sharedRateLimitStore = ratelimit.NewPostgresStore(db)
+		rateLimitStore = sharedRateLimitStore
+	default:
+		return fail("failed to configure rate limiting", fmt.Errorf("unknown store %q", cfg.RateLimitStore))
+	}
 
-	// Защищенные маршруты с middleware
-	protected := router.PathPrefix("/api").Subrouter()
-	protected.Use(middleware.AuthMiddleware(authService))
+	router := handlers.NewRouter(cfg, services, rateLimitStore)
 
-	// Роуты, которые требуют аутентификации
-	protected.HandleFunc("/info", walletHandler.GetInfo).Methods("GET")
-	protected.HandleFunc("/sendCoin", walletHandler.Transfer).Methods("POST")
-	protected.HandleFunc("/buy/{item}", walletHandler.BuyItem).Methods("POST")
+	// Фоновый анализ переводов на мошеннические схемы
+	var workers sync.WaitGroup
+	workers.Add(1)
+	go func() {
+		defer workers.Done()
+		services.Fraud.Run(ctx)
+	}()
 
-	log.Println("Server started on :8080")
+	// Удаление устаревших корзин лимитов из БД
+	if sharedRateLimitStore != nil {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			sharedRateLimitStore.Run(ctx, time.Minute)
+		}()
+	}
 
-	if err := http.ListenAndServe(":8080", router); err != nil {
-		log.Fatalf("Server failed: %v", err)
+	server := &http.Server{
+		Addr:           ":" + cfg.ServerPort,
+		Handler:        router,
+		ReadTimeout:    cfg.HTTPReadTimeout,
+		WriteTimeout:   cfg.HTTPWriteTimeout,
+		IdleTimeout:    cfg.HTTPIdleTimeout,
+		MaxHeaderBytes: cfg.HTTPMaxHeaderBytes,
 	}
 
+	serverErr := make(chan error, 1)
+	go func() {
+		slog.Info("server started", slog.String("addr", server.Addr))
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			serverErr <- err
+		}
+	}()
+
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, syscall.SIGINT, syscall.SIGTERM)
+
+	exitCode := 0
+	drain := false
+	select {
+	case sig := <-stop:
+		slog.Info("shutting down", slog.String("signal", sig.String()))
+		drain = true
+	case err := <-serverErr:
+		slog.Error("server failed", slog.Any("error", err))
+		exitCode = 1
+	}
+
+	// Readiness становится отрицательной, чтобы балансировщик перестал направлять запросы.
+	// Пока он не заметит это при очередной проверке, запросы продолжают обслуживаться;
+	// повторный сигнал прерывает ожидание
+	services.Health.SetShuttingDown()
+	if drain && cfg.ShutdownDrainDelay > 0 {
+		slog.Info("draining before shutdown", slog.Duration("delay", cfg.ShutdownDrainDelay))
+		select {
+		case <-time.After(cfg.ShutdownDrainDelay):
+		case sig := <-stop:
+			slog.Warn("drain interrupted", slog.String("signal", sig.String()))
+		case err := <-serverErr:
+			slog.Error("server failed", slog.Any("error", err))
+			exitCode = 1
+		}
+	}
+
+	// Перестаем принимать соединения и ждем завершения текущих запросов
+	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+	defer shutdownCancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		slog.Error("server shutdown did not complete", slog.Any("error", err))
+		exitCode = 1
+	}
+
+	// Останавливаем фоновые задачи и только затем закрываем соединения с БД
+	cancel()
+	workers.Wait()
+
+	// shutdownCtx мог истечь в ожидании запросов, поэтому у сброса трасс свой срок
+	flushCtx, flushCancel := context.WithTimeout(context.Background(), tracingFlushTimeout)
+	defer flushCancel()
+	if err := shutdownTracing(flushCtx); err != nil {
+		slog.Warn("failed to flush traces", slog.Any("error", err))
+	}
+
+	if err := db.Close(); err != nil {
+		slog.Error("failed to close DB", slog.Any("error", err))
+		exitCode = 1
+	}
+
+	slog.Info("server stopped")
+	return exitCode
+}
+
+// Сообщает о невозможности запуска и возвращает код завершения
+func fail(msg string, err error) int {
+	slog.Error(msg, slog.Any("error", err))
+	return 1
 }
@@ -1,8 +1,11 @@
 package config
 
 import (
-	"log"
+	"log/slog"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 )
@@ -14,35 +17,201 @@ type Config struct {
 	DBPassword string
 	DBName     string
 	JWTSecret  string
+
+	// Параметры подключения: sslmode (disable, require, verify-ca, verify-full), корневой
+	// сертификат, таймаут установки соединения и имя приложения в pg_stat_activity.
+	// DBParams - прочие параметры libpq в формате "key=value key=value"
+	DBSSLMode         string
+	DBSSLRootCert     string
+	DBConnectTimeout  time.Duration
+	DBApplicationName string
+	DBParams          string
+
+	// Пул соединений (0 в DBMaxOpenConns - без ограничения, в DBConnMaxLifetime
+	// и DBConnMaxIdleTime - соединения не закрываются по времени)
+	DBMaxOpenConns    int
+	DBMaxIdleConns    int
+	DBConnMaxLifetime time.Duration
+	DBConnMaxIdleTime time.Duration
+
+	// Сколько при старте ждать доступности базы, повторяя подключение (0 - одна попытка)
+	DBConnectRetryTimeout time.Duration
+
+	// HTTP-сервер. При остановке сервис сначала ShutdownDrainDelay отвечает 503 на /readyz,
+	// продолжая обслуживать запросы, пока балансировщик не исключит экземпляр; затем перестает
+	// принимать соединения и ждет завершения текущих запросов не дольше ShutdownTimeout
+	ServerPort         string
+	HTTPReadTimeout    time.Duration
+	HTTPWriteTimeout   time.Duration
+	HTTPIdleTimeout    time.Duration
+	HTTPMaxHeaderBytes int
+	ShutdownDrainDelay time.Duration
+	ShutdownTimeout    time.Duration
+
+	// Предельное время проверок зависимостей в пробе readiness
+	HealthCheckTimeout time.Duration
+
+	// Трассировка OpenTelemetry: none (по умолчанию), otlp или stdout.
+	// Адрес коллектора для otlp задается стандартными переменными OTEL_EXPORTER_OTLP_*
+	TracesExporter string
+	ServiceName    string
+
+	// Логирование: уровень (debug, info, warn, error) и формат (json или text)
+	LogLevel  string
+	LogFormat string
+
+	// Ограничение частоты запросов (0 запросов в минуту - без ограничения).
+	// Хранилище memory - отдельный лимит на каждом экземпляре, postgres - общий для всех экземпляров
+	RateLimitStore         string
+	RateLimitUserPerMinute int
+	RateLimitUserBurst     int
+	RateLimitAuthPerMinute int
+	RateLimitAuthBurst     int
+
+	// Сколько цена товара хранится в кеше; изменение цены на другом экземпляре сервиса
+	// становится видно не позже чем через это время (0 - кеш отключен)
+	CatalogCacheTTL time.Duration
+
+	// Предельное время обработки запроса к базе данных; по истечении запрос отменяется
+	// (0 - без ограничения, запрос отменяется только при отключении клиента)
+	DBRequestTimeout time.Duration
+
+	// Лимиты переводов по умолчанию (0 - без ограничений)
+	TransferMaxAmount           int
+	TransferMaxDailyVolume      int
+	TransferMaxPerHour          int
+	TransferMaxRecipientsPerDay int
+
+	// Фоновый поиск мошеннических схем (интервал 0 - поиск отключен)
+	FraudScanInterval     time.Duration
+	FraudLookback         time.Duration
+	FraudNewAccountAge    time.Duration
+	FraudDrainMinAmount   int
+	FraudFunnelMinSenders int
+	FraudCycleMinAmount   int
+	FraudAutoFreeze       bool
+
+	// Политика паролей и срок действия токенов сброса
+	PasswordMinLength     int
+	PasswordBlocklistFile string
+	PasswordResetTTL      time.Duration
+
+	// Хеширование паролей: bcrypt или argon2id. Хеши другого алгоритма и с другими
+	// параметрами пересчитываются при входе пользователя
+	PasswordHashAlgorithm string
+	BcryptCost            int
+	Argon2Memory          int // КиБ
+	Argon2Iterations      int
+	Argon2Parallelism     int
+
+	// Издатель, отображаемый в приложении-аутентификаторе
+	TOTPIssuer string
+
+	// Сколько проверка активной сессии и состояние пользователя (роль, блокировка, версия токенов)
+	// хранятся в кеше; изменения на других экземплярах сервиса вступают в силу не позднее чем через это время
+	SessionCacheTTL time.Duration
+
+	// Вход через OpenID Connect (пустой OIDCIssuer - вход отключен)
+	OIDCIssuer       string
+	OIDCClientID     string
+	OIDCClientSecret string
+	OIDCRedirectURL  string
+	OIDCScopes       []string
 }
 
 func LoadConfig() *Config {
 	err := godotenv.Load()
 	if err != nil {
-		log.Println("No .env file")
+		slog.Info("no .env file")
 	}
 
 	appEnv := getEnv("APP_ENV", "local")
 
+	dbHost := "localhost"
 	if appEnv == "docker" {
-		log.Println("Running in Docker mode, ignoring .env file")
-		return &Config{
-			DBHost:     getEnv("DB_HOST", "db"),
-			DBPort:     getEnv("DB_PORT", "5432"),
-			DBUser:     getEnv("DB_USER", "postgres"),
-			DBPassword: getEnv("DB_PASSWORD", "postgres"),
-			DBName:     getEnv("DB_NAME", "shop"),
-			JWTSecret:  getEnv("JWT_SECRET", "supersecretkey"),
-		}
+		slog.Info("running in Docker mode, ignoring .env file")
+		dbHost = "db"
 	}
 
 	return &Config{
-		DBHost:     getEnv("DB_HOST", "localhost"),
+		DBHost:     getEnv("DB_HOST", dbHost),
 		DBPort:     getEnv("DB_PORT", "5432"),
 		DBUser:     getEnv("DB_USER", "postgres"),
 		DBPassword: getEnv("DB_PASSWORD", "postgres"),
 		DBName:     getEnv("DB_NAME", "shop"),
 		JWTSecret:  getEnv("JWT_SECRET", "supersecretkey"),
+
+		DBSSLMode:         getEnv("DB_SSLMODE", "disable"),
+		DBSSLRootCert:     getEnv("DB_SSLROOTCERT", ""),
+		DBConnectTimeout:  getEnvDuration("DB_CONNECT_TIMEOUT", 5*time.Second),
+		DBApplicationName: getEnv("DB_APPLICATION_NAME", "avito-shop-service"),
+		DBParams:          getEnv("DB_PARAMS", ""),
+
+		DBMaxOpenConns:    getEnvInt("DB_MAX_OPEN_CONNS", 25),
+		DBMaxIdleConns:    getEnvInt("DB_MAX_IDLE_CONNS", 10),
+		DBConnMaxLifetime: getEnvDuration("DB_CONN_MAX_LIFETIME", 30*time.Minute),
+		DBConnMaxIdleTime: getEnvDuration("DB_CONN_MAX_IDLE_TIME", 5*time.Minute),
+
+		DBConnectRetryTimeout: getEnvDuration("DB_CONNECT_RETRY_TIMEOUT", 30*time.Second),
+
+		ServerPort:         getEnv("SERVER_PORT", "8080"),
+		HTTPReadTimeout:    getEnvDuration("HTTP_READ_TIMEOUT", 10*time.Second),
+		HTTPWriteTimeout:   getEnvDuration("HTTP_WRITE_TIMEOUT", 15*time.Second),
+		HTTPIdleTimeout:    getEnvDuration("HTTP_IDLE_TIMEOUT", 60*time.Second),
+		HTTPMaxHeaderBytes: getEnvInt("HTTP_MAX_HEADER_BYTES", 1<<20),
+		ShutdownDrainDelay: getEnvDuration("SHUTDOWN_DRAIN_DELAY", 5*time.Second),
+		ShutdownTimeout:    getEnvDuration("SHUTDOWN_TIMEOUT", 20*time.Second),
+
+		HealthCheckTimeout: getEnvDuration("HEALTH_CHECK_TIMEOUT", 2*time.Second),
+
+		TracesExporter: getEnv("OTEL_TRACES_EXPORTER", "none"),
+		ServiceName:    getEnv("OTEL_SERVICE_NAME", "avito-shop-service"),
+
+		LogLevel:  getEnv("LOG_LEVEL", "info"),
+		LogFormat: getEnv("LOG_FORMAT", "json"),
+
+		RateLimitStore:         getEnv("RATE_LIMIT_STORE", "memory"),
+		RateLimitUserPerMinute: getEnvInt("RATE_LIMIT_USER_PER_MINUTE", 120),
+		RateLimitUserBurst:     getEnvInt("RATE_LIMIT_USER_BURST", 30),
+		RateLimitAuthPerMinute: getEnvInt("RATE_LIMIT_AUTH_PER_MINUTE", 20),
+		RateLimitAuthBurst:     getEnvInt("RATE_LIMIT_AUTH_BURST", 10),
+
+		CatalogCacheTTL: getEnvDuration("CATALOG_CACHE_TTL", time.Minute),
+
+		DBRequestTimeout: getEnvDuration("DB_REQUEST_TIMEOUT", 5*time.Second),
+
+		TransferMaxAmount:           getEnvInt("TRANSFER_MAX_AMOUNT", 0),
+		TransferMaxDailyVolume:      getEnvInt("TRANSFER_MAX_DAILY_VOLUME", 0),
+		TransferMaxPerHour:          getEnvInt("TRANSFER_MAX_PER_HOUR", 0),
+		TransferMaxRecipientsPerDay: getEnvInt("TRANSFER_MAX_RECIPIENTS_PER_DAY", 0),
+
+		FraudScanInterval:     getEnvDuration("FRAUD_SCAN_INTERVAL", 5*time.Minute),
+		FraudLookback:         getEnvDuration("FRAUD_LOOKBACK", 72*time.Hour),
+		FraudNewAccountAge:    getEnvDuration("FRAUD_NEW_ACCOUNT_AGE", 24*time.Hour),
+		FraudDrainMinAmount:   getEnvInt("FRAUD_DRAIN_MIN_AMOUNT", 900),
+		FraudFunnelMinSenders: getEnvInt("FRAUD_FUNNEL_MIN_SENDERS", 3),
+		FraudCycleMinAmount:   getEnvInt("FRAUD_CYCLE_MIN_AMOUNT", 100),
+		FraudAutoFreeze:       getEnvBool("FRAUD_AUTO_FREEZE", false),
+
+		PasswordMinLength:     getEnvInt("PASSWORD_MIN_LENGTH", 8),
+		PasswordBlocklistFile: getEnv("PASSWORD_BLOCKLIST_FILE", ""),
+		PasswordResetTTL:      getEnvDuration("PASSWORD_RESET_TTL", time.Hour),
+
+		PasswordHashAlgorithm: getEnv("PASSWORD_HASH_ALGORITHM", "bcrypt"),
+		BcryptCost:            getEnvInt("BCRYPT_COST", 10),
+		Argon2Memory:          getEnvInt("ARGON2_MEMORY", 64*1024),
+		Argon2Iterations:      getEnvInt("ARGON2_ITERATIONS", 3),
+		Argon2Parallelism:     getEnvInt("ARGON2_PARALLELISM", 2),
+
+		TOTPIssuer: getEnv("TOTP_ISSUER", "Avito Shop"),
+
+		SessionCacheTTL: getEnvDuration("SESSION_CACHE_TTL", 30*time.Second),
+
+		OIDCIssuer:       getEnv("OIDC_ISSUER", ""),
+		OIDCClientID:     getEnv("OIDC_CLIENT_ID", ""),
+		OIDCClientSecret: getEnv("OIDC_CLIENT_SECRET", ""),
+		OIDCRedirectURL:  getEnv("OIDC_REDIRECT_URL", "http://localhost:8080/api/auth/oidc/callback"),
+		OIDCScopes:       strings.Fields(getEnv("OIDC_SCOPES", "openid profile email")),
 	}
 }
 
@@ -52,3 +221,45 @@ func getEnv(key, fallback string) string {
 	}
 	return fallback
 }
+
+func getEnvInt(key string, fallback int) int {
+	value, exists := os.LookupEnv(key)
+	if !exists {
+		return fallback
+	}
+
+	parsed, err := strconv.Atoi(value)
+	if err != nil {
+		slog.Warn("invalid config value, using default", slog.String("key", key), slog.Int("default", fallback))
+		return fallback
+	}
+	return parsed
+}
+
+func getEnvDuration(key string, fallback time.Duration) time.Duration {
+	value, exists := os.LookupEnv(key)
+	if !exists {
+		return fallback
+	}
+
+	parsed, err := time.ParseDuration(value)
+	if err != nil {
+		slog.Warn("invalid config value, using default", slog.String("key", key), slog.Duration("default", fallback))
+		return fallback
+	}
+	return parsed
+}
+
+func getEnvBool(key string, fallback bool) bool {
+	value, exists := os.LookupEnv(key)
+	if !exists {
+		return fallback
+	}
+
+	parsed, err := strconv.ParseBool(value)
+	if err != nil {
+		slog.Warn("invalid config value, using default", slog.String("key", key), slog.Bool("default", fallback))
+		return fallback
+	}
+	return parsed
+}
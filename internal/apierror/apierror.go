@@ -0,0 +1,70 @@
+// Package apierror формирует ответы об ошибках в формате ErrorResponse из api/schema.yaml
+package apierror
+
+import (
+	"avito-shop-service/internal/service"
+	"encoding/json"
+	"net/http"
+)
+
+// Тело ответа об ошибке
+type Response struct {
+	Errors string `json:"errors"`
+	Code   string `json:"code"`
+}
+
+var statusByKind = map[service.ErrorKind]int{
+	service.KindInvalid:         http.StatusBadRequest,
+	service.KindUnauthenticated: http.StatusUnauthorized,
+	service.KindForbidden:       http.StatusForbidden,
+	service.KindNotFound:        http.StatusNotFound,
+	service.KindConflict:        http.StatusConflict,
+	service.KindUnprocessable:   http.StatusUnprocessableEntity,
+	service.KindLimitExceeded:   http.StatusTooManyRequests,
+	service.KindTimeout:         http.StatusServiceUnavailable,
+	service.KindUpstream:        http.StatusBadGateway,
+}
+
+// Write отвечает ошибкой из каталога сервисного слоя. Неизвестные ошибки отдаются как 500
+// с сообщением fallback, чтобы детали внутренних сбоев не попадали клиенту
+func Write(w http.ResponseWriter, err error, fallback string) {
+	domainErr, ok := service.ClassifyError(err)
+	if !ok {
+		Internal(w, fallback)
+		return
+	}
+
+	status, ok := statusByKind[domainErr.Kind]
+	if !ok {
+		status = http.StatusInternalServerError
+	}
+	WriteStatus(w, status, domainErr.Code, domainErr.Message)
+}
+
+// BadRequest - некорректный запрос, обнаруженный на уровне обработчика
+func BadRequest(w http.ResponseWriter, message string) {
+	WriteStatus(w, http.StatusBadRequest, service.CodeInvalidRequest, message)
+}
+
+// Unauthorized - запрос без подтвержденной личности
+func Unauthorized(w http.ResponseWriter, message string) {
+	WriteStatus(w, http.StatusUnauthorized, service.CodeUnauthorized, message)
+}
+
+// Forbidden - действие запрещено
+func Forbidden(w http.ResponseWriter, message string) {
+	WriteStatus(w, http.StatusForbidden, service.CodeForbidden, message)
+}
+
+// Internal - внутренняя ошибка сервера
+func Internal(w http.ResponseWriter, message string) {
+	WriteStatus(w, http.StatusInternalServerError, service.CodeInternal, message)
+}
+
+// WriteStatus записывает ответ об ошибке с произвольным статусом и кодом
+func WriteStatus(w http.ResponseWriter, status int, code, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(Response{Errors: message, Code: code})
+}
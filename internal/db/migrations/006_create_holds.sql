@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS holds (
+    id SERIAL PRIMARY KEY,
+    user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    amount INT NOT NULL CHECK (amount > 0),
+    captured_amount INT,
+    status TEXT NOT NULL DEFAULT 'active' CHECK (status IN ('active', 'captured', 'released')),
+    reason TEXT NOT NULL DEFAULT '',
+    expires_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP DEFAULT NOW(),
+    settled_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_holds_user_active ON holds (user_id) WHERE status = 'active';
@@ -0,0 +1,14 @@
+ALTER TABLE users ADD COLUMN IF NOT EXISTS role TEXT NOT NULL DEFAULT 'user' CHECK (role IN ('user', 'admin'));
+
+CREATE TABLE IF NOT EXISTS coin_adjustments (
+    id SERIAL PRIMARY KEY,
+    user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    admin_id INT REFERENCES users(id) ON DELETE SET NULL,
+    amount INT NOT NULL CHECK (amount <> 0),
+    reason TEXT NOT NULL CHECK (reason <> ''),
+    reference TEXT NOT NULL CHECK (reference <> ''),
+    created_at TIMESTAMP DEFAULT NOW(),
+    UNIQUE (user_id, reference)
+);
+
+CREATE INDEX IF NOT EXISTS idx_coin_adjustments_user ON coin_adjustments (user_id);
@@ -0,0 +1,3 @@
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS reversal_of INT UNIQUE REFERENCES transactions(id);
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS reversed_by INT REFERENCES users(id) ON DELETE SET NULL;
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS reversal_reason TEXT;
@@ -0,0 +1,12 @@
+-- Индивидуальные лимиты переводов. NULL означает лимит по умолчанию из конфигурации, 0 - без ограничений
+CREATE TABLE IF NOT EXISTS transfer_limits (
+    user_id INT PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    max_amount INT CHECK (max_amount >= 0),
+    max_daily_volume INT CHECK (max_daily_volume >= 0),
+    max_per_hour INT CHECK (max_per_hour >= 0),
+    max_recipients_per_day INT CHECK (max_recipients_per_day >= 0),
+    updated_by INT REFERENCES users(id) ON DELETE SET NULL,
+    updated_at TIMESTAMP DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_transactions_from_user_created ON transactions (from_user_id, created_at);
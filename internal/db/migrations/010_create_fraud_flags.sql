@@ -0,0 +1,16 @@
+ALTER TABLE users ADD COLUMN IF NOT EXISTS transfers_frozen BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE TABLE IF NOT EXISTS fraud_flags (
+    id SERIAL PRIMARY KEY,
+    user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    pattern TEXT NOT NULL,
+    details TEXT NOT NULL DEFAULT '',
+    status TEXT NOT NULL DEFAULT 'open' CHECK (status IN ('open', 'dismissed', 'confirmed')),
+    created_at TIMESTAMP DEFAULT NOW(),
+    reviewed_by INT REFERENCES users(id) ON DELETE SET NULL,
+    reviewed_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_fraud_flags_user_pattern ON fraud_flags (user_id, pattern);
+CREATE INDEX IF NOT EXISTS idx_fraud_flags_open ON fraud_flags (created_at) WHERE status = 'open';
+CREATE INDEX IF NOT EXISTS idx_transactions_created ON transactions (created_at);
@@ -0,0 +1,17 @@
+-- Закрытие аккаунта не удаляет строку пользователя: история переводов контрагентов должна сохраниться
+ALTER TABLE transactions DROP CONSTRAINT IF EXISTS transactions_from_user_id_fkey;
+ALTER TABLE transactions ADD CONSTRAINT transactions_from_user_id_fkey
+    FOREIGN KEY (from_user_id) REFERENCES users(id) ON DELETE RESTRICT;
+ALTER TABLE transactions DROP CONSTRAINT IF EXISTS transactions_to_user_id_fkey;
+ALTER TABLE transactions ADD CONSTRAINT transactions_to_user_id_fkey
+    FOREIGN KEY (to_user_id) REFERENCES users(id) ON DELETE RESTRICT;
+
+CREATE TABLE IF NOT EXISTS account_closures (
+    user_id INT PRIMARY KEY REFERENCES users(id) ON DELETE RESTRICT,
+    disposition TEXT NOT NULL CHECK (disposition IN ('forfeit', 'transfer')),
+    beneficiary_id INT REFERENCES users(id) ON DELETE SET NULL,
+    amount_settled INT NOT NULL,
+    reason TEXT NOT NULL DEFAULT '',
+    closed_by INT REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP DEFAULT NOW()
+);
@@ -0,0 +1,18 @@
+-- Внешние учетные записи (OIDC), связанные с пользователями магазина по issuer и subject
+CREATE TABLE IF NOT EXISTS user_identities (
+    id SERIAL PRIMARY KEY,
+    user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    issuer TEXT NOT NULL,
+    subject TEXT NOT NULL,
+    email TEXT,
+    created_at TIMESTAMP DEFAULT NOW(),
+    UNIQUE (issuer, subject)
+);
+
+-- Незавершенные входы через OIDC: state, PKCE code_verifier и nonce
+CREATE TABLE IF NOT EXISTS oidc_login_states (
+    state TEXT PRIMARY KEY,
+    code_verifier TEXT NOT NULL,
+    nonce TEXT NOT NULL,
+    expires_at TIMESTAMP NOT NULL
+);
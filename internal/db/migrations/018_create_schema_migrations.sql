@@ -0,0 +1,10 @@
+-- Примененные миграции. Каждая следующая миграция добавляет сюда свой номер;
+-- по максимальному номеру проверка готовности сверяет схему с ожидаемой версией
+CREATE TABLE IF NOT EXISTS schema_migrations (
+    version INT PRIMARY KEY,
+    applied_at TIMESTAMP DEFAULT NOW()
+);
+
+INSERT INTO schema_migrations (version)
+SELECT generate_series(1, 18)
+ON CONFLICT (version) DO NOTHING;
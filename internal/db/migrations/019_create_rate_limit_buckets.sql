@@ -0,0 +1,11 @@
+-- Корзины токенов для ограничения частоты запросов, общие для всех экземпляров сервиса
+CREATE TABLE IF NOT EXISTS rate_limit_buckets (
+    key TEXT PRIMARY KEY,
+    tokens DOUBLE PRECISION NOT NULL,
+    allowed BOOLEAN NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_rate_limit_buckets_updated ON rate_limit_buckets (updated_at);
+
+INSERT INTO schema_migrations (version) VALUES (19) ON CONFLICT (version) DO NOTHING;
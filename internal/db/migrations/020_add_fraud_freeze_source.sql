@@ -0,0 +1,5 @@
+-- Блокировка переводов, выставленная анализом мошенничества. Автоматически снимается только она:
+-- ручная блокировка администратора остается после отклонения отметок
+ALTER TABLE users ADD COLUMN IF NOT EXISTS transfers_frozen_by_fraud BOOLEAN NOT NULL DEFAULT FALSE;
+
+INSERT INTO schema_migrations (version) VALUES (20) ON CONFLICT (version) DO NOTHING;
@@ -0,0 +1,6 @@
+-- Неверные коды двухфакторной аутентификации подряд; после предела проверка кодов
+-- приостанавливается до locked_until, чтобы код нельзя было подобрать перебором
+ALTER TABLE user_totp ADD COLUMN IF NOT EXISTS failed_attempts INT NOT NULL DEFAULT 0;
+ALTER TABLE user_totp ADD COLUMN IF NOT EXISTS locked_until TIMESTAMP;
+
+INSERT INTO schema_migrations (version) VALUES (21) ON CONFLICT (version) DO NOTHING;
@@ -0,0 +1,178 @@
+package handlers
+
+import (
+	"avito-shop-service/internal/apierror"
+	"avito-shop-service/internal/service"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+type AccountHandler struct {
+	accountService *service.AccountService
+	authService    *service.AuthService
+}
+
+func NewAccountHandler(accountService *service.AccountService, authService *service.AuthService) *AccountHandler {
+	return &AccountHandler{accountService: accountService, authService: authService}
+}
+
+// Смена состояния аккаунта администратором
+func (h *AccountHandler) SetStatus(w http.ResponseWriter, r *http.Request) {
+	adminID, err := strconv.Atoi(r.Header.Get("UserID"))
+	if err != nil {
+		apierror.Unauthorized(w, "Invalid user ID")
+		return
+	}
+
+	userID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		apierror.BadRequest(w, "Invalid user ID")
+		return
+	}
+
+	var req struct {
+		Status string `json:"status"`
+		Reason string `json:"reason"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apierror.BadRequest(w, "Invalid request")
+		return
+	}
+
+	change, err := h.accountService.SetStatus(r.Context(), adminID, userID, req.Status, req.Reason)
+	if err != nil {
+		apierror.Write(w, err, "Failed to change account status")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(change); err != nil {
+		apierror.Internal(w, "Failed to encode response")
+	}
+}
+
+// Журнал изменений состояния аккаунта
+func (h *AccountHandler) GetStatusHistory(w http.ResponseWriter, r *http.Request) {
+	userID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		apierror.BadRequest(w, "Invalid user ID")
+		return
+	}
+
+	history, err := h.accountService.GetStatusHistory(r.Context(), userID)
+	if err != nil {
+		apierror.Write(w, err, "Failed to get status history")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(history); err != nil {
+		apierror.Internal(w, "Failed to encode response")
+	}
+}
+
+// Закрытие собственного аккаунта; требует подтверждения паролем
+func (h *AccountHandler) CloseOwnAccount(w http.ResponseWriter, r *http.Request) {
+	userID, err := strconv.Atoi(r.Header.Get("UserID"))
+	if err != nil {
+		apierror.Unauthorized(w, "Invalid user ID")
+		return
+	}
+
+	var req struct {
+		Password      string `json:"password"`
+		Disposition   string `json:"disposition"`
+		BeneficiaryID int    `json:"beneficiary_id"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apierror.BadRequest(w, "Invalid request")
+		return
+	}
+
+	if err := h.authService.VerifyPassword(r.Context(), userID, req.Password); err != nil {
+		apierror.Write(w, err, "Failed to verify password")
+		return
+	}
+
+	closure, err := h.accountService.CloseAccount(r.Context(), userID, userID, req.Disposition, req.BeneficiaryID, "", false)
+	if err != nil {
+		apierror.Write(w, err, "Failed to close account")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(closure); err != nil {
+		apierror.Internal(w, "Failed to encode response")
+	}
+}
+
+// Закрытие аккаунта администратором
+func (h *AccountHandler) CloseAccount(w http.ResponseWriter, r *http.Request) {
+	adminID, err := strconv.Atoi(r.Header.Get("UserID"))
+	if err != nil {
+		apierror.Unauthorized(w, "Invalid user ID")
+		return
+	}
+
+	userID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		apierror.BadRequest(w, "Invalid user ID")
+		return
+	}
+
+	var req struct {
+		Disposition   string `json:"disposition"`
+		BeneficiaryID int    `json:"beneficiary_id"`
+		Reason        string `json:"reason"`
+		// Перевести остаток, даже если аккаунт заморожен
+		Override bool `json:"override"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apierror.BadRequest(w, "Invalid request")
+		return
+	}
+
+	closure, err := h.accountService.CloseAccount(r.Context(), adminID, userID, req.Disposition, req.BeneficiaryID, req.Reason, req.Override)
+	if err != nil {
+		apierror.Write(w, err, "Failed to close account")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(closure); err != nil {
+		apierror.Internal(w, "Failed to encode response")
+	}
+}
+
+// Выгрузка всех данных пользователя в виде JSON-архива
+func (h *AccountHandler) Export(w http.ResponseWriter, r *http.Request) {
+	userID, err := strconv.Atoi(r.Header.Get("UserID"))
+	if err != nil {
+		apierror.Unauthorized(w, "Invalid user ID")
+		return
+	}
+
+	export, err := h.accountService.Export(r.Context(), userID)
+	if err != nil {
+		apierror.Write(w, err, "Failed to export user data")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="user-%d-export.json"`, userID))
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(export); err != nil {
+		apierror.Internal(w, "Failed to encode response")
+	}
+}
@@ -0,0 +1,135 @@
+package handlers
+
+import (
+	"avito-shop-service/internal/models"
+	"avito-shop-service/internal/repository"
+	"avito-shop-service/internal/service"
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+// Аккаунты с заранее заданной ошибкой; база данных не нужна
+type stubAccountRepository struct {
+	repository.AccountRepository
+
+	err error
+}
+
+func (s stubAccountRepository) SetStatus(_ context.Context, userID, adminID int, status, reason string) (*models.StatusChange, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	return &models.StatusChange{ID: 1, UserID: userID, OldStatus: models.StatusActive, NewStatus: status, Reason: reason, ChangedBy: adminID}, nil
+}
+
+func (s stubAccountRepository) GetStatusHistory(_ context.Context, userID int) ([]models.StatusChange, error) {
+	return []models.StatusChange{{ID: 1, UserID: userID, NewStatus: models.StatusFrozen}}, s.err
+}
+
+func (s stubAccountRepository) CloseAccount(_ context.Context, closure *models.AccountClosure) error {
+	return s.err
+}
+
+func newStubAccountRouter(t *testing.T, repo stubAccountRepository) *mux.Router {
+	hasher := service.NewBcryptHasher(4)
+	users := newStubUserRepository(t, hasher, &models.User{ID: 2, Username: "alice", PasswordHash: "correct-password"})
+	cache := service.NewUserStateCache(0)
+	authService := service.NewAuthService(users, "supersecretkey", &service.PasswordPolicy{MinLength: 8},
+		hasher, stubTwoFactor{}, stubSessions{}, cache)
+	accountService := service.NewAccountService(repo, users, &stubWalletRepository{}, stubHoldRepository{}, cache)
+	handler := NewAccountHandler(accountService, authService)
+
+	router := mux.NewRouter()
+	router.HandleFunc("/api/me/close", handler.CloseOwnAccount).Methods("POST")
+	router.HandleFunc("/api/admin/users/{id:[0-9]+}/status", handler.SetStatus).Methods("PUT")
+	router.HandleFunc("/api/admin/users/{id:[0-9]+}/status-history", handler.GetStatusHistory).Methods("GET")
+	router.HandleFunc("/api/admin/users/{id:[0-9]+}/close", handler.CloseAccount).Methods("POST")
+	return router
+}
+
+func TestAccountHandlerSetStatus(t *testing.T) {
+	tests := []struct {
+		name       string
+		repoErr    error
+		body       map[string]interface{}
+		wantStatus int
+		wantCode   string
+	}{
+		{name: "frozen", body: map[string]interface{}{"status": models.StatusFrozen, "reason": "investigation"}, wantStatus: http.StatusOK},
+		{name: "unknown status", body: map[string]interface{}{"status": "banned", "reason": "investigation"},
+			wantStatus: http.StatusBadRequest, wantCode: "invalid_status"},
+		{name: "missing reason", body: map[string]interface{}{"status": models.StatusFrozen},
+			wantStatus: http.StatusBadRequest, wantCode: "missing_reason"},
+		{name: "closed account", repoErr: repository.ErrAccountClosed, body: map[string]interface{}{"status": models.StatusActive, "reason": "appeal"},
+			wantStatus: http.StatusConflict, wantCode: "account_closed"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serveAs(newStubAccountRouter(t, stubAccountRepository{err: tt.repoErr}), "PUT", "/api/admin/users/2/status", "1", tt.body)
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+			if tt.wantCode != "" {
+				assert.Equal(t, tt.wantCode, decodeErrorResponse(t, w).Code)
+				return
+			}
+			var change models.StatusChange
+			assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &change))
+			assert.Equal(t, models.StatusFrozen, change.NewStatus)
+			assert.Equal(t, 1, change.ChangedBy)
+		})
+	}
+}
+
+func TestAccountHandlerGetStatusHistory(t *testing.T) {
+	w := serveAs(newStubAccountRouter(t, stubAccountRepository{}), "GET", "/api/admin/users/2/status-history", "1", nil)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var history []models.StatusChange
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &history))
+	assert.Len(t, history, 1)
+}
+
+func TestAccountHandlerCloseAccount(t *testing.T) {
+	tests := []struct {
+		name       string
+		path       string
+		userID     string
+		repoErr    error
+		body       map[string]interface{}
+		wantStatus int
+		wantCode   string
+	}{
+		{name: "own account", path: "/api/me/close", userID: "2",
+			body:       map[string]interface{}{"password": "correct-password", "disposition": models.DispositionForfeit},
+			wantStatus: http.StatusOK},
+		{name: "own account with wrong password", path: "/api/me/close", userID: "2",
+			body:       map[string]interface{}{"password": "wrong-password", "disposition": models.DispositionForfeit},
+			wantStatus: http.StatusForbidden, wantCode: "invalid_password"},
+		{name: "own account already closed", path: "/api/me/close", userID: "2", repoErr: repository.ErrAccountClosed,
+			body:       map[string]interface{}{"password": "correct-password", "disposition": models.DispositionForfeit},
+			wantStatus: http.StatusConflict, wantCode: "account_closed"},
+		{name: "by admin without reason", path: "/api/admin/users/2/close", userID: "1",
+			body:       map[string]interface{}{"disposition": models.DispositionForfeit},
+			wantStatus: http.StatusBadRequest, wantCode: "missing_reason"},
+		{name: "by admin to inactive beneficiary", path: "/api/admin/users/2/close", userID: "1", repoErr: repository.ErrAccountInactive,
+			body:       map[string]interface{}{"disposition": models.DispositionTransfer, "beneficiary_id": 3, "reason": "left the company"},
+			wantStatus: http.StatusUnprocessableEntity, wantCode: "beneficiary_inactive"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serveAs(newStubAccountRouter(t, stubAccountRepository{err: tt.repoErr}), "POST", tt.path, tt.userID, tt.body)
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+			if tt.wantCode != "" {
+				assert.Equal(t, tt.wantCode, decodeErrorResponse(t, w).Code)
+			}
+		})
+	}
+}
@@ -0,0 +1,189 @@
+package handlers
+
+import (
+	"avito-shop-service/internal/apierror"
+	"avito-shop-service/internal/models"
+	"avito-shop-service/internal/service"
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+type AdminHandler struct {
+	adminService *service.AdminService
+	limitService *service.LimitService
+}
+
+func NewAdminHandler(adminService *service.AdminService, limitService *service.LimitService) *AdminHandler {
+	return &AdminHandler{adminService: adminService, limitService: limitService}
+}
+
+// Начисление или списание монет пользователю администратором
+func (h *AdminHandler) AdjustCoins(w http.ResponseWriter, r *http.Request) {
+	adminID, err := strconv.Atoi(r.Header.Get("UserID"))
+	if err != nil {
+		apierror.Unauthorized(w, "Invalid user ID")
+		return
+	}
+
+	var req struct {
+		UserID    int    `json:"user_id"`
+		Amount    int    `json:"amount"`
+		Reason    string `json:"reason"`
+		Reference string `json:"reference"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apierror.BadRequest(w, "Invalid request")
+		return
+	}
+
+	adjustment, err := h.adminService.AdjustCoins(r.Context(), adminID, req.UserID, req.Amount, req.Reason, req.Reference)
+	if err != nil {
+		apierror.Write(w, err, "Admin operation failed")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(adjustment); err != nil {
+		apierror.Internal(w, "Failed to encode response")
+	}
+}
+
+// Массовое начисление монет (например, квартальные премии)
+func (h *AdminHandler) BulkGrant(w http.ResponseWriter, r *http.Request) {
+	adminID, err := strconv.Atoi(r.Header.Get("UserID"))
+	if err != nil {
+		apierror.Unauthorized(w, "Invalid user ID")
+		return
+	}
+
+	var req struct {
+		UserIDs   []int  `json:"user_ids"`
+		Amount    int    `json:"amount"`
+		Reason    string `json:"reason"`
+		Reference string `json:"reference"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apierror.BadRequest(w, "Invalid request")
+		return
+	}
+
+	adjustments, err := h.adminService.BulkGrant(r.Context(), adminID, req.UserIDs, req.Amount, req.Reason, req.Reference)
+	if err != nil {
+		apierror.Write(w, err, "Admin operation failed")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(adjustments); err != nil {
+		apierror.Internal(w, "Failed to encode response")
+	}
+}
+
+// Отмена ошибочного перевода компенсирующей транзакцией
+func (h *AdminHandler) ReverseTransfer(w http.ResponseWriter, r *http.Request) {
+	adminID, err := strconv.Atoi(r.Header.Get("UserID"))
+	if err != nil {
+		apierror.Unauthorized(w, "Invalid user ID")
+		return
+	}
+
+	transactionID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		apierror.BadRequest(w, "Invalid transaction ID")
+		return
+	}
+
+	var req struct {
+		Policy string `json:"policy"`
+		Reason string `json:"reason"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apierror.BadRequest(w, "Invalid request")
+		return
+	}
+
+	reversal, err := h.adminService.ReverseTransfer(r.Context(), adminID, transactionID, req.Policy, req.Reason)
+	if err != nil {
+		apierror.Write(w, err, "Admin operation failed")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(reversal); err != nil {
+		apierror.Internal(w, "Failed to encode response")
+	}
+}
+
+// Действующие лимиты переводов пользователя
+func (h *AdminHandler) GetLimits(w http.ResponseWriter, r *http.Request) {
+	userID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		apierror.BadRequest(w, "Invalid user ID")
+		return
+	}
+
+	limits, err := h.limitService.GetLimits(r.Context(), userID)
+	if err != nil {
+		apierror.Write(w, err, "Failed to get limits")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(limits); err != nil {
+		apierror.Internal(w, "Failed to encode response")
+	}
+}
+
+// Установка индивидуальных лимитов переводов; отсутствующее поле означает лимит по умолчанию
+func (h *AdminHandler) SetLimits(w http.ResponseWriter, r *http.Request) {
+	adminID, err := strconv.Atoi(r.Header.Get("UserID"))
+	if err != nil {
+		apierror.Unauthorized(w, "Invalid user ID")
+		return
+	}
+
+	userID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		apierror.BadRequest(w, "Invalid user ID")
+		return
+	}
+
+	var override models.TransferLimitOverride
+	if err := json.NewDecoder(r.Body).Decode(&override); err != nil {
+		apierror.BadRequest(w, "Invalid request")
+		return
+	}
+
+	if err := h.limitService.SetOverride(r.Context(), adminID, userID, override); err != nil {
+		apierror.Write(w, err, "Failed to set limits")
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// Сброс индивидуальных лимитов переводов
+func (h *AdminHandler) DeleteLimits(w http.ResponseWriter, r *http.Request) {
+	userID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		apierror.BadRequest(w, "Invalid user ID")
+		return
+	}
+
+	if err := h.limitService.DeleteOverride(r.Context(), userID); err != nil {
+		apierror.Write(w, err, "Failed to delete limits")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,140 @@
+package handlers
+
+import (
+	"avito-shop-service/internal/models"
+	"avito-shop-service/internal/repository"
+	"avito-shop-service/internal/service"
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+// Корректировки и отмены с заранее заданными ошибками; база данных не нужна
+type stubAdjustmentRepository struct {
+	err error
+}
+
+func (s stubAdjustmentRepository) CreateAdjustments(_ context.Context, adjustments []models.Adjustment) ([]models.Adjustment, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	for i := range adjustments {
+		adjustments[i].ID = i + 1
+	}
+	return adjustments, nil
+}
+
+type stubReversalRepository struct {
+	err error
+}
+
+func (s stubReversalRepository) ReverseTransfer(_ context.Context, transactionID, _ int, _, _ string) (*models.Transaction, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	return &models.Transaction{ID: 100, ReversalOf: &transactionID}, nil
+}
+
+func newStubAdminRouter(adjustments stubAdjustmentRepository, reversals stubReversalRepository) *mux.Router {
+	limitService := service.NewLimitService(stubLimitRepository{}, models.TransferLimits{MaxAmount: 1000})
+	handler := NewAdminHandler(service.NewAdminService(adjustments, reversals), limitService)
+
+	router := mux.NewRouter()
+	router.HandleFunc("/api/admin/coins/adjust", handler.AdjustCoins).Methods("POST")
+	router.HandleFunc("/api/admin/coins/bulk-grant", handler.BulkGrant).Methods("POST")
+	router.HandleFunc("/api/admin/transactions/{id:[0-9]+}/reverse", handler.ReverseTransfer).Methods("POST")
+	router.HandleFunc("/api/admin/users/{id:[0-9]+}/limits", handler.GetLimits).Methods("GET")
+	return router
+}
+
+func TestAdminHandlerAdjustCoins(t *testing.T) {
+	tests := []struct {
+		name       string
+		repoErr    error
+		path       string
+		body       map[string]interface{}
+		wantStatus int
+		wantCode   string
+	}{
+		{name: "adjusted", path: "/api/admin/coins/adjust",
+			body:       map[string]interface{}{"user_id": 2, "amount": 500, "reason": "bonus", "reference": "INC-42"},
+			wantStatus: http.StatusCreated},
+		{name: "missing reference", path: "/api/admin/coins/adjust",
+			body:       map[string]interface{}{"user_id": 2, "amount": 500, "reason": "bonus"},
+			wantStatus: http.StatusBadRequest, wantCode: "missing_reason"},
+		{name: "duplicate reference", repoErr: repository.ErrDuplicateAdjustment, path: "/api/admin/coins/adjust",
+			body:       map[string]interface{}{"user_id": 2, "amount": 500, "reason": "bonus", "reference": "INC-42"},
+			wantStatus: http.StatusConflict, wantCode: "duplicate_reference"},
+		{name: "closed account", repoErr: repository.ErrAccountClosed, path: "/api/admin/coins/adjust",
+			body:       map[string]interface{}{"user_id": 2, "amount": 500, "reason": "bonus", "reference": "INC-42"},
+			wantStatus: http.StatusConflict, wantCode: "account_closed"},
+		{name: "bulk grant", path: "/api/admin/coins/bulk-grant",
+			body:       map[string]interface{}{"user_ids": []int{2, 3}, "amount": 100, "reason": "Q3 bonus", "reference": "bonus-q3"},
+			wantStatus: http.StatusCreated},
+		{name: "bulk deduction", path: "/api/admin/coins/bulk-grant",
+			body:       map[string]interface{}{"user_ids": []int{2}, "amount": -100, "reason": "Q3 bonus", "reference": "bonus-q3"},
+			wantStatus: http.StatusBadRequest, wantCode: service.CodeInvalidAmount},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := newStubAdminRouter(stubAdjustmentRepository{err: tt.repoErr}, stubReversalRepository{})
+			w := serveAs(router, "POST", tt.path, "1", tt.body)
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+			if tt.wantCode != "" {
+				assert.Equal(t, tt.wantCode, decodeErrorResponse(t, w).Code)
+			}
+		})
+	}
+}
+
+func TestAdminHandlerReverseTransfer(t *testing.T) {
+	tests := []struct {
+		name       string
+		repoErr    error
+		body       map[string]interface{}
+		wantStatus int
+		wantCode   string
+	}{
+		{name: "reversed", body: map[string]interface{}{"reason": "wrong recipient"}, wantStatus: http.StatusCreated},
+		{name: "unknown policy", body: map[string]interface{}{"policy": "lenient", "reason": "wrong recipient"},
+			wantStatus: http.StatusBadRequest, wantCode: "invalid_reversal_policy"},
+		{name: "transaction not found", repoErr: repository.ErrTransactionNotFound, body: map[string]interface{}{"reason": "wrong recipient"},
+			wantStatus: http.StatusNotFound, wantCode: "transaction_not_found"},
+		{name: "already reversed", repoErr: repository.ErrAlreadyReversed, body: map[string]interface{}{"reason": "wrong recipient"},
+			wantStatus: http.StatusConflict, wantCode: "already_reversed"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := newStubAdminRouter(stubAdjustmentRepository{}, stubReversalRepository{err: tt.repoErr})
+			w := serveAs(router, "POST", "/api/admin/transactions/42/reverse", "1", tt.body)
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+			if tt.wantCode != "" {
+				assert.Equal(t, tt.wantCode, decodeErrorResponse(t, w).Code)
+				return
+			}
+			var reversal models.Transaction
+			assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &reversal))
+			if assert.NotNil(t, reversal.ReversalOf) {
+				assert.Equal(t, 42, *reversal.ReversalOf)
+			}
+		})
+	}
+}
+
+// Без индивидуальных настроек возвращаются лимиты по умолчанию
+func TestAdminHandlerGetLimits(t *testing.T) {
+	w := serveAs(newStubAdminRouter(stubAdjustmentRepository{}, stubReversalRepository{}), "GET", "/api/admin/users/2/limits", "1", nil)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var limits models.TransferLimits
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &limits))
+	assert.Equal(t, 1000, limits.MaxAmount)
+}
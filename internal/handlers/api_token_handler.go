@@ -0,0 +1,102 @@
+package handlers
+
+import (
+	"avito-shop-service/internal/apierror"
+	"avito-shop-service/internal/models"
+	"avito-shop-service/internal/service"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+type APITokenHandler struct {
+	tokenService *service.APITokenService
+}
+
+func NewAPITokenHandler(tokenService *service.APITokenService) *APITokenHandler {
+	return &APITokenHandler{tokenService: tokenService}
+}
+
+// Выпуск персонального токена; значение токена показывается только в этом ответе
+func (h *APITokenHandler) CreateToken(w http.ResponseWriter, r *http.Request) {
+	userID, err := strconv.Atoi(r.Header.Get("UserID"))
+	if err != nil {
+		apierror.Unauthorized(w, "Invalid user ID")
+		return
+	}
+
+	var req struct {
+		Name          string   `json:"name"`
+		Scopes        []string `json:"scopes"`
+		ExpiresInDays int      `json:"expires_in_days"` // 0 - без срока действия
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apierror.BadRequest(w, "Invalid request")
+		return
+	}
+
+	ttl := time.Duration(req.ExpiresInDays) * 24 * time.Hour
+	token, raw, err := h.tokenService.CreateToken(r.Context(), userID, req.Name, req.Scopes, ttl)
+	if err != nil {
+		apierror.Write(w, err, "Failed to create token")
+		return
+	}
+
+	resp := struct {
+		Token string `json:"token"`
+		*models.APIToken
+	}{Token: raw, APIToken: token}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		apierror.Internal(w, "Failed to encode response")
+	}
+}
+
+// Список персональных токенов пользователя
+func (h *APITokenHandler) GetTokens(w http.ResponseWriter, r *http.Request) {
+	userID, err := strconv.Atoi(r.Header.Get("UserID"))
+	if err != nil {
+		apierror.Unauthorized(w, "Invalid user ID")
+		return
+	}
+
+	tokens, err := h.tokenService.GetTokens(r.Context(), userID)
+	if err != nil {
+		apierror.Write(w, err, "Failed to get tokens")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(tokens); err != nil {
+		apierror.Internal(w, "Failed to encode response")
+	}
+}
+
+// Отзыв персонального токена
+func (h *APITokenHandler) RevokeToken(w http.ResponseWriter, r *http.Request) {
+	userID, err := strconv.Atoi(r.Header.Get("UserID"))
+	if err != nil {
+		apierror.Unauthorized(w, "Invalid user ID")
+		return
+	}
+
+	tokenID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		apierror.BadRequest(w, "Invalid token ID")
+		return
+	}
+
+	if err := h.tokenService.RevokeToken(r.Context(), userID, tokenID); err != nil {
+		apierror.Write(w, err, "Failed to revoke token")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
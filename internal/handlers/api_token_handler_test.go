@@ -0,0 +1,98 @@
+package handlers
+
+import (
+	"avito-shop-service/internal/models"
+	"avito-shop-service/internal/repository"
+	"avito-shop-service/internal/service"
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+// Персональные токены с заранее заданной ошибкой; база данных не нужна
+type stubAPITokenRepository struct {
+	repository.APITokenRepository
+
+	err error
+}
+
+func (s stubAPITokenRepository) CreateToken(_ context.Context, token *models.APIToken, _ string) error {
+	token.ID = 3
+	return s.err
+}
+
+func (s stubAPITokenRepository) GetTokens(_ context.Context, userID int) ([]models.APIToken, error) {
+	return []models.APIToken{{ID: 3, UserID: userID, Name: "ci", Scopes: []string{models.ScopeInfoRead}}}, s.err
+}
+
+func (s stubAPITokenRepository) RevokeToken(_ context.Context, _, _ int) error {
+	return s.err
+}
+
+func newStubAPITokenRouter(repo stubAPITokenRepository) *mux.Router {
+	handler := NewAPITokenHandler(service.NewAPITokenService(repo))
+
+	router := mux.NewRouter()
+	router.HandleFunc("/api/me/tokens", handler.CreateToken).Methods("POST")
+	router.HandleFunc("/api/me/tokens", handler.GetTokens).Methods("GET")
+	router.HandleFunc("/api/me/tokens/{id:[0-9]+}", handler.RevokeToken).Methods("DELETE")
+	return router
+}
+
+func TestAPITokenHandlerCreateToken(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       map[string]interface{}
+		wantStatus int
+		wantCode   string
+	}{
+		{name: "created", body: map[string]interface{}{"name": "ci", "scopes": []string{models.ScopeInfoRead}, "expires_in_days": 30},
+			wantStatus: http.StatusCreated},
+		{name: "unknown scope", body: map[string]interface{}{"name": "ci", "scopes": []string{"everything"}},
+			wantStatus: http.StatusBadRequest, wantCode: "invalid_scope"},
+		{name: "missing name", body: map[string]interface{}{"scopes": []string{models.ScopeInfoRead}},
+			wantStatus: http.StatusBadRequest, wantCode: "invalid_token_name"},
+		{name: "negative lifetime", body: map[string]interface{}{"name": "ci", "scopes": []string{models.ScopeInfoRead}, "expires_in_days": -1},
+			wantStatus: http.StatusBadRequest, wantCode: "invalid_token_ttl"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serveAs(newStubAPITokenRouter(stubAPITokenRepository{}), "POST", "/api/me/tokens", "2", tt.body)
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+			if tt.wantCode != "" {
+				assert.Equal(t, tt.wantCode, decodeErrorResponse(t, w).Code)
+				return
+			}
+			var resp struct {
+				Token string `json:"token"`
+				models.APIToken
+			}
+			assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+			assert.True(t, strings.HasPrefix(resp.Token, resp.Prefix), resp.Token)
+			assert.Equal(t, 3, resp.ID)
+			assert.NotNil(t, resp.ExpiresAt)
+		})
+	}
+}
+
+func TestAPITokenHandlerListAndRevoke(t *testing.T) {
+	w := serveAs(newStubAPITokenRouter(stubAPITokenRepository{}), "GET", "/api/me/tokens", "2", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	var tokens []models.APIToken
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &tokens))
+	assert.Len(t, tokens, 1)
+
+	w = serveAs(newStubAPITokenRouter(stubAPITokenRepository{}), "DELETE", "/api/me/tokens/3", "2", nil)
+	assert.Equal(t, http.StatusNoContent, w.Code)
+
+	w = serveAs(newStubAPITokenRouter(stubAPITokenRepository{err: repository.ErrAPITokenNotFound}), "DELETE", "/api/me/tokens/4", "2", nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Equal(t, "api_token_not_found", decodeErrorResponse(t, w).Code)
+}
@@ -1,8 +1,11 @@
 package handlers
 
 import (
+	"avito-shop-service/internal/apierror"
+	"avito-shop-service/internal/repository"
 	"avito-shop-service/internal/service"
 	"encoding/json"
+	"errors"
 	"net/http"
 )
 
@@ -21,42 +24,82 @@ func (h *AuthHandler) Auth(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid request", http.StatusBadRequest)
+		apierror.BadRequest(w, "Invalid request")
 		return
 	}
 
 	// Проверка на пустое имя пользователя и пароль
 	if req.Username == "" || req.Password == "" {
-		http.Error(w, "Username and password cannot be empty", http.StatusBadRequest)
+		apierror.BadRequest(w, "Username and password cannot be empty")
 		return
 	}
 
 	// Попытка авторизации
-	token, err := h.authService.Login(req.Username, req.Password)
+	result, err := h.authService.Login(r.Context(), req.Username, req.Password, clientInfo(r))
 	if err == nil {
-		// Если авторизация успешна, возвращаем токен
-		if err := json.NewEncoder(w).Encode(map[string]string{"token": token}); err != nil {
-			http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		// Если авторизация успешна, возвращаем токен (или токен подтверждения второго фактора)
+		if err := json.NewEncoder(w).Encode(result); err != nil {
+			apierror.Internal(w, "Failed to encode response")
 		}
 
 		return
 	}
 
-	// Если ошибка (пользователя не сущетвует - первая аутентификация), регистрируем нового пользователя
-	if err := h.authService.Register(req.Username, req.Password); err != nil {
-		http.Error(w, "User registration failed", http.StatusConflict)
+	// Пользователь существует, пароль неверный: всегда 401, до регистрации дело не доходит,
+	// иначе ошибки регистрации (занятое имя, правила паролей) выдали бы существование аккаунта
+	if errors.Is(err, service.ErrInvalidPassword) {
+		apierror.Write(w, service.ErrInvalidCredentials, "Login failed")
+		return
+	}
+
+	// Регистрация только при первой аутентификации (пользователя не существует); остальные
+	// ошибки (заблокированный аккаунт, сбой базы или хранилища сессий) возвращаются как есть
+	if !errors.Is(err, repository.ErrUserNotFound) {
+		apierror.Write(w, err, "Login failed")
+		return
+	}
+
+	if err := h.authService.Register(r.Context(), req.Username, req.Password); err != nil {
+		// Имя заняли между входом и регистрацией: это тоже неверный пароль существующего пользователя
+		if errors.Is(err, repository.ErrUsernameTaken) {
+			err = service.ErrInvalidCredentials
+		}
+		apierror.Write(w, err, "User registration failed")
 		return
 	}
 
 	// После успешной регистрации авторизуем пользователя и возвращаем токен
-	token, err = h.authService.Login(req.Username, req.Password)
+	result, err = h.authService.Login(r.Context(), req.Username, req.Password, clientInfo(r))
 	if err != nil {
-		http.Error(w, "Error during login after registration", http.StatusInternalServerError)
+		apierror.Write(w, err, "Error during login after registration")
 		return
 	}
 
-	if err := json.NewEncoder(w).Encode(map[string]string{"token": token}); err != nil {
-		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+	if err := json.NewEncoder(w).Encode(result); err != nil {
+		apierror.Internal(w, "Failed to encode response")
 	}
 
 }
+
+// Второй шаг входа: обмен токена подтверждения и кода на JWT-токен
+func (h *AuthHandler) CompleteTwoFactor(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		ChallengeToken string `json:"challenge_token"`
+		Code           string `json:"code"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apierror.BadRequest(w, "Invalid request")
+		return
+	}
+
+	token, err := h.authService.CompleteTwoFactor(r.Context(), req.ChallengeToken, req.Code, clientInfo(r))
+	if err != nil {
+		apierror.Write(w, err, "Failed to verify two-factor code")
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(map[string]string{"token": token}); err != nil {
+		apierror.Internal(w, "Failed to encode response")
+	}
+}
@@ -0,0 +1,181 @@
+package handlers
+
+import (
+	"avito-shop-service/internal/models"
+	"avito-shop-service/internal/repository"
+	"avito-shop-service/internal/service"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Пользователи в памяти; база данных не нужна
+type stubUserRepository struct {
+	repository.UserRepositoryInterface
+
+	mu    sync.Mutex
+	users map[string]*models.User
+}
+
+func (s *stubUserRepository) CreateUser(_ context.Context, user *models.User) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.users[user.Username]; ok {
+		return repository.ErrUsernameTaken
+	}
+	user.ID, user.Status = len(s.users)+1, models.StatusActive
+	s.users[user.Username] = user
+	return nil
+}
+
+func (s *stubUserRepository) GetUserByUsername(_ context.Context, username string) (*models.User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.users[username], nil
+}
+
+func (s *stubUserRepository) GetUserByID(_ context.Context, userID int) (*models.User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, user := range s.users {
+		if user.ID == userID {
+			return user, nil
+		}
+	}
+	return nil, nil
+}
+
+func (s *stubUserRepository) UpdatePassword(_ context.Context, userID int, passwordHash string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, user := range s.users {
+		if user.ID == userID {
+			user.PasswordHash = passwordHash
+			user.TokenVersion++
+			return nil
+		}
+	}
+	return repository.ErrUserNotFound
+}
+
+type stubTwoFactor struct{}
+
+func (stubTwoFactor) IsEnabled(_ context.Context, _ int) (bool, error) { return false, nil }
+func (stubTwoFactor) Verify(_ context.Context, _ int, _ string) error {
+	return service.ErrInvalidTOTPCode
+}
+
+// Хранилище сессий; err имитирует его недоступность
+type stubSessions struct {
+	err error
+}
+
+func (s stubSessions) Create(_ context.Context, _ int, _ models.ClientInfo, _ time.Time) (string, error) {
+	if s.err != nil {
+		return "", s.err
+	}
+	return "sid", nil
+}
+func (stubSessions) Validate(_ context.Context, _ string, _ int) error { return nil }
+
+// Активные пользователи в памяти; в PasswordHash передается пароль, он сохраняется хешем
+func newStubUserRepository(t *testing.T, hasher service.PasswordHasher, users ...*models.User) *stubUserRepository {
+	t.Helper()
+	repo := &stubUserRepository{users: map[string]*models.User{}}
+	for _, user := range users {
+		if user.PasswordHash != "" {
+			hash, err := hasher.Hash(user.PasswordHash)
+			require.NoError(t, err)
+			user.PasswordHash = hash
+		}
+		user.Status = models.StatusActive
+		repo.users[user.Username] = user
+	}
+	return repo
+}
+
+func newStubAuthService(t *testing.T, sessions stubSessions, users ...*models.User) *service.AuthService {
+	hasher := service.NewBcryptHasher(4)
+	return service.NewAuthService(newStubUserRepository(t, hasher, users...), "supersecretkey", &service.PasswordPolicy{MinLength: 8},
+		hasher, stubTwoFactor{}, sessions, service.NewUserStateCache(0))
+}
+
+func newStubAuthHandler(t *testing.T, sessions stubSessions, users ...*models.User) *AuthHandler {
+	return NewAuthHandler(newStubAuthService(t, sessions, users...))
+}
+
+// Ответ для существующего пользователя с неверным паролем не зависит от пароля
+// и не отличается от ответа на неверный пароль: ошибки регистрации не раскрывают аккаунт
+func TestAuthHandlerWrongPassword(t *testing.T) {
+	tests := []struct {
+		name     string
+		username string
+		password string
+	}{
+		{name: "wrong password", username: "alice", password: "wrong-password"},
+		{name: "wrong password shorter than policy", username: "alice", password: "short"},
+		{name: "account without password (single sign-on)", username: "sso-bob", password: "any-password"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := newStubAuthHandler(t, stubSessions{},
+				&models.User{ID: 1, Username: "alice", PasswordHash: "correct-password"},
+				&models.User{ID: 2, Username: "sso-bob"},
+			)
+
+			body, _ := json.Marshal(map[string]string{"username": tt.username, "password": tt.password})
+			w := httptest.NewRecorder()
+			handler.Auth(w, httptest.NewRequest("POST", "/api/auth", bytes.NewReader(body)))
+
+			assert.Equal(t, http.StatusUnauthorized, w.Code)
+			assert.Equal(t, "invalid_credentials", decodeErrorResponse(t, w).Code)
+		})
+	}
+}
+
+func TestAuthHandlerRegistersNewUser(t *testing.T) {
+	tests := []struct {
+		name       string
+		password   string
+		wantStatus int
+	}{
+		{name: "valid password", password: "new-password", wantStatus: http.StatusOK},
+		{name: "password too short", password: "short", wantStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := newStubAuthHandler(t, stubSessions{})
+
+			body, _ := json.Marshal(map[string]string{"username": "carol", "password": tt.password})
+			w := httptest.NewRecorder()
+			handler.Auth(w, httptest.NewRequest("POST", "/api/auth", bytes.NewReader(body)))
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+		})
+	}
+}
+
+// Сбой при входе с верным паролем - внутренняя ошибка, а не неверный пароль и не попытка регистрации
+func TestAuthHandlerLoginFailure(t *testing.T) {
+	handler := newStubAuthHandler(t, stubSessions{err: errors.New("session store is down")},
+		&models.User{ID: 1, Username: "alice", PasswordHash: "correct-password"},
+	)
+
+	body, _ := json.Marshal(map[string]string{"username": "alice", "password": "correct-password"})
+	w := httptest.NewRecorder()
+	handler.Auth(w, httptest.NewRequest("POST", "/api/auth", bytes.NewReader(body)))
+
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.Equal(t, service.CodeInternal, decodeErrorResponse(t, w).Code)
+}
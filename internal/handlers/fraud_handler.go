@@ -0,0 +1,110 @@
+package handlers
+
+import (
+	"avito-shop-service/internal/apierror"
+	"avito-shop-service/internal/service"
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+type FraudHandler struct {
+	fraudService *service.FraudService
+}
+
+func NewFraudHandler(fraudService *service.FraudService) *FraudHandler {
+	return &FraudHandler{fraudService: fraudService}
+}
+
+// Список отметок о подозрительной активности (?status=open|dismissed|confirmed)
+func (h *FraudHandler) GetFlags(w http.ResponseWriter, r *http.Request) {
+	flags, err := h.fraudService.GetFlags(r.Context(), r.URL.Query().Get("status"))
+	if err != nil {
+		apierror.Write(w, err, "Failed to get fraud flags")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(flags); err != nil {
+		apierror.Internal(w, "Failed to encode response")
+	}
+}
+
+// Решение администратора по отметке
+func (h *FraudHandler) ReviewFlag(w http.ResponseWriter, r *http.Request) {
+	adminID, err := strconv.Atoi(r.Header.Get("UserID"))
+	if err != nil {
+		apierror.Unauthorized(w, "Invalid user ID")
+		return
+	}
+
+	flagID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		apierror.BadRequest(w, "Invalid flag ID")
+		return
+	}
+
+	var req struct {
+		Decision string `json:"decision"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apierror.BadRequest(w, "Invalid request")
+		return
+	}
+
+	flag, err := h.fraudService.ReviewFlag(r.Context(), adminID, flagID, req.Decision)
+	if err != nil {
+		apierror.Write(w, err, "Failed to review fraud flag")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(flag); err != nil {
+		apierror.Internal(w, "Failed to encode response")
+	}
+}
+
+// Внеплановый запуск анализа переводов
+func (h *FraudHandler) Scan(w http.ResponseWriter, r *http.Request) {
+	flagged, err := h.fraudService.Scan(r.Context())
+	if err != nil {
+		apierror.Write(w, err, "Fraud scan failed")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(map[string]int{"flagged": flagged}); err != nil {
+		apierror.Internal(w, "Failed to encode response")
+	}
+}
+
+// Ручная блокировка или разблокировка исходящих переводов пользователя
+func (h *FraudHandler) SetTransfersFrozen(w http.ResponseWriter, r *http.Request) {
+	userID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		apierror.BadRequest(w, "Invalid user ID")
+		return
+	}
+
+	var req struct {
+		Frozen bool `json:"frozen"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apierror.BadRequest(w, "Invalid request")
+		return
+	}
+
+	if err := h.fraudService.SetTransfersFrozen(r.Context(), userID, req.Frozen); err != nil {
+		apierror.Write(w, err, "Failed to update transfers freeze")
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
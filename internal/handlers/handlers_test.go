@@ -2,10 +2,10 @@ package handlers
 
 import (
 	"avito-shop-service/config"
-	"avito-shop-service/internal/middleware"
+	"avito-shop-service/internal/ratelimit"
 	"avito-shop-service/internal/repository"
-	"avito-shop-service/internal/service"
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -18,36 +18,16 @@ import (
 
 func setupRouter() *mux.Router {
 	cfg := config.LoadConfig()
-	db := repository.ConnectDB(cfg)
-
-	// Инициализируем репозитории
-	userRepo := repository.NewUserRepository(db)
-	walletRepo := repository.NewPostgresWalletRepository(db)
-
-	// Инициализируем сервисы
-	authService := service.NewAuthService(userRepo, cfg.JWTSecret)
-	walletService := service.NewWalletService(walletRepo)
-
-	// Инициализируем обработчики
-	authHandler := NewAuthHandler(authService)
-	walletHandler := NewWalletHandler(walletService)
-
-	router := mux.NewRouter()
-
-	// Применяем middleware для защищенных маршрутов
-	// Регистрация и вход не требуют аутентификации
-	router.HandleFunc("/api/auth", authHandler.Auth).Methods("POST")
-
-	// Защищенные маршруты с middleware
-	protected := router.PathPrefix("/api").Subrouter()
-	protected.Use(middleware.AuthMiddleware(authService))
-
-	// Роуты, которые требуют аутентификации
-	protected.HandleFunc("/info", walletHandler.GetInfo).Methods("GET")
-	protected.HandleFunc("/sendCoin", walletHandler.Transfer).Methods("POST")
-	protected.HandleFunc("/buy/{item}", walletHandler.BuyItem).Methods("POST")
+	db, err := repository.ConnectDB(context.Background(), cfg)
+	if err != nil {
+		panic(err)
+	}
 
-	return router
+	services, err := NewServices(db, cfg)
+	if err != nil {
+		panic(err)
+	}
+	return NewRouter(cfg, services, ratelimit.NewMemoryStore())
 }
 
 // Получение токена
@@ -121,3 +101,65 @@ func TestGetInfo(t *testing.T) {
 
 	assert.Equal(t, http.StatusOK, w.Code)
 }
+
+// Задержка /api/info под параллельной нагрузкой: отдельные запросы, как раньше, и один
+// запрос со снимком. Нужна запущенная база данных:
+// go test ./internal/handlers -run '^$' -bench GetInfo -cpu 8
+func BenchmarkGetInfo(b *testing.B) {
+	getValidToken()
+
+	cfg := config.LoadConfig()
+	db, err := repository.ConnectDB(context.Background(), cfg)
+	if err != nil {
+		panic(err)
+	}
+	defer db.Close()
+
+	user, err := repository.NewUserRepository(db).GetUserByUsername(context.Background(), "testuser111")
+	if err != nil {
+		b.Fatal(err)
+	}
+	repo := repository.NewPostgresWalletRepository(db)
+
+	separate := func(ctx context.Context) error {
+		if _, err := repo.GetBalance(ctx, user.ID); err != nil {
+			return err
+		}
+		if _, err := repo.GetAvailableBalance(ctx, user.ID); err != nil {
+			return err
+		}
+		if _, err := repo.GetInventory(ctx, user.ID); err != nil {
+			return err
+		}
+		if _, err := repo.GetTransactions(ctx, user.ID); err != nil {
+			return err
+		}
+		_, err := repo.GetAdjustments(ctx, user.ID)
+		return err
+	}
+	snapshot := func(ctx context.Context) error {
+		_, err := repo.GetInfo(ctx, user.ID)
+		return err
+	}
+
+	benchmarks := []struct {
+		name  string
+		fetch func(ctx context.Context) error
+	}{
+		{name: "separate", fetch: separate},
+		{name: "snapshot", fetch: snapshot},
+	}
+
+	for _, bm := range benchmarks {
+		b.Run(bm.name, func(b *testing.B) {
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					if err := bm.fetch(context.Background()); err != nil {
+						b.Error(err)
+						return
+					}
+				}
+			})
+		})
+	}
+}
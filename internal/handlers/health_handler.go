@@ -0,0 +1,38 @@
+package handlers
+
+import (
+	"avito-shop-service/internal/models"
+	"avito-shop-service/internal/service"
+	"encoding/json"
+	"net/http"
+)
+
+type HealthHandler struct {
+	healthService *service.HealthService
+}
+
+func NewHealthHandler(healthService *service.HealthService) *HealthHandler {
+	return &HealthHandler{healthService: healthService}
+}
+
+// Проба liveness: процесс жив и отвечает
+func (h *HealthHandler) Liveness(w http.ResponseWriter, _ *http.Request) {
+	writeHealthReport(w, h.healthService.Liveness())
+}
+
+// Проба readiness: сервис готов принимать трафик
+func (h *HealthHandler) Readiness(w http.ResponseWriter, r *http.Request) {
+	writeHealthReport(w, h.healthService.Readiness(r.Context()))
+}
+
+func writeHealthReport(w http.ResponseWriter, report models.HealthReport) {
+	status := http.StatusOK
+	if !report.Healthy() {
+		status = http.StatusServiceUnavailable
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(report)
+}
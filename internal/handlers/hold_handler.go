@@ -0,0 +1,142 @@
+package handlers
+
+import (
+	"avito-shop-service/internal/apierror"
+	"avito-shop-service/internal/service"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+type HoldHandler struct {
+	holdService *service.HoldService
+}
+
+func NewHoldHandler(holdService *service.HoldService) *HoldHandler {
+	return &HoldHandler{holdService: holdService}
+}
+
+// Создание холда на часть баланса
+func (h *HoldHandler) PlaceHold(w http.ResponseWriter, r *http.Request) {
+	userID, err := strconv.Atoi(r.Header.Get("UserID"))
+	if err != nil {
+		apierror.Unauthorized(w, "Invalid user ID")
+		return
+	}
+
+	var req struct {
+		Amount     int    `json:"amount"`
+		TTLSeconds int    `json:"ttl_seconds"`
+		Reason     string `json:"reason"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apierror.BadRequest(w, "Invalid request")
+		return
+	}
+
+	hold, err := h.holdService.PlaceHold(r.Context(), userID, req.Amount, time.Duration(req.TTLSeconds)*time.Second, req.Reason)
+	if err != nil {
+		apierror.Write(w, err, "Hold operation failed")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(hold); err != nil {
+		apierror.Internal(w, "Failed to encode response")
+	}
+}
+
+// Список холдов пользователя
+func (h *HoldHandler) GetHolds(w http.ResponseWriter, r *http.Request) {
+	userID, err := strconv.Atoi(r.Header.Get("UserID"))
+	if err != nil {
+		apierror.Unauthorized(w, "Invalid user ID")
+		return
+	}
+
+	holds, err := h.holdService.GetHolds(r.Context(), userID)
+	if err != nil {
+		apierror.Write(w, err, "Failed to get holds")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(holds); err != nil {
+		apierror.Internal(w, "Failed to encode response")
+	}
+}
+
+// Списание холда: перевод пользователю (to_user_id, amount) или покупка товара (item, quantity)
+func (h *HoldHandler) Capture(w http.ResponseWriter, r *http.Request) {
+	userID, err := strconv.Atoi(r.Header.Get("UserID"))
+	if err != nil {
+		apierror.Unauthorized(w, "Invalid user ID")
+		return
+	}
+
+	holdID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		apierror.BadRequest(w, "Invalid hold ID")
+		return
+	}
+
+	var req struct {
+		ToUserID int    `json:"to_user_id"`
+		Amount   int    `json:"amount"`
+		Item     string `json:"item"`
+		Quantity int    `json:"quantity"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apierror.BadRequest(w, "Invalid request")
+		return
+	}
+
+	switch {
+	case req.Item != "" && req.ToUserID == 0:
+		if req.Quantity == 0 {
+			req.Quantity = 1
+		}
+		err = h.holdService.CapturePurchase(r.Context(), userID, holdID, req.Item, req.Quantity)
+	case req.ToUserID != 0 && req.Item == "":
+		err = h.holdService.CaptureTransfer(r.Context(), userID, holdID, req.ToUserID, req.Amount)
+	default:
+		apierror.BadRequest(w, "Either to_user_id or item must be specified")
+		return
+	}
+
+	if err != nil {
+		apierror.Write(w, err, "Hold operation failed")
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// Снятие холда
+func (h *HoldHandler) Release(w http.ResponseWriter, r *http.Request) {
+	userID, err := strconv.Atoi(r.Header.Get("UserID"))
+	if err != nil {
+		apierror.Unauthorized(w, "Invalid user ID")
+		return
+	}
+
+	holdID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		apierror.BadRequest(w, "Invalid hold ID")
+		return
+	}
+
+	if err := h.holdService.Release(r.Context(), userID, holdID); err != nil {
+		apierror.Write(w, err, "Hold operation failed")
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
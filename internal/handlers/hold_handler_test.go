@@ -0,0 +1,121 @@
+package handlers
+
+import (
+	"avito-shop-service/internal/models"
+	"avito-shop-service/internal/repository"
+	"avito-shop-service/internal/service"
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+// Холды с заранее заданной ошибкой; база данных не нужна
+type stubHoldRepository struct {
+	err error
+}
+
+func (s stubHoldRepository) CreateHold(_ context.Context, userID, amount int, reason string, expiresAt time.Time) (*models.Hold, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	return &models.Hold{ID: 7, UserID: userID, Amount: amount, Status: models.HoldStatusActive, Reason: reason, ExpiresAt: expiresAt}, nil
+}
+
+func (s stubHoldRepository) GetHolds(_ context.Context, _ int) ([]models.Hold, error) {
+	return nil, s.err
+}
+
+func (s stubHoldRepository) CaptureTransfer(_ context.Context, _, _, _, _ int, _ models.TransferCheck) error {
+	return s.err
+}
+
+func (s stubHoldRepository) CapturePurchase(_ context.Context, _, _ int, _ string, _ int) error {
+	return s.err
+}
+
+func (s stubHoldRepository) ReleaseHold(_ context.Context, _, _ int) error {
+	return s.err
+}
+
+func newStubHoldRouter(repo stubHoldRepository) *mux.Router {
+	limitService := service.NewLimitService(stubLimitRepository{}, models.TransferLimits{})
+	handler := NewHoldHandler(service.NewHoldService(repo, &stubWalletRepository{balance: 1000}, limitService))
+
+	router := mux.NewRouter()
+	router.HandleFunc("/api/holds", handler.PlaceHold).Methods("POST")
+	router.HandleFunc("/api/holds/{id:[0-9]+}/capture", handler.Capture).Methods("POST")
+	router.HandleFunc("/api/holds/{id:[0-9]+}/release", handler.Release).Methods("POST")
+	return router
+}
+
+func TestHoldHandlerPlaceHold(t *testing.T) {
+	tests := []struct {
+		name       string
+		repoErr    error
+		body       map[string]interface{}
+		wantStatus int
+		wantCode   string
+	}{
+		{name: "placed", body: map[string]interface{}{"amount": 100, "reason": "order"}, wantStatus: http.StatusCreated},
+		{name: "invalid amount", body: map[string]interface{}{"amount": 0}, wantStatus: http.StatusBadRequest, wantCode: service.CodeInvalidAmount},
+		{name: "ttl too long", body: map[string]interface{}{"amount": 100, "ttl_seconds": int((service.MaxHoldTTL + time.Hour).Seconds())},
+			wantStatus: http.StatusBadRequest, wantCode: "invalid_hold_ttl"},
+		{name: "insufficient funds", repoErr: repository.ErrInsufficientFunds, body: map[string]interface{}{"amount": 5000},
+			wantStatus: http.StatusUnprocessableEntity, wantCode: service.CodeInsufficientFunds},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serveAs(newStubHoldRouter(stubHoldRepository{err: tt.repoErr}), "POST", "/api/holds", "1", tt.body)
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+			if tt.wantCode != "" {
+				assert.Equal(t, tt.wantCode, decodeErrorResponse(t, w).Code)
+				return
+			}
+			var hold models.Hold
+			assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &hold))
+			assert.Equal(t, 7, hold.ID)
+			assert.Equal(t, 100, hold.Amount)
+		})
+	}
+}
+
+func TestHoldHandlerCaptureAndRelease(t *testing.T) {
+	tests := []struct {
+		name       string
+		repoErr    error
+		path       string
+		body       map[string]interface{}
+		wantStatus int
+		wantCode   string
+	}{
+		{name: "capture purchase", path: "/api/holds/7/capture", body: map[string]interface{}{"item": "cup"}, wantStatus: http.StatusOK},
+		{name: "capture transfer", path: "/api/holds/7/capture", body: map[string]interface{}{"to_user_id": 2, "amount": 50}, wantStatus: http.StatusOK},
+		{name: "capture without target", path: "/api/holds/7/capture", body: map[string]interface{}{"amount": 50},
+			wantStatus: http.StatusBadRequest, wantCode: service.CodeInvalidRequest},
+		{name: "capture both targets", path: "/api/holds/7/capture", body: map[string]interface{}{"item": "cup", "to_user_id": 2},
+			wantStatus: http.StatusBadRequest, wantCode: service.CodeInvalidRequest},
+		{name: "capture expired hold", repoErr: repository.ErrHoldExpired, path: "/api/holds/7/capture", body: map[string]interface{}{"item": "cup"},
+			wantStatus: http.StatusConflict, wantCode: "hold_expired"},
+		{name: "release", path: "/api/holds/7/release", wantStatus: http.StatusOK},
+		{name: "release unknown hold", repoErr: repository.ErrHoldNotFound, path: "/api/holds/8/release",
+			wantStatus: http.StatusNotFound, wantCode: "hold_not_found"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serveAs(newStubHoldRouter(stubHoldRepository{err: tt.repoErr}), "POST", tt.path, "1", tt.body)
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+			if tt.wantCode != "" {
+				assert.Equal(t, tt.wantCode, decodeErrorResponse(t, w).Code)
+			}
+		})
+	}
+}
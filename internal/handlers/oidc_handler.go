@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"avito-shop-service/internal/apierror"
+	"avito-shop-service/internal/service"
+	"encoding/json"
+	"net/http"
+)
+
+// Cookie с state привязывает вход к браузеру, который его начал
+const (
+	oidcStateCookie     = "oidc_state"
+	oidcStateCookiePath = "/api/auth/oidc"
+)
+
+type OIDCHandler struct {
+	oidcService *service.OIDCService
+}
+
+func NewOIDCHandler(oidcService *service.OIDCService) *OIDCHandler {
+	return &OIDCHandler{oidcService: oidcService}
+}
+
+// Начало входа через корпоративного провайдера: перенаправление на страницу входа
+func (h *OIDCHandler) Login(w http.ResponseWriter, r *http.Request) {
+	authURL, state, err := h.oidcService.AuthorizationURL(r.Context())
+	if err != nil {
+		apierror.Write(w, err, "Single sign-on failed")
+		return
+	}
+
+	// SameSite=Lax: cookie отправляется при возврате с провайдера (переход верхнего уровня)
+	http.SetCookie(w, &http.Cookie{
+		Name:     oidcStateCookie,
+		Value:    state,
+		Path:     oidcStateCookiePath,
+		MaxAge:   int(service.OIDCLoginStateTTL.Seconds()),
+		HttpOnly: true,
+		Secure:   true,
+		SameSite: http.SameSiteLaxMode,
+	})
+	http.Redirect(w, r, authURL, http.StatusFound)
+}
+
+// Возврат от провайдера: обмен кода на токен сервиса
+func (h *OIDCHandler) Callback(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	if idpErr := query.Get("error"); idpErr != "" {
+		apierror.WriteStatus(w, http.StatusUnauthorized, service.CodeSSOLoginFailed, "Identity provider error: "+idpErr)
+		return
+	}
+
+	state, code := query.Get("state"), query.Get("code")
+	if state == "" || code == "" {
+		apierror.BadRequest(w, "Missing state or code")
+		return
+	}
+
+	var browserState string
+	if cookie, err := r.Cookie(oidcStateCookie); err == nil {
+		browserState = cookie.Value
+	}
+	// State одноразовый: cookie больше не нужна при любом исходе
+	http.SetCookie(w, &http.Cookie{
+		Name:     oidcStateCookie,
+		Path:     oidcStateCookiePath,
+		MaxAge:   -1,
+		HttpOnly: true,
+		Secure:   true,
+		SameSite: http.SameSiteLaxMode,
+	})
+
+	result, err := h.oidcService.Callback(r.Context(), state, browserState, code, clientInfo(r))
+	if err != nil {
+		apierror.Write(w, err, "Single sign-on failed")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(result); err != nil {
+		apierror.Internal(w, "Failed to encode response")
+	}
+}
@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	"avito-shop-service/internal/models"
+	"avito-shop-service/internal/repository"
+	"avito-shop-service/internal/service"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Состояния входа с заранее заданной ошибкой сохранения; база данных не нужна
+type stubIdentityRepository struct {
+	repository.IdentityRepository
+
+	err error
+}
+
+func (s stubIdentityRepository) SaveLoginState(_ context.Context, _ *models.OIDCLoginState) error {
+	return s.err
+}
+
+// Провайдер, отдающий только discovery
+func newStubIdP(t *testing.T) *httptest.Server {
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 server.URL,
+			"authorization_endpoint": server.URL + "/authorize",
+		})
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func newStubOIDCHandler(issuer string, repo stubIdentityRepository) *OIDCHandler {
+	return NewOIDCHandler(service.NewOIDCService(service.OIDCConfig{Issuer: issuer, ClientID: "shop"}, repo, nil))
+}
+
+func TestOIDCHandlerLogin(t *testing.T) {
+	idp := newStubIdP(t)
+	down := newStubIdP(t)
+	down.Close()
+
+	tests := []struct {
+		name       string
+		issuer     string
+		repo       stubIdentityRepository
+		wantStatus int
+		wantCode   string
+	}{
+		{name: "redirects to the provider", issuer: idp.URL, wantStatus: http.StatusFound},
+		{name: "single sign-on disabled", wantStatus: http.StatusNotFound, wantCode: "sso_disabled"},
+		{name: "provider unavailable", issuer: down.URL, wantStatus: http.StatusBadGateway, wantCode: service.CodeSSOProviderError},
+		{name: "login state not saved", issuer: idp.URL, repo: stubIdentityRepository{err: errors.New("db is down")},
+			wantStatus: http.StatusInternalServerError, wantCode: service.CodeInternal},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			newStubOIDCHandler(tt.issuer, tt.repo).Login(w, httptest.NewRequest("GET", "/api/auth/oidc/login", nil))
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+			if tt.wantCode != "" {
+				assert.Equal(t, tt.wantCode, decodeErrorResponse(t, w).Code)
+			}
+		})
+	}
+}
@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"avito-shop-service/internal/apierror"
+	"avito-shop-service/internal/service"
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+type PasswordHandler struct {
+	passwordService *service.PasswordService
+}
+
+func NewPasswordHandler(passwordService *service.PasswordService) *PasswordHandler {
+	return &PasswordHandler{passwordService: passwordService}
+}
+
+// Смена пароля пользователем; требует текущий пароль
+func (h *PasswordHandler) ChangePassword(w http.ResponseWriter, r *http.Request) {
+	userID, err := strconv.Atoi(r.Header.Get("UserID"))
+	if err != nil {
+		apierror.Unauthorized(w, "Invalid user ID")
+		return
+	}
+
+	var req struct {
+		CurrentPassword string `json:"current_password"`
+		NewPassword     string `json:"new_password"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apierror.BadRequest(w, "Invalid request")
+		return
+	}
+
+	if err := h.passwordService.ChangePassword(r.Context(), userID, req.CurrentPassword, req.NewPassword); err != nil {
+		apierror.Write(w, err, "Failed to update password")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Выдача администратором одноразового токена сброса пароля
+func (h *PasswordHandler) IssueResetToken(w http.ResponseWriter, r *http.Request) {
+	adminID, err := strconv.Atoi(r.Header.Get("UserID"))
+	if err != nil {
+		apierror.Unauthorized(w, "Invalid user ID")
+		return
+	}
+
+	userID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		apierror.BadRequest(w, "Invalid user ID")
+		return
+	}
+
+	token, expiresAt, err := h.passwordService.IssueResetToken(r.Context(), adminID, userID)
+	if err != nil {
+		apierror.Write(w, err, "Failed to update password")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	resp := map[string]interface{}{"reset_token": token, "expires_at": expiresAt}
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		apierror.Internal(w, "Failed to encode response")
+	}
+}
+
+// Установка нового пароля по токену сброса; не требует аутентификации
+func (h *PasswordHandler) ResetPassword(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Token       string `json:"token"`
+		NewPassword string `json:"new_password"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apierror.BadRequest(w, "Invalid request")
+		return
+	}
+
+	if err := h.passwordService.ResetPassword(r.Context(), req.Token, req.NewPassword); err != nil {
+		apierror.Write(w, err, "Failed to update password")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,92 @@
+package handlers
+
+import (
+	"avito-shop-service/internal/models"
+	"avito-shop-service/internal/repository"
+	"avito-shop-service/internal/service"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+// Токены сброса пароля: действителен только valid-token; база данных не нужна
+type stubPasswordResetRepository struct{}
+
+func (stubPasswordResetRepository) CreateResetToken(_ context.Context, _, _ int, _ string, _ time.Time) error {
+	return nil
+}
+
+func (stubPasswordResetRepository) ResetPassword(_ context.Context, tokenHash, _ string) (int, error) {
+	sum := sha256.Sum256([]byte("valid-token"))
+	if tokenHash != hex.EncodeToString(sum[:]) {
+		return 0, repository.ErrInvalidResetToken
+	}
+	return 2, nil
+}
+
+func newStubPasswordRouter(t *testing.T) *mux.Router {
+	hasher := service.NewBcryptHasher(4)
+	users := newStubUserRepository(t, hasher, &models.User{ID: 2, Username: "alice", PasswordHash: "correct-password"})
+	passwordService := service.NewPasswordService(users, stubPasswordResetRepository{}, &service.PasswordPolicy{MinLength: 8},
+		hasher, time.Hour, service.NewUserStateCache(0))
+	handler := NewPasswordHandler(passwordService)
+
+	router := mux.NewRouter()
+	router.HandleFunc("/api/auth/password-reset", handler.ResetPassword).Methods("POST")
+	router.HandleFunc("/api/me/password", handler.ChangePassword).Methods("POST")
+	router.HandleFunc("/api/admin/users/{id:[0-9]+}/password-reset", handler.IssueResetToken).Methods("POST")
+	return router
+}
+
+func TestPasswordHandlerChangePassword(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       map[string]string
+		wantStatus int
+		wantCode   string
+	}{
+		{name: "changed", body: map[string]string{"current_password": "correct-password", "new_password": "brand-new-password"},
+			wantStatus: http.StatusNoContent},
+		{name: "wrong current password", body: map[string]string{"current_password": "wrong-password", "new_password": "brand-new-password"},
+			wantStatus: http.StatusForbidden, wantCode: "invalid_password"},
+		{name: "same password", body: map[string]string{"current_password": "correct-password", "new_password": "correct-password"},
+			wantStatus: http.StatusBadRequest, wantCode: "same_password"},
+		{name: "new password too short", body: map[string]string{"current_password": "correct-password", "new_password": "short"},
+			wantStatus: http.StatusBadRequest, wantCode: "password_too_short"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serveAs(newStubPasswordRouter(t), "POST", "/api/me/password", "2", tt.body)
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+			if tt.wantCode != "" {
+				assert.Equal(t, tt.wantCode, decodeErrorResponse(t, w).Code)
+			}
+		})
+	}
+}
+
+func TestPasswordHandlerReset(t *testing.T) {
+	router := newStubPasswordRouter(t)
+
+	w := serveAs(router, "POST", "/api/admin/users/2/password-reset", "1", nil)
+	assert.Equal(t, http.StatusCreated, w.Code)
+	var issued map[string]string
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &issued))
+	assert.NotEmpty(t, issued["reset_token"])
+
+	w = serveAs(router, "POST", "/api/auth/password-reset", "", map[string]string{"token": "valid-token", "new_password": "brand-new-password"})
+	assert.Equal(t, http.StatusNoContent, w.Code)
+
+	w = serveAs(router, "POST", "/api/auth/password-reset", "", map[string]string{"token": "used-token", "new_password": "brand-new-password"})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, "invalid_reset_token", decodeErrorResponse(t, w).Code)
+}
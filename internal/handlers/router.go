@@ -0,0 +1,199 @@
+package handlers
+
+import (
+	"avito-shop-service/config"
+	"avito-shop-service/internal/metrics"
+	"avito-shop-service/internal/middleware"
+	"avito-shop-service/internal/models"
+	"avito-shop-service/internal/ratelimit"
+	"avito-shop-service/internal/repository"
+	"avito-shop-service/internal/service"
+	"database/sql"
+	"fmt"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gorilla/mux/otelmux"
+)
+
+// Services - сервисы, из которых собираются обработчики API
+type Services struct {
+	Health    *service.HealthService
+	TOTP      *service.TOTPService
+	Sessions  *service.SessionService
+	Auth      *service.AuthService
+	OIDC      *service.OIDCService
+	APITokens *service.APITokenService
+	Password  *service.PasswordService
+	Limits    *service.LimitService
+	Wallet    *service.WalletService
+	Holds     *service.HoldService
+	Admin     *service.AdminService
+	Accounts  *service.AccountService
+	Fraud     *service.FraudService
+}
+
+// NewServices создает репозитории и сервисы поверх подключения к базе данных
+func NewServices(db *sql.DB, cfg *config.Config) (*Services, error) {
+	// Инициализируем репозитории
+	userRepo := repository.NewUserRepository(db)
+	// Цены магазина кешируются; CatalogCacheTTL = 0 отключает кеш
+	var walletRepo repository.WalletRepository = repository.NewPostgresWalletRepository(db)
+	if cfg.CatalogCacheTTL > 0 {
+		walletRepo = repository.NewCachingWalletRepository(walletRepo, cfg.CatalogCacheTTL)
+	}
+	holdRepo := repository.NewPostgresHoldRepository(db)
+	adjustmentRepo := repository.NewPostgresAdjustmentRepository(db)
+	reversalRepo := repository.NewPostgresReversalRepository(db)
+	limitRepo := repository.NewPostgresLimitRepository(db)
+	fraudRepo := repository.NewPostgresFraudRepository(db)
+	accountRepo := repository.NewPostgresAccountRepository(db)
+	passwordResetRepo := repository.NewPostgresPasswordResetRepository(db)
+	totpRepo := repository.NewPostgresTOTPRepository(db)
+	identityRepo := repository.NewPostgresIdentityRepository(db)
+	apiTokenRepo := repository.NewPostgresAPITokenRepository(db)
+	sessionRepo := repository.NewPostgresSessionRepository(db)
+	healthRepo := repository.NewPostgresHealthRepository(db)
+
+	passwordPolicy, err := service.NewPasswordPolicy(cfg.PasswordMinLength, cfg.PasswordBlocklistFile)
+	if err != nil {
+		return nil, fmt.Errorf("load password policy: %w", err)
+	}
+
+	passwordHasher, err := service.NewPasswordHasher(
+		cfg.PasswordHashAlgorithm,
+		service.NewBcryptHasher(cfg.BcryptCost),
+		service.NewArgon2idHasher(uint32(cfg.Argon2Memory), uint32(cfg.Argon2Iterations), uint8(cfg.Argon2Parallelism)),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("configure password hashing: %w", err)
+	}
+
+	// Инициализируем сервисы
+	s := &Services{}
+	s.Health = service.NewHealthService(healthRepo, cfg.HealthCheckTimeout)
+	s.TOTP = service.NewTOTPService(totpRepo, userRepo, cfg.TOTPIssuer)
+	s.Sessions = service.NewSessionService(sessionRepo, cfg.SessionCacheTTL)
+	userStateCache := service.NewUserStateCache(cfg.SessionCacheTTL)
+	s.Auth = service.NewAuthService(userRepo, cfg.JWTSecret, passwordPolicy, passwordHasher, s.TOTP, s.Sessions, userStateCache)
+	s.OIDC = service.NewOIDCService(service.OIDCConfig{
+		Issuer:       cfg.OIDCIssuer,
+		ClientID:     cfg.OIDCClientID,
+		ClientSecret: cfg.OIDCClientSecret,
+		RedirectURL:  cfg.OIDCRedirectURL,
+		Scopes:       cfg.OIDCScopes,
+	}, identityRepo, s.Auth)
+	s.APITokens = service.NewAPITokenService(apiTokenRepo)
+	s.Password = service.NewPasswordService(userRepo, passwordResetRepo, passwordPolicy, passwordHasher, cfg.PasswordResetTTL, userStateCache)
+	s.Limits = service.NewLimitService(limitRepo, models.TransferLimits{
+		MaxAmount:           cfg.TransferMaxAmount,
+		MaxDailyVolume:      cfg.TransferMaxDailyVolume,
+		MaxPerHour:          cfg.TransferMaxPerHour,
+		MaxRecipientsPerDay: cfg.TransferMaxRecipientsPerDay,
+	})
+	s.Wallet = service.NewWalletService(walletRepo, s.Limits)
+	s.Holds = service.NewHoldService(holdRepo, walletRepo, s.Limits)
+	s.Admin = service.NewAdminService(adjustmentRepo, reversalRepo)
+	s.Accounts = service.NewAccountService(accountRepo, userRepo, walletRepo, holdRepo, userStateCache)
+	s.Fraud = service.NewFraudService(fraudRepo, service.FraudConfig{
+		ScanInterval: cfg.FraudScanInterval,
+		AutoFreeze:   cfg.FraudAutoFreeze,
+		Params: models.FraudScanParams{
+			Lookback:         cfg.FraudLookback,
+			NewAccountAge:    cfg.FraudNewAccountAge,
+			DrainMinAmount:   cfg.FraudDrainMinAmount,
+			FunnelMinSenders: cfg.FraudFunnelMinSenders,
+			CycleMinAmount:   cfg.FraudCycleMinAmount,
+		},
+	})
+	return s, nil
+}
+
+// NewRouter регистрирует маршруты API с middleware. Лимиты частоты запросов хранятся в rateLimitStore
+func NewRouter(cfg *config.Config, s *Services, rateLimitStore ratelimit.Store) *mux.Router {
+	// Инициализируем обработчики
+	authHandler := NewAuthHandler(s.Auth)
+	walletHandler := NewWalletHandler(s.Wallet)
+	holdHandler := NewHoldHandler(s.Holds)
+	adminHandler := NewAdminHandler(s.Admin, s.Limits)
+	fraudHandler := NewFraudHandler(s.Fraud)
+	accountHandler := NewAccountHandler(s.Accounts, s.Auth)
+	passwordHandler := NewPasswordHandler(s.Password)
+	totpHandler := NewTOTPHandler(s.TOTP)
+	oidcHandler := NewOIDCHandler(s.OIDC)
+	apiTokenHandler := NewAPITokenHandler(s.APITokens)
+	sessionHandler := NewSessionHandler(s.Sessions)
+	healthHandler := NewHealthHandler(s.Health)
+
+	router := mux.NewRouter()
+	// Спан запроса продолжает трассу из заголовка traceparent, если он передан
+	router.Use(otelmux.Middleware(cfg.ServiceName))
+	router.Use(middleware.RequestID)
+	router.Use(middleware.AccessLog("/healthz", "/readyz", "/metrics"))
+	router.Use(middleware.Metrics)
+	router.Use(middleware.Deadline(cfg.DBRequestTimeout))
+
+	// Пробы для оркестратора и метрики: без аутентификации
+	router.HandleFunc("/healthz", healthHandler.Liveness).Methods("GET")
+	router.HandleFunc("/readyz", healthHandler.Readiness).Methods("GET")
+	router.Handle("/metrics", metrics.Handler()).Methods("GET")
+
+	// Ограничение частоты запросов: вход - по адресу клиента, остальные маршруты - по пользователю
+	authLimit := middleware.RateLimit(rateLimitStore, ratelimit.PerMinute(cfg.RateLimitAuthPerMinute, cfg.RateLimitAuthBurst), middleware.IPKey)
+	userLimit := middleware.RateLimit(rateLimitStore, ratelimit.PerMinute(cfg.RateLimitUserPerMinute, cfg.RateLimitUserBurst), middleware.UserKey)
+
+	// Применяем middleware для защищенных маршрутов
+	// Регистрация и вход не требуют аутентификации
+	router.Handle("/api/auth", authLimit(http.HandlerFunc(authHandler.Auth))).Methods("POST")
+	router.Handle("/api/auth/2fa", authLimit(http.HandlerFunc(authHandler.CompleteTwoFactor))).Methods("POST")
+	router.Handle("/api/auth/oidc/login", authLimit(http.HandlerFunc(oidcHandler.Login))).Methods("GET")
+	router.Handle("/api/auth/oidc/callback", authLimit(http.HandlerFunc(oidcHandler.Callback))).Methods("GET")
+	router.Handle("/api/auth/password-reset", authLimit(http.HandlerFunc(passwordHandler.ResetPassword))).Methods("POST")
+
+	// Защищенные маршруты с middleware
+	protected := router.PathPrefix("/api").Subrouter()
+	protected.Use(middleware.AuthMiddleware(s.Auth, s.APITokens))
+	protected.Use(userLimit)
+
+	// Роуты, которые требуют аутентификации. Маршруты, отмеченные Scoped, доступны также
+	// по персональным токенам с соответствующей областью доступа
+	protected.Handle("/info", middleware.Scoped(models.ScopeInfoRead, walletHandler.GetInfo)).Methods("GET")
+	protected.Handle("/sendCoin", middleware.Scoped(models.ScopeCoinsSend, walletHandler.Transfer)).Methods("POST")
+	protected.Handle("/buy/{item}", middleware.Scoped(models.ScopeShopBuy, walletHandler.BuyItem)).Methods("POST")
+	protected.Handle("/holds", middleware.Scoped(models.ScopeHoldsWrite, holdHandler.PlaceHold)).Methods("POST")
+	protected.Handle("/holds", middleware.Scoped(models.ScopeInfoRead, holdHandler.GetHolds)).Methods("GET")
+	protected.Handle("/holds/{id:[0-9]+}/capture", middleware.Scoped(models.ScopeHoldsWrite, holdHandler.Capture)).Methods("POST")
+	protected.Handle("/holds/{id:[0-9]+}/release", middleware.Scoped(models.ScopeHoldsWrite, holdHandler.Release)).Methods("POST")
+	protected.HandleFunc("/me/export", accountHandler.Export).Methods("GET")
+	protected.HandleFunc("/me/close", accountHandler.CloseOwnAccount).Methods("POST")
+	protected.HandleFunc("/me/password", passwordHandler.ChangePassword).Methods("POST")
+	protected.HandleFunc("/me/2fa/enroll", totpHandler.Enroll).Methods("POST")
+	protected.HandleFunc("/me/2fa/confirm", totpHandler.Confirm).Methods("POST")
+	protected.HandleFunc("/me/2fa/disable", totpHandler.Disable).Methods("POST")
+	protected.HandleFunc("/me/tokens", apiTokenHandler.CreateToken).Methods("POST")
+	protected.HandleFunc("/me/tokens", apiTokenHandler.GetTokens).Methods("GET")
+	protected.HandleFunc("/me/tokens/{id:[0-9]+}", apiTokenHandler.RevokeToken).Methods("DELETE")
+	protected.HandleFunc("/me/sessions", sessionHandler.GetSessions).Methods("GET")
+	protected.HandleFunc("/me/sessions/{id}", sessionHandler.RevokeSession).Methods("DELETE")
+
+	// Административные маршруты
+	admin := protected.PathPrefix("/admin").Subrouter()
+	admin.Use(middleware.AdminMiddleware(s.Auth))
+	admin.Handle("/coins/adjust", middleware.Scoped(models.ScopeAdminCoins, adminHandler.AdjustCoins)).Methods("POST")
+	admin.Handle("/coins/bulk-grant", middleware.Scoped(models.ScopeAdminCoins, adminHandler.BulkGrant)).Methods("POST")
+	admin.HandleFunc("/transactions/{id:[0-9]+}/reverse", adminHandler.ReverseTransfer).Methods("POST")
+	admin.HandleFunc("/users/{id:[0-9]+}/limits", adminHandler.GetLimits).Methods("GET")
+	admin.HandleFunc("/users/{id:[0-9]+}/limits", adminHandler.SetLimits).Methods("PUT")
+	admin.HandleFunc("/users/{id:[0-9]+}/limits", adminHandler.DeleteLimits).Methods("DELETE")
+	admin.HandleFunc("/users/{id:[0-9]+}/transfers-frozen", fraudHandler.SetTransfersFrozen).Methods("PUT")
+	admin.HandleFunc("/users/{id:[0-9]+}/status", accountHandler.SetStatus).Methods("PUT")
+	admin.HandleFunc("/users/{id:[0-9]+}/status-history", accountHandler.GetStatusHistory).Methods("GET")
+	admin.HandleFunc("/users/{id:[0-9]+}/close", accountHandler.CloseAccount).Methods("POST")
+	admin.HandleFunc("/users/{id:[0-9]+}/password-reset", passwordHandler.IssueResetToken).Methods("POST")
+	admin.HandleFunc("/fraud/flags", fraudHandler.GetFlags).Methods("GET")
+	admin.HandleFunc("/fraud/flags/{id:[0-9]+}/review", fraudHandler.ReviewFlag).Methods("POST")
+	admin.HandleFunc("/fraud/scan", fraudHandler.Scan).Methods("POST")
+	admin.HandleFunc("/shop/{item}", walletHandler.SetItemPrice).Methods("PUT")
+
+	return router
+}
@@ -0,0 +1,59 @@
+package handlers
+
+import (
+	"avito-shop-service/config"
+	"avito-shop-service/internal/ratelimit"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Маршруты API регистрируются с ожидаемыми методами; обработчики не вызываются, поэтому сервисы не нужны
+func TestNewRouterRoutes(t *testing.T) {
+	router := NewRouter(&config.Config{}, &Services{}, ratelimit.NewMemoryStore())
+
+	routes := map[string]bool{}
+	err := router.Walk(func(route *mux.Route, _ *mux.Router, _ []*mux.Route) error {
+		template, err := route.GetPathTemplate()
+		if err != nil {
+			return nil
+		}
+		methods, err := route.GetMethods()
+		if err != nil {
+			return nil
+		}
+		routes[strings.Join(methods, ",")+" "+template] = true
+		return nil
+	})
+	require.NoError(t, err)
+
+	for _, route := range []string{
+		"POST /api/auth",
+		"POST /api/auth/2fa",
+		"GET /api/auth/oidc/login",
+		"POST /api/auth/password-reset",
+		"GET /api/info",
+		"POST /api/sendCoin",
+		"POST /api/buy/{item}",
+		"POST /api/holds",
+		"GET /api/holds",
+		"POST /api/holds/{id:[0-9]+}/capture",
+		"POST /api/me/close",
+		"POST /api/me/password",
+		"POST /api/me/2fa/enroll",
+		"DELETE /api/me/tokens/{id:[0-9]+}",
+		"DELETE /api/me/sessions/{id}",
+		"POST /api/admin/coins/adjust",
+		"POST /api/admin/transactions/{id:[0-9]+}/reverse",
+		"PUT /api/admin/users/{id:[0-9]+}/status",
+		"POST /api/admin/users/{id:[0-9]+}/password-reset",
+		"PUT /api/admin/shop/{item}",
+		"GET /healthz",
+		"GET /metrics",
+	} {
+		assert.True(t, routes[route], route)
+	}
+}
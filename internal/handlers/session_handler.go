@@ -0,0 +1,67 @@
+package handlers
+
+import (
+	"avito-shop-service/internal/apierror"
+	"avito-shop-service/internal/models"
+	"avito-shop-service/internal/service"
+	"encoding/json"
+	"net"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+type SessionHandler struct {
+	sessionService *service.SessionService
+}
+
+func NewSessionHandler(sessionService *service.SessionService) *SessionHandler {
+	return &SessionHandler{sessionService: sessionService}
+}
+
+// Список активных сессий пользователя; сессия текущего запроса отмечена полем current
+func (h *SessionHandler) GetSessions(w http.ResponseWriter, r *http.Request) {
+	userID, err := strconv.Atoi(r.Header.Get("UserID"))
+	if err != nil {
+		apierror.Unauthorized(w, "Invalid user ID")
+		return
+	}
+
+	sessions, err := h.sessionService.List(r.Context(), userID, r.Header.Get("SessionID"))
+	if err != nil {
+		apierror.Write(w, err, "Failed to get sessions")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(sessions); err != nil {
+		apierror.Internal(w, "Failed to encode response")
+	}
+}
+
+// Отзыв сессии; токен этой сессии перестает приниматься
+func (h *SessionHandler) RevokeSession(w http.ResponseWriter, r *http.Request) {
+	userID, err := strconv.Atoi(r.Header.Get("UserID"))
+	if err != nil {
+		apierror.Unauthorized(w, "Invalid user ID")
+		return
+	}
+
+	if err := h.sessionService.Revoke(r.Context(), userID, mux.Vars(r)["id"]); err != nil {
+		apierror.Write(w, err, "Failed to revoke session")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Сведения о клиенте для новой сессии
+func clientInfo(r *http.Request) models.ClientInfo {
+	ip, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		ip = r.RemoteAddr
+	}
+	return models.ClientInfo{UserAgent: r.UserAgent(), IP: ip}
+}
@@ -0,0 +1,79 @@
+package handlers
+
+import (
+	"avito-shop-service/internal/models"
+	"avito-shop-service/internal/repository"
+	"avito-shop-service/internal/service"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+// Сессии с заранее заданной ошибкой; база данных не нужна
+type stubSessionRepository struct {
+	repository.SessionRepository
+
+	err error
+}
+
+func (s stubSessionRepository) GetActiveSessions(_ context.Context, userID int) ([]models.Session, error) {
+	return []models.Session{{ID: "sid-1", UserID: userID}, {ID: "sid-2", UserID: userID}}, s.err
+}
+
+func (s stubSessionRepository) RevokeSession(_ context.Context, _ string, _ int) error {
+	return s.err
+}
+
+func newStubSessionRouter(repo stubSessionRepository) *mux.Router {
+	handler := NewSessionHandler(service.NewSessionService(repo, 0))
+
+	router := mux.NewRouter()
+	router.HandleFunc("/api/me/sessions", handler.GetSessions).Methods("GET")
+	router.HandleFunc("/api/me/sessions/{id}", handler.RevokeSession).Methods("DELETE")
+	return router
+}
+
+// Сессия текущего запроса отмечена в списке
+func TestSessionHandlerGetSessions(t *testing.T) {
+	req := httptest.NewRequest("GET", "/api/me/sessions", nil)
+	req.Header.Set("UserID", "2")
+	req.Header.Set("SessionID", "sid-2")
+	w := httptest.NewRecorder()
+	newStubSessionRouter(stubSessionRepository{}).ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var sessions []models.Session
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &sessions))
+	if assert.Len(t, sessions, 2) {
+		assert.False(t, sessions[0].Current)
+		assert.True(t, sessions[1].Current)
+	}
+}
+
+func TestSessionHandlerRevokeSession(t *testing.T) {
+	tests := []struct {
+		name       string
+		repoErr    error
+		wantStatus int
+		wantCode   string
+	}{
+		{name: "revoked", wantStatus: http.StatusNoContent},
+		{name: "unknown session", repoErr: repository.ErrSessionNotFound, wantStatus: http.StatusNotFound, wantCode: "session_not_found"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serveAs(newStubSessionRouter(stubSessionRepository{err: tt.repoErr}), "DELETE", "/api/me/sessions/sid-1", "2", nil)
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+			if tt.wantCode != "" {
+				assert.Equal(t, tt.wantCode, decodeErrorResponse(t, w).Code)
+			}
+		})
+	}
+}
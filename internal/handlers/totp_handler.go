@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"avito-shop-service/internal/apierror"
+	"avito-shop-service/internal/service"
+	"encoding/json"
+	"net/http"
+	"strconv"
+)
+
+type TOTPHandler struct {
+	totpService *service.TOTPService
+}
+
+func NewTOTPHandler(totpService *service.TOTPService) *TOTPHandler {
+	return &TOTPHandler{totpService: totpService}
+}
+
+// Начало подключения двухфакторной аутентификации: секрет и otpauth URI для приложения
+func (h *TOTPHandler) Enroll(w http.ResponseWriter, r *http.Request) {
+	userID, err := strconv.Atoi(r.Header.Get("UserID"))
+	if err != nil {
+		apierror.Unauthorized(w, "Invalid user ID")
+		return
+	}
+
+	enrollment, err := h.totpService.Enroll(r.Context(), userID)
+	if err != nil {
+		apierror.Write(w, err, "Failed to update two-factor authentication")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(enrollment); err != nil {
+		apierror.Internal(w, "Failed to encode response")
+	}
+}
+
+// Подтверждение подключения первым кодом; в ответе коды восстановления
+func (h *TOTPHandler) Confirm(w http.ResponseWriter, r *http.Request) {
+	userID, err := strconv.Atoi(r.Header.Get("UserID"))
+	if err != nil {
+		apierror.Unauthorized(w, "Invalid user ID")
+		return
+	}
+
+	var req struct {
+		Code string `json:"code"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apierror.BadRequest(w, "Invalid request")
+		return
+	}
+
+	codes, err := h.totpService.Confirm(r.Context(), userID, req.Code)
+	if err != nil {
+		apierror.Write(w, err, "Failed to update two-factor authentication")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(map[string][]string{"recovery_codes": codes}); err != nil {
+		apierror.Internal(w, "Failed to encode response")
+	}
+}
+
+// Отключение двухфакторной аутентификации; требует код или код восстановления
+func (h *TOTPHandler) Disable(w http.ResponseWriter, r *http.Request) {
+	userID, err := strconv.Atoi(r.Header.Get("UserID"))
+	if err != nil {
+		apierror.Unauthorized(w, "Invalid user ID")
+		return
+	}
+
+	var req struct {
+		Code string `json:"code"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apierror.BadRequest(w, "Invalid request")
+		return
+	}
+
+	if err := h.totpService.Disable(r.Context(), userID, req.Code); err != nil {
+		apierror.Write(w, err, "Failed to update two-factor authentication")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	"avito-shop-service/internal/models"
+	"avito-shop-service/internal/service"
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+// Состояние двухфакторной аутентификации одного пользователя; база данных не нужна
+type stubTOTPRepository struct {
+	totp *models.TOTP
+}
+
+func (s *stubTOTPRepository) SaveSecret(_ context.Context, userID int, secret string) error {
+	s.totp = &models.TOTP{UserID: userID, Secret: secret}
+	return nil
+}
+
+func (s *stubTOTPRepository) GetTOTP(_ context.Context, _ int) (*models.TOTP, error) {
+	return s.totp, nil
+}
+
+func (s *stubTOTPRepository) Enable(_ context.Context, _ int, step int64, _ []string) error {
+	now := time.Now()
+	s.totp.EnabledAt, s.totp.LastUsedStep = &now, step
+	return nil
+}
+
+func (s *stubTOTPRepository) UseStep(_ context.Context, _ int, _ int64) (bool, error) {
+	return true, nil
+}
+
+func (s *stubTOTPRepository) UseRecoveryCode(_ context.Context, _ int, _ string) (bool, error) {
+	return false, nil
+}
+
+func (s *stubTOTPRepository) RecordFailure(_ context.Context, _, _ int, _ time.Time) error {
+	return nil
+}
+
+func (s *stubTOTPRepository) Disable(_ context.Context, _ int) error {
+	s.totp = nil
+	return nil
+}
+
+func newStubTOTPRouter(t *testing.T, repo *stubTOTPRepository) *mux.Router {
+	users := newStubUserRepository(t, service.NewBcryptHasher(4), &models.User{ID: 2, Username: "alice"})
+	handler := NewTOTPHandler(service.NewTOTPService(repo, users, "Avito Shop"))
+
+	router := mux.NewRouter()
+	router.HandleFunc("/api/me/2fa/enroll", handler.Enroll).Methods("POST")
+	router.HandleFunc("/api/me/2fa/confirm", handler.Confirm).Methods("POST")
+	router.HandleFunc("/api/me/2fa/disable", handler.Disable).Methods("POST")
+	return router
+}
+
+func TestTOTPHandlerEnroll(t *testing.T) {
+	repo := &stubTOTPRepository{}
+	w := serveAs(newStubTOTPRouter(t, repo), "POST", "/api/me/2fa/enroll", "2", nil)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var enrollment models.TOTPEnrollment
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &enrollment))
+	assert.Equal(t, repo.totp.Secret, enrollment.Secret)
+	assert.True(t, strings.HasPrefix(enrollment.URI, "otpauth://totp/"), enrollment.URI)
+}
+
+func TestTOTPHandlerErrors(t *testing.T) {
+	enrolled := &models.TOTP{UserID: 2, Secret: "JBSWY3DPEHPK3PXP"}
+	now := time.Now()
+	enabled := &models.TOTP{UserID: 2, Secret: "JBSWY3DPEHPK3PXP", EnabledAt: &now}
+	lockedUntil := now.Add(time.Minute)
+	locked := &models.TOTP{UserID: 2, Secret: "JBSWY3DPEHPK3PXP", EnabledAt: &now, LockedUntil: &lockedUntil}
+
+	tests := []struct {
+		name       string
+		totp       *models.TOTP
+		path       string
+		wantStatus int
+		wantCode   string
+	}{
+		{name: "confirm without enrollment", path: "/api/me/2fa/confirm", wantStatus: http.StatusConflict, wantCode: "two_factor_not_enrolled"},
+		{name: "confirm with wrong code", totp: enrolled, path: "/api/me/2fa/confirm", wantStatus: http.StatusUnprocessableEntity, wantCode: "invalid_two_factor_code"},
+		{name: "confirm twice", totp: enabled, path: "/api/me/2fa/confirm", wantStatus: http.StatusConflict, wantCode: "two_factor_already_enabled"},
+		{name: "disable when not enabled", totp: enrolled, path: "/api/me/2fa/disable", wantStatus: http.StatusConflict, wantCode: "two_factor_not_enrolled"},
+		{name: "disable with unknown recovery code", totp: enabled, path: "/api/me/2fa/disable", wantStatus: http.StatusUnprocessableEntity, wantCode: "invalid_two_factor_code"},
+		{name: "disable after too many wrong codes", totp: locked, path: "/api/me/2fa/disable", wantStatus: http.StatusTooManyRequests, wantCode: "two_factor_locked"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := newStubTOTPRouter(t, &stubTOTPRepository{totp: tt.totp})
+			w := serveAs(router, "POST", tt.path, "2", map[string]string{"code": "not-a-code"})
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+			assert.Equal(t, tt.wantCode, decodeErrorResponse(t, w).Code)
+		})
+	}
+}
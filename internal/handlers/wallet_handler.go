@@ -1,7 +1,7 @@
 package handlers
 
 import (
-	"avito-shop-service/internal/models"
+	"avito-shop-service/internal/apierror"
 	"avito-shop-service/internal/service"
 	"encoding/json"
 	"net/http"
@@ -25,7 +25,7 @@ func (h *WalletHandler) Transfer(w http.ResponseWriter, r *http.Request) {
 
 	fromUserID, err := strconv.Atoi(userID)
 	if err != nil {
-		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		apierror.Unauthorized(w, "Unauthorized")
 		return
 	}
 
@@ -35,19 +35,13 @@ func (h *WalletHandler) Transfer(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid request", http.StatusBadRequest)
-		return
-	}
-
-	// Проверяем, что пользователь не переводит монеты самому себе
-	if fromUserID == req.ToUserID {
-		http.Error(w, "Cannot transfer to yourself", http.StatusBadRequest)
+		apierror.BadRequest(w, "Invalid request")
 		return
 	}
 
 	// Выполняем перевод
-	if err := h.walletService.Transfer(fromUserID, req.ToUserID, req.Amount); err != nil {
-		http.Error(w, "Transfer failed", http.StatusInternalServerError)
+	if err := h.walletService.Transfer(r.Context(), fromUserID, req.ToUserID, req.Amount); err != nil {
+		apierror.Write(w, err, "Transfer failed")
 		return
 	}
 
@@ -61,21 +55,21 @@ func (h *WalletHandler) GetTransactions(w http.ResponseWriter, r *http.Request)
 
 	userID, err := strconv.Atoi(userIDStr)
 	if err != nil {
-		http.Error(w, "Invalid user ID", http.StatusUnauthorized)
+		apierror.Unauthorized(w, "Invalid user ID")
 		return
 	}
 
 	// Получаем транзакции пользователя
-	transactions, err := h.walletService.GetTransactions(userID)
+	transactions, err := h.walletService.GetTransactions(r.Context(), userID)
 	if err != nil {
-		http.Error(w, "Failed to get transactions", http.StatusInternalServerError)
+		apierror.Write(w, err, "Failed to get transactions")
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 	if err := json.NewEncoder(w).Encode(transactions); err != nil {
-		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		apierror.Internal(w, "Failed to encode response")
 	}
 }
 
@@ -90,8 +84,8 @@ func (h *WalletHandler) BuyItem(w http.ResponseWriter, r *http.Request) {
 	}
 
 	quantity, err := strconv.Atoi(quantityStr)
-	if err != nil || quantity <= 0 {
-		http.Error(w, "Invalid quantity", http.StatusBadRequest)
+	if err != nil {
+		apierror.BadRequest(w, "Invalid quantity")
 		return
 	}
 
@@ -99,19 +93,13 @@ func (h *WalletHandler) BuyItem(w http.ResponseWriter, r *http.Request) {
 
 	userIDInt, err := strconv.Atoi(userID)
 	if err != nil {
-		http.Error(w, "Invalid user ID", http.StatusUnauthorized)
-		return
-	}
-
-	itemPrice, err := h.walletService.GetItemPrice(itemName)
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusNotFound)
+		apierror.Unauthorized(w, "Invalid user ID")
 		return
 	}
 
-	err = h.walletService.PurchaseItem(userIDInt, itemName, itemPrice, quantity)
+	err = h.walletService.PurchaseItem(r.Context(), userIDInt, itemName, quantity)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		apierror.Write(w, err, "Purchase failed")
 		return
 	}
 
@@ -119,51 +107,50 @@ func (h *WalletHandler) BuyItem(w http.ResponseWriter, r *http.Request) {
 	if err := json.NewEncoder(w).Encode(map[string]string{
 		"message": "Item purchased successfully",
 	}); err != nil {
-		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		apierror.Internal(w, "Failed to encode response")
 	}
 
 }
 
-// Получение информации о монетах, инвентаре и истории транзакций
-func (h *WalletHandler) GetInfo(w http.ResponseWriter, r *http.Request) {
-	userID := r.Header.Get("UserID")
+// Добавление товара или изменение цены администратором
+func (h *WalletHandler) SetItemPrice(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Price int `json:"price"`
+	}
 
-	userIDInt, err := strconv.Atoi(userID)
-	if err != nil {
-		http.Error(w, "Invalid user ID", http.StatusUnauthorized)
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apierror.BadRequest(w, "Invalid request")
 		return
 	}
 
-	// Получаем баланс пользователя
-	balance, err := h.walletService.GetBalance(userIDInt)
-	if err != nil {
-		http.Error(w, "Failed to get balance", http.StatusInternalServerError)
+	if err := h.walletService.SetItemPrice(r.Context(), mux.Vars(r)["item"], req.Price); err != nil {
+		apierror.Write(w, err, "Failed to set item price")
 		return
 	}
 
-	// Получаем инвентарь пользователя
-	inventory, err := h.walletService.GetInventory(userIDInt)
+	w.WriteHeader(http.StatusOK)
+}
+
+// Получение информации о монетах, инвентаре и истории транзакций
+func (h *WalletHandler) GetInfo(w http.ResponseWriter, r *http.Request) {
+	userID := r.Header.Get("UserID")
+
+	userIDInt, err := strconv.Atoi(userID)
 	if err != nil {
-		http.Error(w, "Failed to get inventory", http.StatusInternalServerError)
+		apierror.Unauthorized(w, "Invalid user ID")
 		return
 	}
 
-	// Получаем транзакции пользователя
-	transactions, err := h.walletService.GetTransactions(userIDInt)
+	// Баланс, инвентарь и история читаются одним запросом из одного снимка базы
+	infoResponse, err := h.walletService.GetInfo(r.Context(), userIDInt)
 	if err != nil {
-		http.Error(w, "Failed to get transactions", http.StatusInternalServerError)
+		apierror.Write(w, err, "Failed to get info")
 		return
 	}
 
-	infoResponse := models.InfoResponse{
-		Balance:      balance,
-		Inventory:    inventory,
-		Transactions: transactions,
-	}
-
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 	if err := json.NewEncoder(w).Encode(infoResponse); err != nil {
-		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		apierror.Internal(w, "Failed to encode response")
 	}
 }
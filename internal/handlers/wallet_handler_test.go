@@ -0,0 +1,214 @@
+package handlers
+
+import (
+	"avito-shop-service/internal/apierror"
+	"avito-shop-service/internal/models"
+	"avito-shop-service/internal/repository"
+	"avito-shop-service/internal/service"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+const stubItemPrice = 80
+
+// Кошелек с заранее заданными ошибками; база данных не нужна
+type stubWalletRepository struct {
+	balance     int
+	transferErr error
+	priceErr    error
+	purchaseErr error
+}
+
+func (s *stubWalletRepository) GetBalance(_ context.Context, _ int) (int, error) {
+	return s.balance, nil
+}
+
+func (s *stubWalletRepository) GetAvailableBalance(_ context.Context, _ int) (int, error) {
+	return s.balance, nil
+}
+
+func (s *stubWalletRepository) Transfer(_ context.Context, _, _, _ int, _ models.TransferCheck) error {
+	return s.transferErr
+}
+
+func (s *stubWalletRepository) GetTransactions(_ context.Context, _ int) ([]models.Transaction, error) {
+	return nil, nil
+}
+
+// Как и в базе, цена и нехватка монет проверяются в транзакции покупки
+func (s *stubWalletRepository) PurchaseItem(_ context.Context, _ int, _ string, quantity int) error {
+	if s.purchaseErr == nil && s.balance < stubItemPrice*quantity {
+		return repository.ErrInsufficientFunds
+	}
+	return s.purchaseErr
+}
+
+func (s *stubWalletRepository) GetInventory(_ context.Context, _ int) ([]models.Item, error) {
+	return nil, nil
+}
+
+func (s *stubWalletRepository) GetItemPrice(_ context.Context, _ string) (int, error) {
+	return stubItemPrice, s.priceErr
+}
+
+func (s *stubWalletRepository) SetItemPrice(_ context.Context, _ string, _ int) error {
+	return nil
+}
+
+func (s *stubWalletRepository) GetInfo(_ context.Context, _ int) (*models.InfoResponse, error) {
+	return &models.InfoResponse{Balance: s.balance, AvailableBalance: s.balance}, nil
+}
+
+func (s *stubWalletRepository) GetAdjustments(_ context.Context, _ int) ([]models.Adjustment, error) {
+	return nil, nil
+}
+
+// Лимиты не заданы
+type stubLimitRepository struct{}
+
+func (stubLimitRepository) GetOverride(_ context.Context, _ int) (*models.TransferLimitOverride, error) {
+	return nil, nil
+}
+
+func (stubLimitRepository) SetOverride(_ context.Context, _, _ int, _ models.TransferLimitOverride) error {
+	return nil
+}
+
+func (stubLimitRepository) DeleteOverride(_ context.Context, _ int) error { return nil }
+
+func newStubWalletRouter(repo *stubWalletRepository) *mux.Router {
+	walletService := service.NewWalletService(repo, service.NewLimitService(stubLimitRepository{}, models.TransferLimits{}))
+	handler := NewWalletHandler(walletService)
+
+	router := mux.NewRouter()
+	router.HandleFunc("/api/sendCoin", handler.Transfer).Methods("POST")
+	router.HandleFunc("/api/buy/{item}", handler.BuyItem).Methods("POST")
+	router.HandleFunc("/api/info", handler.GetInfo).Methods("GET")
+	router.HandleFunc("/api/admin/shop/{item}", handler.SetItemPrice).Methods("PUT")
+	return router
+}
+
+func decodeErrorResponse(t *testing.T, w *httptest.ResponseRecorder) apierror.Response {
+	t.Helper()
+	assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+
+	var resp apierror.Response
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	return resp
+}
+
+// Запрос от имени пользователя, как после AuthMiddleware; body кодируется в JSON
+func serveAs(router http.Handler, method, path, userID string, body interface{}) *httptest.ResponseRecorder {
+	var reqBody bytes.Buffer
+	if body != nil {
+		_ = json.NewEncoder(&reqBody).Encode(body)
+	}
+	req := httptest.NewRequest(method, path, &reqBody)
+	req.Header.Set("UserID", userID)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestWalletHandlerTransferErrors(t *testing.T) {
+	tests := []struct {
+		name       string
+		toUserID   int
+		amount     int
+		repoErr    error
+		wantStatus int
+		wantCode   string
+	}{
+		{name: "self transfer", toUserID: 1, amount: 10, wantStatus: http.StatusBadRequest, wantCode: "self_transfer"},
+		{name: "invalid amount", toUserID: 2, amount: 0, wantStatus: http.StatusBadRequest, wantCode: service.CodeInvalidAmount},
+		{name: "unknown recipient", toUserID: 2, amount: 10, repoErr: repository.ErrUserNotFound, wantStatus: http.StatusNotFound, wantCode: service.CodeUserNotFound},
+		{name: "insufficient funds", toUserID: 2, amount: 10, repoErr: repository.ErrInsufficientFunds, wantStatus: http.StatusUnprocessableEntity, wantCode: service.CodeInsufficientFunds},
+		{name: "transfers frozen", toUserID: 2, amount: 10, repoErr: repository.ErrTransfersFrozen, wantStatus: http.StatusForbidden, wantCode: "transfers_frozen"},
+		{name: "database failure", toUserID: 2, amount: 10, repoErr: errors.New("connection refused"), wantStatus: http.StatusInternalServerError, wantCode: service.CodeInternal},
+		{name: "request timeout", toUserID: 2, amount: 10, repoErr: fmt.Errorf("lock users: %w", context.DeadlineExceeded), wantStatus: http.StatusServiceUnavailable, wantCode: service.CodeRequestTimeout},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := newStubWalletRouter(&stubWalletRepository{transferErr: tt.repoErr})
+
+			body, _ := json.Marshal(map[string]int{"to_user_id": tt.toUserID, "amount": tt.amount})
+			req := httptest.NewRequest("POST", "/api/sendCoin", bytes.NewBuffer(body))
+			req.Header.Set("UserID", "1")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+			resp := decodeErrorResponse(t, w)
+			assert.Equal(t, tt.wantCode, resp.Code)
+			// Детали внутренних ошибок наружу не попадают
+			assert.NotContains(t, resp.Errors, "connection refused")
+		})
+	}
+}
+
+func TestWalletHandlerBuyItemErrors(t *testing.T) {
+	tests := []struct {
+		name       string
+		query      string
+		repo       *stubWalletRepository
+		wantStatus int
+		wantCode   string
+	}{
+		{name: "invalid quantity", query: "?quantity=0", repo: &stubWalletRepository{balance: 1000}, wantStatus: http.StatusBadRequest, wantCode: "invalid_quantity"},
+		{name: "unknown item", repo: &stubWalletRepository{priceErr: repository.ErrItemNotFound}, wantStatus: http.StatusNotFound, wantCode: service.CodeItemNotFound},
+		{name: "price lookup failure", repo: &stubWalletRepository{priceErr: errors.New("connection refused")}, wantStatus: http.StatusInternalServerError, wantCode: service.CodeInternal},
+		{name: "insufficient funds", repo: &stubWalletRepository{balance: 10}, wantStatus: http.StatusUnprocessableEntity, wantCode: service.CodeInsufficientFunds},
+		{name: "purchase failure", repo: &stubWalletRepository{balance: 1000, purchaseErr: errors.New("connection refused")}, wantStatus: http.StatusInternalServerError, wantCode: service.CodeInternal},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := newStubWalletRouter(tt.repo)
+
+			w := serveAs(router, "POST", "/api/buy/cup"+tt.query, "1", nil)
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+			assert.Equal(t, tt.wantCode, decodeErrorResponse(t, w).Code)
+		})
+	}
+}
+
+func TestWalletHandlerGetInfo(t *testing.T) {
+	router := newStubWalletRouter(&stubWalletRepository{balance: 500})
+
+	req := httptest.NewRequest("GET", "/api/info", nil)
+	req.Header.Set("UserID", "1")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var info models.InfoResponse
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &info))
+	assert.Equal(t, 500, info.Balance)
+	assert.Equal(t, 500, info.AvailableBalance)
+}
+
+func TestWalletHandlerSetItemPrice(t *testing.T) {
+	router := newStubWalletRouter(&stubWalletRepository{})
+
+	req := httptest.NewRequest("PUT", "/api/admin/shop/cup", bytes.NewBufferString(`{"price": 0}`))
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, "invalid_price", decodeErrorResponse(t, w).Code)
+
+	req = httptest.NewRequest("PUT", "/api/admin/shop/cup", bytes.NewBufferString(`{"price": 120}`))
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+}
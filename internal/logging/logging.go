@@ -0,0 +1,95 @@
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+)
+
+// Форматы логов
+const (
+	FormatJSON = "json"
+	FormatText = "text"
+)
+
+// Setup настраивает логгер по умолчанию. Записи, сделанные с контекстом запроса
+// (slog.InfoContext и т.п.), получают поля request_id и user_id
+func Setup(w io.Writer, level, format string) error {
+	var lvl slog.Level
+	if err := lvl.UnmarshalText([]byte(level)); err != nil {
+		return fmt.Errorf("invalid log level %q", level)
+	}
+	opts := &slog.HandlerOptions{Level: lvl}
+
+	var handler slog.Handler
+	switch strings.ToLower(format) {
+	case FormatJSON:
+		handler = slog.NewJSONHandler(w, opts)
+	case FormatText:
+		handler = slog.NewTextHandler(w, opts)
+	default:
+		return fmt.Errorf("invalid log format %q", format)
+	}
+
+	slog.SetDefault(slog.New(contextHandler{handler}))
+	return nil
+}
+
+// RequestFields - сведения о запросе, которые добавляются к каждой записи лога.
+// Заполняются middleware по ходу обработки запроса
+type RequestFields struct {
+	RequestID string
+	UserID    int
+}
+
+type fieldsKey struct{}
+
+// NewContext сохраняет сведения о запросе в контексте
+func NewContext(ctx context.Context, fields *RequestFields) context.Context {
+	return context.WithValue(ctx, fieldsKey{}, fields)
+}
+
+// FromContext возвращает сведения о запросе или nil, если контекст не относится к запросу
+func FromContext(ctx context.Context) *RequestFields {
+	fields, _ := ctx.Value(fieldsKey{}).(*RequestFields)
+	return fields
+}
+
+// RequestID возвращает идентификатор запроса из контекста
+func RequestID(ctx context.Context) string {
+	if fields := FromContext(ctx); fields != nil {
+		return fields.RequestID
+	}
+	return ""
+}
+
+// SetUserID запоминает аутентифицированного пользователя для последующих записей лога
+func SetUserID(ctx context.Context, userID int) {
+	if fields := FromContext(ctx); fields != nil {
+		fields.UserID = userID
+	}
+}
+
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h contextHandler) Handle(ctx context.Context, record slog.Record) error {
+	if fields := FromContext(ctx); fields != nil {
+		record.AddAttrs(slog.String("request_id", fields.RequestID))
+		if fields.UserID != 0 {
+			record.AddAttrs(slog.Int("user_id", fields.UserID))
+		}
+	}
+	return h.Handler.Handle(ctx, record)
+}
+
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{h.Handler.WithGroup(name)}
+}
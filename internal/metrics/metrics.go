@@ -0,0 +1,95 @@
+package metrics
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "shop"
+
+// Метрики HTTP. Маршрут - шаблон mux (например, /api/buy/{item}), а не путь запроса,
+// поэтому число рядов не зависит от параметров
+var (
+	HTTPRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "Количество HTTP-запросов по маршруту, методу и статусу ответа.",
+	}, []string{"method", "route", "status"})
+
+	HTTPRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "Время обработки HTTP-запроса.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route"})
+)
+
+// Бизнес-события
+var (
+	CoinsTransferred = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "coins_transferred_total",
+		Help:      "Сумма монет в успешных переводах между пользователями.",
+	})
+
+	Purchases = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "purchases_total",
+		Help:      "Количество купленных предметов по названию.",
+	}, []string{"item"})
+
+	AuthFailures = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "auth_failures_total",
+		Help:      "Неудачные попытки входа по причине.",
+	}, []string{"reason"})
+
+	InsufficientFunds = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "insufficient_funds_total",
+		Help:      "Операции, отклоненные из-за нехватки монет.",
+	}, []string{"operation"})
+)
+
+// Ошибки хранилища лимитов частоты. Запросы при этом пропускаются без ограничения
+var RateLimitStoreErrors = promauto.NewCounter(prometheus.CounterOpts{
+	Namespace: namespace,
+	Name:      "rate_limit_store_errors_total",
+	Help:      "Проверки лимита частоты, не выполненные из-за ошибки хранилища.",
+})
+
+// Причины неудачного входа
+const (
+	AuthReasonInvalidPassword = "invalid_password"
+	AuthReasonAccountDisabled = "account_disabled"
+	AuthReasonTwoFactor       = "invalid_two_factor_code"
+	AuthReasonTwoFactorLocked = "two_factor_locked"
+)
+
+// Операции, для которых учитывается нехватка монет
+const (
+	OperationTransfer = "transfer"
+	OperationPurchase = "purchase"
+	OperationHold     = "hold"
+)
+
+// RegisterDB публикует статистику пула соединений sql.DB. Повторная регистрация не считается ошибкой
+func RegisterDB(db *sql.DB) error {
+	err := prometheus.Register(collectors.NewDBStatsCollector(db, namespace))
+	var already prometheus.AlreadyRegisteredError
+	if errors.As(err, &already) {
+		return nil
+	}
+	return err
+}
+
+// Handler отдает метрики в формате Prometheus
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
@@ -0,0 +1,35 @@
+package middleware
+
+import (
+	"avito-shop-service/internal/apierror"
+	"avito-shop-service/internal/service"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+// AdminMiddleware пропускает только администраторов. Должен подключаться после AuthMiddleware.
+func AdminMiddleware(authService *service.AuthService) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			userID, err := strconv.Atoi(r.Header.Get("UserID"))
+			if err != nil {
+				apierror.Unauthorized(w, "Unauthorized")
+				return
+			}
+
+			isAdmin, err := authService.IsAdmin(r.Context(), userID)
+			if err != nil {
+				apierror.Write(w, err, "Failed to check permissions")
+				return
+			}
+			if !isAdmin {
+				apierror.Forbidden(w, "Forbidden")
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -1,6 +1,8 @@
 package middleware
 
 import (
+	"avito-shop-service/internal/apierror"
+	"avito-shop-service/internal/logging"
 	"avito-shop-service/internal/service"
 	"net/http"
 	"strconv"
@@ -9,31 +11,75 @@ import (
 	"github.com/gorilla/mux"
 )
 
-func AuthMiddleware(authService *service.AuthService) mux.MiddlewareFunc {
+func AuthMiddleware(authService *service.AuthService, tokenService *service.APITokenService) mux.MiddlewareFunc {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			// Извлекаем токен из заголовка
 			authHeader := r.Header.Get("Authorization")
 			if authHeader == "" {
-				http.Error(w, "Missing token", http.StatusUnauthorized)
+				apierror.Unauthorized(w, "Missing token")
 				return
 			}
 
 			// Убираем префикс "Bearer "
 			token := strings.TrimPrefix(authHeader, "Bearer ")
 
-			// Парсим токен и извлекаем user_id
-			userID, err := authService.ParseToken(token)
-			if err != nil {
-				http.Error(w, "Invalid token", http.StatusUnauthorized)
-				return
+			var userID int
+			var sessionID string
+			if service.IsAPIToken(token) {
+				var ok bool
+				if userID, ok = authenticateAPIToken(w, r, authService, tokenService, token); !ok {
+					return
+				}
+			} else {
+				var err error
+				// Проверяем токен и состояние аккаунта, извлекаем user_id.
+				// Токены заблокированных и закрытых аккаунтов, отозванных сессий, а также выданные до смены пароля не принимаются
+				userID, sessionID, err = authService.Authenticate(r.Context(), token)
+				if err != nil {
+					apierror.Write(w, err, "Failed to check token")
+					return
+				}
 			}
 
-			// Добавляем user_id в заголовки запроса
+			// Добавляем user_id в заголовки запроса и в записи лога
 			r.Header.Set("UserID", strconv.Itoa(userID))
+			logging.SetUserID(r.Context(), userID)
+			// Идентификатор сессии задается только сервером; значение от клиента отбрасывается
+			if sessionID != "" {
+				r.Header.Set("SessionID", sessionID)
+			} else {
+				r.Header.Del("SessionID")
+			}
 
 			// Переходим к следующему обработчику
 			next.ServeHTTP(w, r)
 		})
 	}
 }
+
+// Персональный токен принимается только маршрутами, отмеченными Scoped, и только с нужной областью доступа.
+// При отказе ответ уже записан и возвращается false.
+func authenticateAPIToken(w http.ResponseWriter, r *http.Request, authService *service.AuthService, tokenService *service.APITokenService, raw string) (int, bool) {
+	token, err := tokenService.Authenticate(r.Context(), raw)
+	if err != nil {
+		apierror.Write(w, err, "Failed to check token")
+		return 0, false
+	}
+
+	scope, ok := routeScope(r)
+	if !ok {
+		apierror.Forbidden(w, "Personal access tokens are not accepted for this endpoint")
+		return 0, false
+	}
+	if !token.HasScope(scope) {
+		apierror.WriteStatus(w, http.StatusForbidden, service.CodeInsufficientScope, "Token does not have the required scope: "+scope)
+		return 0, false
+	}
+
+	if err := authService.ValidateUser(r.Context(), token.UserID); err != nil {
+		apierror.Write(w, err, "Failed to check token")
+		return 0, false
+	}
+	return token.UserID, true
+}
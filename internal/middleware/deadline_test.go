@@ -0,0 +1,51 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Обработчик получает контекст запроса с ограничением по времени; нулевой timeout его не задает
+func TestDeadline(t *testing.T) {
+	tests := []struct {
+		name         string
+		timeout      time.Duration
+		wantDeadline bool
+	}{
+		{name: "timeout set", timeout: time.Second, wantDeadline: true},
+		{name: "disabled", timeout: 0, wantDeadline: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var deadline time.Time
+			var hasDeadline bool
+			handler := Deadline(tt.timeout)(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+				deadline, hasDeadline = r.Context().Deadline()
+			}))
+
+			start := time.Now()
+			handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/api/info", nil))
+
+			assert.Equal(t, tt.wantDeadline, hasDeadline)
+			if tt.wantDeadline {
+				assert.WithinDuration(t, start.Add(tt.timeout), deadline, 100*time.Millisecond)
+			}
+		})
+	}
+}
+
+// По истечении времени контекст обработчика отменяется
+func TestDeadlineCancelsContext(t *testing.T) {
+	handler := Deadline(10 * time.Millisecond)(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+		assert.ErrorIs(t, r.Context().Err(), context.DeadlineExceeded)
+	}))
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/api/info", nil))
+}
@@ -0,0 +1,45 @@
+package middleware
+
+import (
+	"avito-shop-service/internal/metrics"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+// Метрики запроса учитываются по шаблону маршрута, а не по пути, со статусом ответа обработчика
+func TestMetricsUseRouteTemplate(t *testing.T) {
+	router := mux.NewRouter()
+	router.Use(Metrics)
+	router.HandleFunc("/api/buy/{item}", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}).Methods("POST")
+
+	counter := metrics.HTTPRequests.WithLabelValues("POST", "/api/buy/{item}", "404")
+	before := testutil.ToFloat64(counter)
+
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("POST", "/api/buy/unknown-item", nil))
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("POST", "/api/buy/another-item", nil))
+
+	assert.Equal(t, before+2, testutil.ToFloat64(counter))
+}
+
+// Ответ без явного WriteHeader учитывается как 200
+func TestMetricsDefaultStatus(t *testing.T) {
+	router := mux.NewRouter()
+	router.Use(Metrics)
+	router.HandleFunc("/api/info", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte("{}"))
+	}).Methods("GET")
+
+	counter := metrics.HTTPRequests.WithLabelValues("GET", "/api/info", "200")
+	before := testutil.ToFloat64(counter)
+
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/api/info", nil))
+
+	assert.Equal(t, before+1, testutil.ToFloat64(counter))
+}
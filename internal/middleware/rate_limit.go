@@ -0,0 +1,75 @@
+package middleware
+
+import (
+	"avito-shop-service/internal/apierror"
+	"avito-shop-service/internal/metrics"
+	"avito-shop-service/internal/ratelimit"
+	"avito-shop-service/internal/service"
+	"log/slog"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// RateLimit ограничивает частоту запросов с одним ключом. Запросы с пустым ключом не ограничиваются.
+// Состояние лимита возвращается в заголовках RateLimit-*, при отказе - 429 с Retry-After.
+// Недоступность хранилища не блокирует запросы: сбой базы не должен останавливать весь сервис.
+// Такие запросы учитываются в метрике shop_rate_limit_store_errors_total
+func RateLimit(store ratelimit.Store, limit ratelimit.Limit, key func(*http.Request) string) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		if !limit.Enabled() {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			k := key(r)
+			if k == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			res, err := store.Take(r.Context(), k, limit)
+			if err != nil {
+				slog.WarnContext(r.Context(), "rate limit check failed", slog.Any("error", err))
+				metrics.RateLimitStoreErrors.Inc()
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			w.Header().Set("RateLimit-Limit", strconv.Itoa(res.Limit))
+			w.Header().Set("RateLimit-Remaining", strconv.Itoa(res.Remaining))
+			w.Header().Set("RateLimit-Reset", strconv.Itoa(ceilSeconds(res.ResetAfter)))
+
+			if !res.Allowed {
+				w.Header().Set("Retry-After", strconv.Itoa(max(1, ceilSeconds(res.RetryAfter))))
+				apierror.WriteStatus(w, http.StatusTooManyRequests, service.CodeRateLimited, "Too many requests")
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// UserKey - ключ лимита аутентифицированного пользователя. Должен использоваться после AuthMiddleware
+func UserKey(r *http.Request) string {
+	if userID := r.Header.Get("UserID"); userID != "" {
+		return "user:" + userID
+	}
+	return ""
+}
+
+// IPKey - ключ лимита по адресу клиента
+func IPKey(r *http.Request) string {
+	ip, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		ip = r.RemoteAddr
+	}
+	return "ip:" + ip
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
@@ -0,0 +1,156 @@
+package middleware
+
+import (
+	"avito-shop-service/internal/apierror"
+	"avito-shop-service/internal/metrics"
+	"avito-shop-service/internal/ratelimit"
+	"avito-shop-service/internal/service"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+// Хранилище лимитов с заданным ответом
+type stubRateLimitStore struct {
+	res ratelimit.Result
+	err error
+}
+
+func (s stubRateLimitStore) Take(_ context.Context, _ string, _ ratelimit.Limit) (ratelimit.Result, error) {
+	return s.res, s.err
+}
+
+func newRateLimitedHandler(store ratelimit.Store, limit ratelimit.Limit) http.Handler {
+	return RateLimit(store, limit, UserKey)(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+}
+
+func serveAs(handler http.Handler, userID string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest("POST", "/api/buy/cup", nil)
+	req.Header.Set("UserID", userID)
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	return w
+}
+
+// После исчерпания корзины запросы пользователя отклоняются с Retry-After; другие пользователи не затронуты
+func TestRateLimitPerUser(t *testing.T) {
+	handler := newRateLimitedHandler(ratelimit.NewMemoryStore(), ratelimit.PerMinute(60, 2))
+
+	w := serveAs(handler, "1")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "2", w.Header().Get("RateLimit-Limit"))
+	assert.Equal(t, "1", w.Header().Get("RateLimit-Remaining"))
+
+	assert.Equal(t, http.StatusOK, serveAs(handler, "1").Code)
+
+	w = serveAs(handler, "1")
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "0", w.Header().Get("RateLimit-Remaining"))
+	assert.Equal(t, "1", w.Header().Get("Retry-After"))
+	assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+
+	var resp apierror.Response
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, service.CodeRateLimited, resp.Code)
+
+	assert.Equal(t, http.StatusOK, serveAs(handler, "2").Code)
+}
+
+func TestRateLimitHeaders(t *testing.T) {
+	tests := []struct {
+		name           string
+		store          stubRateLimitStore
+		wantStatus     int
+		wantHeaders    map[string]string
+		wantStoreError bool
+	}{
+		{
+			name:        "allowed",
+			store:       stubRateLimitStore{res: ratelimit.Result{Allowed: true, Limit: 30, Remaining: 12, ResetAfter: 8500 * time.Millisecond}},
+			wantStatus:  http.StatusOK,
+			wantHeaders: map[string]string{"RateLimit-Limit": "30", "RateLimit-Remaining": "12", "RateLimit-Reset": "9", "Retry-After": ""},
+		},
+		{
+			name:        "rejected",
+			store:       stubRateLimitStore{res: ratelimit.Result{Limit: 30, ResetAfter: 15 * time.Second, RetryAfter: 1500 * time.Millisecond}},
+			wantStatus:  http.StatusTooManyRequests,
+			wantHeaders: map[string]string{"RateLimit-Remaining": "0", "RateLimit-Reset": "15", "Retry-After": "2"},
+		},
+		{
+			// Токен почти накопился: клиент все равно ждет не меньше секунды
+			name:        "retry after is at least one second",
+			store:       stubRateLimitStore{res: ratelimit.Result{Limit: 30, RetryAfter: 10 * time.Millisecond}},
+			wantStatus:  http.StatusTooManyRequests,
+			wantHeaders: map[string]string{"Retry-After": "1"},
+		},
+		{
+			// Недоступное хранилище не останавливает сервис
+			name:           "store error lets request through",
+			store:          stubRateLimitStore{err: errors.New("connection refused")},
+			wantStatus:     http.StatusOK,
+			wantHeaders:    map[string]string{"RateLimit-Limit": "", "Retry-After": ""},
+			wantStoreError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			storeErrors := testutil.ToFloat64(metrics.RateLimitStoreErrors)
+
+			w := serveAs(newRateLimitedHandler(tt.store, ratelimit.PerMinute(60, 30)), "1")
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+			for header, want := range tt.wantHeaders {
+				assert.Equal(t, want, w.Header().Get(header), header)
+			}
+			if tt.wantStoreError {
+				assert.Equal(t, storeErrors+1, testutil.ToFloat64(metrics.RateLimitStoreErrors))
+			}
+		})
+	}
+}
+
+// Запросы без ключа и при отключенном лимите хранилище не затрагивают
+func TestRateLimitSkipsStore(t *testing.T) {
+	failing := stubRateLimitStore{err: errors.New("must not be called")}
+
+	tests := []struct {
+		name   string
+		limit  ratelimit.Limit
+		userID string
+	}{
+		{name: "no key", limit: ratelimit.PerMinute(60, 1), userID: ""},
+		{name: "disabled limit", limit: ratelimit.PerMinute(0, 0), userID: "1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			storeErrors := testutil.ToFloat64(metrics.RateLimitStoreErrors)
+
+			w := serveAs(newRateLimitedHandler(failing, tt.limit), tt.userID)
+
+			assert.Equal(t, http.StatusOK, w.Code)
+			assert.Empty(t, w.Header().Get("RateLimit-Limit"))
+			assert.Equal(t, storeErrors, testutil.ToFloat64(metrics.RateLimitStoreErrors))
+		})
+	}
+}
+
+func TestRateLimitKeys(t *testing.T) {
+	req := httptest.NewRequest("GET", "/api/info", nil)
+	req.RemoteAddr = "10.0.0.7:51234"
+	assert.Equal(t, "ip:10.0.0.7", IPKey(req))
+	assert.Equal(t, "", UserKey(req))
+
+	req.Header.Set("UserID", "5")
+	assert.Equal(t, "user:5", UserKey(req))
+}
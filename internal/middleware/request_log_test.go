@@ -0,0 +1,89 @@
+package middleware
+
+import (
+	"avito-shop-service/internal/logging"
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+// Логи пишутся в буфер в формате JSON
+func captureLogs(t *testing.T) *bytes.Buffer {
+	t.Helper()
+	var buf bytes.Buffer
+	defaultLogger := slog.Default()
+	assert.NoError(t, logging.Setup(&buf, "info", logging.FormatJSON))
+	t.Cleanup(func() { slog.SetDefault(defaultLogger) })
+	return &buf
+}
+
+func newRequestLogRouter(skipPaths ...string) *mux.Router {
+	router := mux.NewRouter()
+	router.Use(RequestID, AccessLog(skipPaths...))
+	router.HandleFunc("/api/buy/{item}", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}).Methods("POST")
+	router.HandleFunc("/health", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}).Methods("GET")
+	return router
+}
+
+// Идентификатор запроса принимается от клиента или создается и попадает в строку access-лога
+func TestRequestIDInAccessLog(t *testing.T) {
+	buf := captureLogs(t)
+	router := newRequestLogRouter()
+
+	req := httptest.NewRequest("POST", "/api/buy/unknown-item", nil)
+	req.Header.Set(RequestIDHeader, "req-42")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, "req-42", w.Header().Get(RequestIDHeader))
+
+	var entry map[string]interface{}
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+	assert.Equal(t, "req-42", entry["request_id"])
+	assert.Equal(t, "/api/buy/{item}", entry["route"])
+	assert.Equal(t, float64(http.StatusNotFound), entry["status"])
+}
+
+func TestRequestIDReplacesInvalid(t *testing.T) {
+	tests := []struct {
+		name string
+		id   string
+	}{
+		{name: "missing", id: ""},
+		{name: "unsafe characters", id: "bad id\nwith newline"},
+		{name: "too long", id: string(bytes.Repeat([]byte("a"), maxRequestIDLength+1))},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("POST", "/api/buy/unknown-item", nil)
+			req.Header.Set(RequestIDHeader, tt.id)
+			w := httptest.NewRecorder()
+			newRequestLogRouter().ServeHTTP(w, req)
+
+			assert.Len(t, w.Header().Get(RequestIDHeader), 32)
+		})
+	}
+}
+
+// Пробы не попадают в access-лог, но получают идентификатор запроса
+func TestAccessLogSkipsPaths(t *testing.T) {
+	buf := captureLogs(t)
+
+	w := httptest.NewRecorder()
+	newRequestLogRouter("/health").ServeHTTP(w, httptest.NewRequest("GET", "/health", nil))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NotEmpty(t, w.Header().Get(RequestIDHeader))
+	assert.Empty(t, buf.String())
+}
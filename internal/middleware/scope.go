@@ -0,0 +1,36 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+// Обработчик маршрута, доступного по персональному токену
+type scopedHandler struct {
+	scope string
+	next  http.Handler
+}
+
+func (h scopedHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.next.ServeHTTP(w, r)
+}
+
+// Scoped разрешает вызов маршрута персональным токеном с областью доступа scope.
+// Маршруты без отметки принимают только JWT.
+func Scoped(scope string, next http.HandlerFunc) http.Handler {
+	return scopedHandler{scope: scope, next: next}
+}
+
+// Область доступа, требуемая сопоставленным маршрутом
+func routeScope(r *http.Request) (string, bool) {
+	route := mux.CurrentRoute(r)
+	if route == nil {
+		return "", false
+	}
+	h, ok := route.GetHandler().(scopedHandler)
+	if !ok {
+		return "", false
+	}
+	return h.scope, true
+}
@@ -0,0 +1,48 @@
+package models
+
+import "time"
+
+// Способы распоряжения остатком баланса при закрытии аккаунта
+const (
+	DispositionForfeit  = "forfeit"  // Остаток списывается в общий фонд
+	DispositionTransfer = "transfer" // Остаток переводится выбранному пользователю
+)
+
+// Результат закрытия аккаунта
+type AccountClosure struct {
+	UserID        int    `json:"user_id"`
+	Disposition   string `json:"disposition"`
+	BeneficiaryID int    `json:"beneficiary_id,omitempty"`
+	AmountSettled int    `json:"amount_settled"`
+	Reason        string `json:"reason"`
+	ClosedBy      int    `json:"closed_by"`
+	// Администратор разрешил перевод остатка, несмотря на заморозку аккаунта
+	Override  bool      `json:"override,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Отдельная покупка пользователя
+type Purchase struct {
+	ID        int       `json:"id"`
+	Item      string    `json:"item"`
+	Price     int       `json:"price"`
+	Quantity  int       `json:"quantity"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Выгрузка всех данных пользователя для ответа на запрос /api/me/export
+type UserExport struct {
+	ExportedAt       time.Time      `json:"exported_at"`
+	User             *User          `json:"user"`
+	Balance          int            `json:"balance"`
+	AvailableBalance int            `json:"available_balance"`
+	Purchases        []Purchase     `json:"purchases"`
+	Transactions     []Transaction  `json:"transactions"`
+	Adjustments      []Adjustment   `json:"adjustments"`
+	Holds            []Hold         `json:"holds"`
+	StatusHistory    []StatusChange `json:"status_history"`
+	Identities       []Identity     `json:"identities"`
+	Sessions         []Session      `json:"sessions"`
+	APITokens        []APIToken     `json:"api_tokens"`
+	FraudFlags       []FraudFlag    `json:"fraud_flags"`
+}
@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+// Структура административной корректировки баланса (начисление или списание)
+type Adjustment struct {
+	ID        int       `json:"id"`
+	UserID    int       `json:"user_id"`
+	AdminID   int       `json:"admin_id"`
+	Amount    int       `json:"amount"` // Положительное значение - начисление, отрицательное - списание
+	Reason    string    `json:"reason"`
+	Reference string    `json:"reference"`
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -0,0 +1,38 @@
+package models
+
+import "time"
+
+// Области доступа персональных токенов
+const (
+	ScopeInfoRead   = "info:read"   // Баланс, история и холды
+	ScopeCoinsSend  = "coins:send"  // Переводы монет
+	ScopeShopBuy    = "shop:buy"    // Покупки в магазине
+	ScopeHoldsWrite = "holds:write" // Создание, списание и снятие холдов
+	ScopeAdminCoins = "admin:coins" // Начисления администратора (требует роль admin)
+)
+
+// Все известные области доступа
+var APITokenScopes = []string{ScopeInfoRead, ScopeCoinsSend, ScopeShopBuy, ScopeHoldsWrite, ScopeAdminCoins}
+
+// Персональный токен доступа
+type APIToken struct {
+	ID         int        `json:"id"`
+	UserID     int        `json:"user_id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Scopes     []string   `json:"scopes"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// HasScope сообщает, разрешена ли токену область доступа
+func (t *APIToken) HasScope(scope string) bool {
+	for _, s := range t.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
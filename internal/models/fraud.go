@@ -0,0 +1,52 @@
+package models
+
+import "time"
+
+// Шаблоны подозрительной активности
+const (
+	FraudPatternDrainedNewAccount = "drained_new_account" // Новый аккаунт сразу перевел почти все монеты одному пользователю
+	FraudPatternCircularFlow      = "circular_flow"       // Монеты прошли по кругу между несколькими аккаунтами
+	FraudPatternFunnel            = "funnel"              // Много новых аккаунтов переводят монеты одному получателю
+)
+
+// Статусы отметок о подозрительной активности
+const (
+	FraudFlagOpen      = "open"
+	FraudFlagDismissed = "dismissed"
+	FraudFlagConfirmed = "confirmed"
+)
+
+// Отметка о подозрительной активности пользователя, ожидающая проверки администратором
+type FraudFlag struct {
+	ID         int        `json:"id"`
+	UserID     int        `json:"user_id"`
+	Pattern    string     `json:"pattern"`
+	Details    string     `json:"details"`
+	Status     string     `json:"status"`
+	CreatedAt  time.Time  `json:"created_at"`
+	ReviewedBy *int       `json:"reviewed_by,omitempty"`
+	ReviewedAt *time.Time `json:"reviewed_at,omitempty"`
+}
+
+// Новый аккаунт, переведший крупную сумму одному получателю вскоре после регистрации
+type DrainedAccount struct {
+	UserID      int
+	RecipientID int
+	Amount      int
+}
+
+// Получатель переводов от множества новых аккаунтов
+type FunnelRecipient struct {
+	UserID  int
+	Senders int
+	Amount  int
+}
+
+// Параметры поиска подозрительных переводов
+type FraudScanParams struct {
+	Lookback         time.Duration // Анализируются переводы за этот период
+	NewAccountAge    time.Duration // Аккаунт считается новым в течение этого времени после регистрации
+	DrainMinAmount   int           // Минимальная сумма, переведенная новым аккаунтом одному получателю
+	FunnelMinSenders int           // Минимальное количество новых аккаунтов-отправителей
+	CycleMinAmount   int           // Минимальная сумма каждого перевода в цикле
+}
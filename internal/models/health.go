@@ -0,0 +1,25 @@
+package models
+
+const (
+	HealthStatusOK   = "ok"
+	HealthStatusFail = "fail"
+)
+
+// Состояние сервиса для проб liveness и readiness
+type HealthReport struct {
+	Status     string                     `json:"status"`
+	Components map[string]ComponentHealth `json:"components,omitempty"`
+}
+
+// Состояние отдельной зависимости
+type ComponentHealth struct {
+	Status   string `json:"status"`
+	Error    string `json:"error,omitempty"`
+	Version  int    `json:"version,omitempty"`  // Только для migrations
+	Expected int    `json:"expected,omitempty"` // Только для migrations
+}
+
+// Healthy сообщает, что все компоненты в порядке
+func (r HealthReport) Healthy() bool {
+	return r.Status == HealthStatusOK
+}
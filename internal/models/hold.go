@@ -0,0 +1,24 @@
+package models
+
+import "time"
+
+// Статусы холда
+const (
+	HoldStatusActive   = "active"
+	HoldStatusCaptured = "captured"
+	HoldStatusReleased = "released"
+	HoldStatusExpired  = "expired" // Вычисляемый статус: активный холд с истекшим сроком
+)
+
+// Структура холда (резерва монет на балансе пользователя)
+type Hold struct {
+	ID             int        `json:"id"`
+	UserID         int        `json:"user_id"`
+	Amount         int        `json:"amount"`
+	CapturedAmount *int       `json:"captured_amount,omitempty"`
+	Status         string     `json:"status"`
+	Reason         string     `json:"reason"`
+	ExpiresAt      time.Time  `json:"expires_at"`
+	CreatedAt      time.Time  `json:"created_at"`
+	SettledAt      *time.Time `json:"settled_at,omitempty"`
+}
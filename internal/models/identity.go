@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+// Внешняя учетная запись пользователя у провайдера OIDC
+type Identity struct {
+	ID        int       `json:"id"`
+	UserID    int       `json:"user_id"`
+	Issuer    string    `json:"issuer"`
+	Subject   string    `json:"subject"`
+	Email     string    `json:"email,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Параметры незавершенного входа через OIDC
+type OIDCLoginState struct {
+	State        string
+	CodeVerifier string
+	Nonce        string
+	ExpiresAt    time.Time
+}
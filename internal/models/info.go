@@ -8,14 +8,24 @@ type Transaction struct {
 	FromUserID int       `json:"from_user_id"`
 	ToUserID   int       `json:"to_user_id"`
 	Amount     int       `json:"amount"`
+	ReversalOf *int      `json:"reversal_of,omitempty"` // Для компенсирующей транзакции - id отмененного перевода
 	CreatedAt  time.Time `json:"created_at"`
 }
 
+// Политики отмены перевода, если получатель уже потратил монеты
+const (
+	ReversalPolicyStrict        = "strict"         // Отмена только на полную сумму
+	ReversalPolicyPartial       = "partial"        // Отмена в пределах доступного баланса получателя
+	ReversalPolicyAllowNegative = "allow_negative" // Полная отмена с уходом баланса получателя в минус
+)
+
 // Структура для ответа на запрос /api/info
 type InfoResponse struct {
-	Balance      int           `json:"balance"`
-	Inventory    []Item        `json:"inventory"`
-	Transactions []Transaction `json:"transactions"`
+	Balance          int           `json:"balance"`
+	AvailableBalance int           `json:"available_balance"` // Баланс за вычетом активных холдов
+	Inventory        []Item        `json:"inventory"`
+	Transactions     []Transaction `json:"transactions"`
+	Adjustments      []Adjustment  `json:"adjustments"` // Административные начисления и списания
 }
 
 // Структура для представления предмета в инвентаре
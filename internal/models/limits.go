@@ -0,0 +1,29 @@
+package models
+
+// Лимиты переводов пользователя (0 - без ограничений)
+type TransferLimits struct {
+	MaxAmount           int `json:"max_amount"`
+	MaxDailyVolume      int `json:"max_daily_volume"`
+	MaxPerHour          int `json:"max_per_hour"`
+	MaxRecipientsPerDay int `json:"max_recipients_per_day"`
+}
+
+// Индивидуальное переопределение лимитов; nil-поле означает лимит по умолчанию
+type TransferLimitOverride struct {
+	MaxAmount           *int `json:"max_amount"`
+	MaxDailyVolume      *int `json:"max_daily_volume"`
+	MaxPerHour          *int `json:"max_per_hour"`
+	MaxRecipientsPerDay *int `json:"max_recipients_per_day"`
+}
+
+// Проверка лимитов по статистике отправителя. Выполняется в транзакции перевода после
+// блокировки строки отправителя, поэтому параллельные переводы проверяются по очереди
+type TransferCheck func(stats TransferStats) error
+
+// Статистика исходящих переводов пользователя за скользящие окна (час и сутки)
+type TransferStats struct {
+	HourlyCount        int
+	DailyVolume        int
+	DailyRecipients    int
+	RecipientSeenToday bool // Переводы этому получателю за сутки уже были
+}
@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+// Сведения о клиенте, с которого выполняется вход
+type ClientInfo struct {
+	UserAgent string
+	IP        string
+}
+
+// Сессия пользователя, созданная при входе
+type Session struct {
+	ID         string    `json:"id"`
+	UserID     int       `json:"-"`
+	UserAgent  string    `json:"user_agent"`
+	IP         string    `json:"ip"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastSeenAt time.Time `json:"last_seen_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+	Current    bool      `json:"current"` // Сессия, с которой выполнен запрос
+}
@@ -0,0 +1,26 @@
+package models
+
+import "time"
+
+// Настройки TOTP пользователя
+type TOTP struct {
+	UserID       int
+	Secret       string
+	EnabledAt    *time.Time // nil - подключение не подтверждено
+	LastUsedStep int64
+	LockedUntil  *time.Time // Проверка кодов приостановлена после серии неверных кодов
+}
+
+// Данные для подключения приложения-аутентификатора
+type TOTPEnrollment struct {
+	Secret string `json:"secret"`
+	URI    string `json:"otpauth_uri"`
+}
+
+// Результат входа. Если у пользователя включена двухфакторная аутентификация,
+// вместо токена доступа выдается краткоживущий токен подтверждения.
+type LoginResult struct {
+	Token             string `json:"token,omitempty"`
+	ChallengeToken    string `json:"challenge_token,omitempty"`
+	TwoFactorRequired bool   `json:"two_factor_required,omitempty"`
+}
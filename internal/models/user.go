@@ -2,10 +2,43 @@ package models
 
 import "time"
 
+// Роли пользователей
+const (
+	RoleUser  = "user"
+	RoleAdmin = "admin"
+)
+
+// Состояния аккаунта
+const (
+	StatusActive    = "active"
+	StatusFrozen    = "frozen"    // Вход и просмотр разрешены, движение монет запрещено
+	StatusSuspended = "suspended" // Вход запрещен, выданные токены не принимаются
+	StatusClosed    = "closed"    // Аккаунт закрыт окончательно
+)
+
 type User struct {
 	ID           int       `json:"id"`
 	Username     string    `json:"username"`
 	PasswordHash string    `json:"-"`     // Приватное поле для хранения хеша пароля
 	Coins        int       `json:"coins"` // Баланс пользователя, при регистрации пользователь получает 1000 Coins
+	Role         string    `json:"role"`
+	Status       string    `json:"status"`
+	TokenVersion int       `json:"-"` // Увеличивается при смене пароля, инвалидируя выданные токены
 	CreatedAt    time.Time `json:"created_at"`
 }
+
+// CanLogin сообщает, разрешены ли вход и работа с API в текущем состоянии аккаунта
+func (u *User) CanLogin() bool {
+	return u.Status == StatusActive || u.Status == StatusFrozen
+}
+
+// Запись журнала изменений состояния аккаунта
+type StatusChange struct {
+	ID        int       `json:"id"`
+	UserID    int       `json:"user_id"`
+	OldStatus string    `json:"old_status"`
+	NewStatus string    `json:"new_status"`
+	Reason    string    `json:"reason"`
+	ChangedBy int       `json:"changed_by"`
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -0,0 +1,68 @@
+package ratelimit
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"time"
+)
+
+// Корзины, не менявшиеся дольше этого времени, удаляются. При разумных лимитах
+// они давно заполнились, и удаление равносильно сохранению полной корзины
+const postgresBucketRetention = time.Hour
+
+// PostgresStore хранит корзины в общей базе, поэтому лимит действует на все экземпляры сервиса
+type PostgresStore struct {
+	db *sql.DB
+}
+
+func NewPostgresStore(db *sql.DB) *PostgresStore {
+	return &PostgresStore{db: db}
+}
+
+// Take пополняет корзину и берет токен одним запросом; конкурентные запросы
+// к одной корзине упорядочиваются блокировкой строки
+func (s *PostgresStore) Take(ctx context.Context, key string, limit Limit) (Result, error) {
+	if !limit.Enabled() {
+		return Result{}, ErrInvalidLimit
+	}
+
+	var tokens float64
+	var allowed bool
+	err := s.db.QueryRowContext(ctx, `
+		INSERT INTO rate_limit_buckets AS b (key, tokens, allowed, updated_at)
+		VALUES ($1, $2::float8 - 1, TRUE, NOW())
+		ON CONFLICT (key) DO UPDATE SET
+			tokens = CASE
+				WHEN LEAST($2::float8, b.tokens + EXTRACT(EPOCH FROM NOW() - b.updated_at) * $3::float8) >= 1
+				THEN LEAST($2::float8, b.tokens + EXTRACT(EPOCH FROM NOW() - b.updated_at) * $3::float8) - 1
+				ELSE LEAST($2::float8, b.tokens + EXTRACT(EPOCH FROM NOW() - b.updated_at) * $3::float8)
+			END,
+			allowed = LEAST($2::float8, b.tokens + EXTRACT(EPOCH FROM NOW() - b.updated_at) * $3::float8) >= 1,
+			updated_at = NOW()
+		RETURNING tokens, allowed
+	`, key, limit.Burst, limit.Rate).Scan(&tokens, &allowed)
+	if err != nil {
+		return Result{}, err
+	}
+	return result(limit, tokens, allowed), nil
+}
+
+// Run периодически удаляет давно не использовавшиеся корзины до отмены контекста
+func (s *PostgresStore) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			_, err := s.db.ExecContext(ctx, "DELETE FROM rate_limit_buckets WHERE updated_at < NOW() - make_interval(secs => $1)",
+				postgresBucketRetention.Seconds())
+			if err != nil && ctx.Err() == nil {
+				slog.ErrorContext(ctx, "failed to purge rate limit buckets", slog.Any("error", err))
+			}
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"math"
+	"time"
+)
+
+// Limit - параметры корзины токенов: Burst запросов подряд, затем Rate запросов в секунду
+type Limit struct {
+	Rate  float64
+	Burst int
+}
+
+// PerMinute задает лимит в запросах в минуту
+func PerMinute(requests, burst int) Limit {
+	return Limit{Rate: float64(requests) / 60, Burst: burst}
+}
+
+// Enabled сообщает, что лимит задан. Нулевой лимит отключает ограничение
+func (l Limit) Enabled() bool {
+	return l.Rate > 0 && l.Burst > 0
+}
+
+// Result - решение по запросу и состояние корзины после него
+type Result struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	ResetAfter time.Duration // через сколько корзина заполнится полностью
+	RetryAfter time.Duration // через сколько появится токен; только для отклоненных запросов
+}
+
+// Store хранит корзины токенов. Take расходует токен корзины key, если он есть
+type Store interface {
+	Take(ctx context.Context, key string, limit Limit) (Result, error)
+}
+
+var ErrInvalidLimit = errors.New("rate limit must have positive rate and burst")
+
+// Состояние корзины после пополнения и попытки взять токен
+func result(limit Limit, tokens float64, allowed bool) Result {
+	res := Result{
+		Allowed:    allowed,
+		Limit:      limit.Burst,
+		Remaining:  int(math.Max(0, math.Floor(tokens))),
+		ResetAfter: seconds((float64(limit.Burst) - tokens) / limit.Rate),
+	}
+	if !allowed {
+		res.RetryAfter = seconds((1 - tokens) / limit.Rate)
+	}
+	return res
+}
+
+func seconds(s float64) time.Duration {
+	if s <= 0 {
+		return 0
+	}
+	return time.Duration(math.Ceil(s * float64(time.Second)))
+}
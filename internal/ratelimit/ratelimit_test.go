@@ -0,0 +1,197 @@
+package ratelimit
+
+import (
+	"avito-shop-service/config"
+	"avito-shop-service/internal/repository"
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Хранилище в памяти с управляемыми часами
+func newTestMemoryStore() (*MemoryStore, *time.Time) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	store := NewMemoryStore()
+	store.now = func() time.Time { return now }
+	return store, &now
+}
+
+func TestResult(t *testing.T) {
+	limit := Limit{Rate: 2, Burst: 10}
+
+	tests := []struct {
+		name    string
+		tokens  float64
+		allowed bool
+		want    Result
+	}{
+		{
+			name: "full bucket", tokens: 10, allowed: true,
+			want: Result{Allowed: true, Limit: 10, Remaining: 10},
+		},
+		{
+			name: "fractional tokens round down", tokens: 3.5, allowed: true,
+			want: Result{Allowed: true, Limit: 10, Remaining: 3, ResetAfter: 3250 * time.Millisecond},
+		},
+		{
+			name: "rejected with half a token", tokens: 0.5, allowed: false,
+			want: Result{Limit: 10, Remaining: 0, ResetAfter: 4750 * time.Millisecond, RetryAfter: 250 * time.Millisecond},
+		},
+		{
+			name: "rejected empty bucket", tokens: 0, allowed: false,
+			want: Result{Limit: 10, Remaining: 0, ResetAfter: 5 * time.Second, RetryAfter: 500 * time.Millisecond},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, result(limit, tt.tokens, tt.allowed))
+		})
+	}
+}
+
+func TestRefill(t *testing.T) {
+	limit := PerMinute(60, 5) // один токен в секунду
+
+	tests := []struct {
+		name    string
+		tokens  float64
+		elapsed time.Duration
+		want    float64
+	}{
+		{name: "no time passed", tokens: 2, elapsed: 0, want: 2},
+		{name: "partial token", tokens: 0, elapsed: 500 * time.Millisecond, want: 0.5},
+		{name: "several tokens", tokens: 1, elapsed: 3 * time.Second, want: 4},
+		{name: "capped at burst", tokens: 4, elapsed: time.Hour, want: 5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.InDelta(t, tt.want, refill(tt.tokens, tt.elapsed, limit), 1e-9)
+		})
+	}
+}
+
+func TestLimitEnabled(t *testing.T) {
+	assert.True(t, PerMinute(120, 30).Enabled())
+	assert.False(t, PerMinute(0, 30).Enabled())
+	assert.False(t, PerMinute(120, 0).Enabled())
+}
+
+// Сначала расходуется запас burst, затем запросы проходят с частотой rate
+func TestMemoryStoreBurstAndRefill(t *testing.T) {
+	store, now := newTestMemoryStore()
+	limit := PerMinute(60, 3)
+	ctx := context.Background()
+
+	steps := []struct {
+		advance       time.Duration
+		wantAllowed   bool
+		wantRemaining int
+		wantRetry     time.Duration
+	}{
+		{wantAllowed: true, wantRemaining: 2},
+		{wantAllowed: true, wantRemaining: 1},
+		{wantAllowed: true, wantRemaining: 0},
+		{wantAllowed: false, wantRemaining: 0, wantRetry: time.Second},
+		{advance: 400 * time.Millisecond, wantAllowed: false, wantRemaining: 0, wantRetry: 600 * time.Millisecond},
+		{advance: 600 * time.Millisecond, wantAllowed: true, wantRemaining: 0},
+		{advance: 10 * time.Second, wantAllowed: true, wantRemaining: 2},
+	}
+
+	for i, step := range steps {
+		*now = now.Add(step.advance)
+		res, err := store.Take(ctx, "user:1", limit)
+		assert.NoError(t, err)
+		assert.Equal(t, step.wantAllowed, res.Allowed, "step %d", i)
+		assert.Equal(t, step.wantRemaining, res.Remaining, "step %d", i)
+		assert.Equal(t, step.wantRetry, res.RetryAfter, "step %d", i)
+	}
+}
+
+// Корзины разных ключей независимы
+func TestMemoryStoreKeys(t *testing.T) {
+	store, _ := newTestMemoryStore()
+	limit := PerMinute(60, 1)
+
+	res, _ := store.Take(context.Background(), "user:1", limit)
+	assert.True(t, res.Allowed)
+	res, _ = store.Take(context.Background(), "user:1", limit)
+	assert.False(t, res.Allowed)
+	res, _ = store.Take(context.Background(), "user:2", limit)
+	assert.True(t, res.Allowed)
+}
+
+// Заполнившиеся корзины удаляются, неполные сохраняются
+func TestMemoryStoreSweep(t *testing.T) {
+	store, now := newTestMemoryStore()
+	ctx := context.Background()
+
+	_, _ = store.Take(ctx, "fast", PerMinute(60, 2))  // заполнится за секунду
+	_, _ = store.Take(ctx, "slow", PerMinute(1, 100)) // заполнится за 100 минут
+	assert.Len(t, store.buckets, 2)
+
+	// Очистка выполняется не чаще memorySweepInterval
+	*now = now.Add(memorySweepInterval / 2)
+	_, _ = store.Take(ctx, "other", PerMinute(60, 2))
+	assert.Len(t, store.buckets, 3)
+
+	*now = now.Add(memorySweepInterval)
+	_, _ = store.Take(ctx, "slow", PerMinute(1, 100))
+	assert.NotContains(t, store.buckets, "fast")
+	assert.NotContains(t, store.buckets, "other")
+	assert.Contains(t, store.buckets, "slow")
+}
+
+func TestStoresRejectDisabledLimit(t *testing.T) {
+	_, err := NewMemoryStore().Take(context.Background(), "user:1", Limit{})
+	assert.ErrorIs(t, err, ErrInvalidLimit)
+
+	_, err = NewPostgresStore(nil).Take(context.Background(), "user:1", Limit{Rate: 1})
+	assert.ErrorIs(t, err, ErrInvalidLimit)
+}
+
+// Корзины в базе ведут себя так же, как в памяти. Нужна запущенная база данных с миграциями
+func TestPostgresStore(t *testing.T) {
+	cfg := config.LoadConfig()
+	cfg.DBConnectRetryTimeout = 0
+	db, err := repository.ConnectDB(context.Background(), cfg)
+	if err != nil {
+		t.Skip(err)
+	}
+	defer db.Close()
+
+	store := NewPostgresStore(db)
+	ctx := context.Background()
+	key := fmt.Sprintf("test:%d", time.Now().UnixNano())
+	defer db.Exec("DELETE FROM rate_limit_buckets WHERE key = $1", key)
+
+	// Пополнение за время теста (доли секунды) не должно давать целого токена
+	limit := PerMinute(1, 2)
+
+	res, err := store.Take(ctx, key, limit)
+	assert.NoError(t, err)
+	assert.True(t, res.Allowed)
+	assert.Equal(t, 1, res.Remaining)
+
+	res, err = store.Take(ctx, key, limit)
+	assert.NoError(t, err)
+	assert.True(t, res.Allowed)
+	assert.Equal(t, 0, res.Remaining)
+
+	res, err = store.Take(ctx, key, limit)
+	assert.NoError(t, err)
+	assert.False(t, res.Allowed)
+	assert.Greater(t, res.RetryAfter, 50*time.Second)
+
+	// Корзина заполнилась, пока ее никто не использовал
+	_, err = db.Exec("UPDATE rate_limit_buckets SET updated_at = NOW() - INTERVAL '10 minutes' WHERE key = $1", key)
+	assert.NoError(t, err)
+	res, err = store.Take(ctx, key, limit)
+	assert.NoError(t, err)
+	assert.True(t, res.Allowed)
+	assert.Equal(t, 1, res.Remaining)
+}
@@ -0,0 +1,371 @@
+package repository
+
+import (
+	"avito-shop-service/internal/models"
+	"context"
+	"database/sql"
+	"errors"
+)
+
+type AccountRepository interface {
+	SetStatus(ctx context.Context, userID, adminID int, status, reason string) (*models.StatusChange, error)
+	GetStatusHistory(ctx context.Context, userID int) ([]models.StatusChange, error)
+	CloseAccount(ctx context.Context, closure *models.AccountClosure) error
+	GetPurchases(ctx context.Context, userID int) ([]models.Purchase, error)
+	GetIdentities(ctx context.Context, userID int) ([]models.Identity, error)
+	GetSessions(ctx context.Context, userID int) ([]models.Session, error)
+	GetAPITokens(ctx context.Context, userID int) ([]models.APIToken, error)
+	GetFraudFlags(ctx context.Context, userID int) ([]models.FraudFlag, error)
+}
+
+type PostgresAccountRepository struct {
+	db *sql.DB
+}
+
+func NewPostgresAccountRepository(db *sql.DB) *PostgresAccountRepository {
+	return &PostgresAccountRepository{db: db}
+}
+
+// SetStatus меняет состояние аккаунта и записывает изменение в журнал
+func (r *PostgresAccountRepository) SetStatus(ctx context.Context, userID, adminID int, status, reason string) (*models.StatusChange, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	change, err := setStatusInTx(ctx, tx, userID, adminID, status, reason)
+	if err != nil {
+		rollback(ctx, tx)
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return change, nil
+}
+
+// GetStatusHistory возвращает журнал изменений состояния аккаунта, начиная с последних
+func (r *PostgresAccountRepository) GetStatusHistory(ctx context.Context, userID int) ([]models.StatusChange, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT id, user_id, old_status, new_status, reason, COALESCE(changed_by, 0), created_at
+		FROM account_status_log
+		WHERE user_id = $1
+		ORDER BY created_at DESC
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var history []models.StatusChange
+	for rows.Next() {
+		var c models.StatusChange
+		if err := rows.Scan(&c.ID, &c.UserID, &c.OldStatus, &c.NewStatus, &c.Reason, &c.ChangedBy, &c.CreatedAt); err != nil {
+			return nil, err
+		}
+		history = append(history, c)
+	}
+	return history, rows.Err()
+}
+
+// CloseAccount закрывает аккаунт: снимает холды, распоряжается остатком баланса,
+// обезличивает имя пользователя и делает вход невозможным. Строка пользователя сохраняется,
+// чтобы история переводов контрагентов осталась целой.
+func (r *PostgresAccountRepository) CloseAccount(ctx context.Context, closure *models.AccountClosure) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	if err := closeInTx(ctx, tx, closure); err != nil {
+		rollback(ctx, tx)
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// GetPurchases возвращает все покупки пользователя
+func (r *PostgresAccountRepository) GetPurchases(ctx context.Context, userID int) ([]models.Purchase, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT id, item, price, quantity, created_at
+		FROM purchases
+		WHERE user_id = $1
+		ORDER BY created_at DESC
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var purchases []models.Purchase
+	for rows.Next() {
+		var p models.Purchase
+		if err := rows.Scan(&p.ID, &p.Item, &p.Price, &p.Quantity, &p.CreatedAt); err != nil {
+			return nil, err
+		}
+		purchases = append(purchases, p)
+	}
+	return purchases, rows.Err()
+}
+
+// GetIdentities возвращает внешние учетные записи пользователя (OIDC)
+func (r *PostgresAccountRepository) GetIdentities(ctx context.Context, userID int) ([]models.Identity, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT id, user_id, issuer, subject, COALESCE(email, ''), created_at
+		FROM user_identities
+		WHERE user_id = $1
+		ORDER BY created_at
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var identities []models.Identity
+	for rows.Next() {
+		var i models.Identity
+		if err := rows.Scan(&i.ID, &i.UserID, &i.Issuer, &i.Subject, &i.Email, &i.CreatedAt); err != nil {
+			return nil, err
+		}
+		identities = append(identities, i)
+	}
+	return identities, rows.Err()
+}
+
+// GetSessions возвращает все сессии пользователя, в том числе отозванные и истекшие
+func (r *PostgresAccountRepository) GetSessions(ctx context.Context, userID int) ([]models.Session, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT id, user_id, user_agent, ip, created_at, last_seen_at, expires_at
+		FROM sessions
+		WHERE user_id = $1
+		ORDER BY created_at DESC
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var sessions []models.Session
+	for rows.Next() {
+		var s models.Session
+		if err := rows.Scan(&s.ID, &s.UserID, &s.UserAgent, &s.IP, &s.CreatedAt, &s.LastSeenAt, &s.ExpiresAt); err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, s)
+	}
+	return sessions, rows.Err()
+}
+
+// GetAPITokens возвращает персональные токены пользователя, в том числе отозванные
+func (r *PostgresAccountRepository) GetAPITokens(ctx context.Context, userID int) ([]models.APIToken, error) {
+	rows, err := r.db.QueryContext(ctx, "SELECT "+apiTokenColumns+" FROM api_tokens WHERE user_id = $1 ORDER BY created_at DESC", userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tokens []models.APIToken
+	for rows.Next() {
+		token, err := scanAPIToken(rows)
+		if err != nil {
+			return nil, err
+		}
+		tokens = append(tokens, *token)
+	}
+	return tokens, rows.Err()
+}
+
+// GetFraudFlags возвращает отметки о подозрительной активности пользователя
+func (r *PostgresAccountRepository) GetFraudFlags(ctx context.Context, userID int) ([]models.FraudFlag, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT `+fraudFlagColumns+`
+		FROM fraud_flags
+		WHERE user_id = $1
+		ORDER BY created_at DESC
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanFraudFlags(rows)
+}
+
+func closeInTx(ctx context.Context, tx *sql.Tx, closure *models.AccountClosure) error {
+	if closure.Disposition == models.DispositionTransfer {
+		if err := lockUsers(ctx, tx, closure.UserID, closure.BeneficiaryID); err != nil {
+			return err
+		}
+		if err := checkAccountActive(ctx, tx, closure.BeneficiaryID); err != nil {
+			return err
+		}
+		// Замороженный аккаунт не может вывести баланс через закрытие; строка пользователя
+		// уже заблокирована, поэтому заморозка не может произойти между проверкой и переводом
+		if !closure.Override {
+			if err := checkBalanceTransferAllowed(ctx, tx, closure.UserID); err != nil {
+				return err
+			}
+		}
+	}
+
+	// Смена состояния блокирует строку пользователя и отклоняет повторное закрытие
+	if _, err := setStatusInTx(ctx, tx, closure.UserID, closure.ClosedBy, models.StatusClosed, closure.Reason); err != nil {
+		return err
+	}
+
+	// Активные холды больше не нужны: весь баланс распределяется ниже
+	_, err := tx.ExecContext(ctx,
+		"UPDATE holds SET status = 'released', settled_at = NOW() WHERE user_id = $1 AND status = 'active'",
+		closure.UserID,
+	)
+	if err != nil {
+		return err
+	}
+
+	var coins int
+	if err := tx.QueryRowContext(ctx, "SELECT coins FROM users WHERE id = $1", closure.UserID).Scan(&coins); err != nil {
+		return err
+	}
+	closure.AmountSettled = coins
+
+	if coins > 0 && closure.Disposition == models.DispositionTransfer {
+		if _, err := tx.ExecContext(ctx, "UPDATE users SET coins = coins + $1 WHERE id = $2", coins, closure.BeneficiaryID); err != nil {
+			return err
+		}
+		_, err := tx.ExecContext(ctx,
+			"INSERT INTO transactions (from_user_id, to_user_id, amount) VALUES ($1, $2, $3)",
+			closure.UserID, closure.BeneficiaryID, coins,
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Остаток (или непогашенный долг) списывается, имя заменяется обезличенным
+	_, err = tx.ExecContext(ctx, `
+		UPDATE users SET coins = 0, username = 'deleted-user-' || id, password_hash = ''
+		WHERE id = $1
+	`, closure.UserID)
+	if err != nil {
+		return err
+	}
+
+	// Секреты второго фактора закрытого аккаунта не нужны
+	if _, err := tx.ExecContext(ctx, "DELETE FROM user_totp WHERE user_id = $1", closure.UserID); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, "DELETE FROM totp_recovery_codes WHERE user_id = $1", closure.UserID); err != nil {
+		return err
+	}
+
+	// Вход через внешних провайдеров и персональные токены больше невозможен; email
+	// провайдера, IP-адреса и User-Agent сессий удаляются. Отметки о мошенничестве
+	// сохраняются как история проверок
+	if _, err := tx.ExecContext(ctx, "DELETE FROM user_identities WHERE user_id = $1", closure.UserID); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, "DELETE FROM api_tokens WHERE user_id = $1", closure.UserID); err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx, `
+		UPDATE sessions SET ip = '', user_agent = '', revoked_at = COALESCE(revoked_at, NOW())
+		WHERE user_id = $1
+	`, closure.UserID)
+	if err != nil {
+		return err
+	}
+
+	var beneficiary sql.NullInt64
+	if closure.Disposition == models.DispositionTransfer {
+		beneficiary = sql.NullInt64{Int64: int64(closure.BeneficiaryID), Valid: true}
+	}
+	return tx.QueryRowContext(ctx, `
+		INSERT INTO account_closures (user_id, disposition, beneficiary_id, amount_settled, reason, closed_by)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING created_at
+	`, closure.UserID, closure.Disposition, beneficiary, coins, closure.Reason, closure.ClosedBy).Scan(&closure.CreatedAt)
+}
+
+// Смена состояния аккаунта внутри открытой транзакции. Закрытый аккаунт изменить нельзя.
+func setStatusInTx(ctx context.Context, tx *sql.Tx, userID, adminID int, status, reason string) (*models.StatusChange, error) {
+	change := &models.StatusChange{UserID: userID, NewStatus: status, Reason: reason, ChangedBy: adminID}
+
+	err := tx.QueryRowContext(ctx, "SELECT status FROM users WHERE id = $1 FOR UPDATE", userID).Scan(&change.OldStatus)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrUserNotFound
+		}
+		return nil, err
+	}
+	if change.OldStatus == models.StatusClosed {
+		return nil, ErrAccountClosed
+	}
+
+	if _, err := tx.ExecContext(ctx, "UPDATE users SET status = $1 WHERE id = $2", status, userID); err != nil {
+		return nil, err
+	}
+
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO account_status_log (user_id, old_status, new_status, reason, changed_by)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, created_at
+	`, userID, change.OldStatus, status, reason, adminID).Scan(&change.ID, &change.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return change, nil
+}
+
+// Проверяет, что остаток закрываемого аккаунта можно перевести другому пользователю:
+// аккаунт активен и исходящие переводы не заблокированы
+func checkBalanceTransferAllowed(ctx context.Context, tx *sql.Tx, userID int) error {
+	var status string
+	var frozen bool
+	err := tx.QueryRowContext(ctx, "SELECT status, transfers_frozen FROM users WHERE id = $1", userID).Scan(&status, &frozen)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrUserNotFound
+		}
+		return err
+	}
+	if status != models.StatusActive || frozen {
+		return ErrBalanceTransferBlocked
+	}
+	return nil
+}
+
+// Проверяет, что аккаунт не закрыт. Корректировки и отмены администратора допустимы
+// для замороженных аккаунтов, но баланс закрытого уже списан и учтен при закрытии.
+// Вызывается после блокировки строки пользователя, чтобы не разойтись с параллельным закрытием
+func checkAccountNotClosed(ctx context.Context, tx *sql.Tx, userID int) error {
+	var status string
+	err := tx.QueryRowContext(ctx, "SELECT status FROM users WHERE id = $1", userID).Scan(&status)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrUserNotFound
+		}
+		return err
+	}
+	if status == models.StatusClosed {
+		return ErrAccountClosed
+	}
+	return nil
+}
+
+// Проверяет, что аккаунт активен и может участвовать в движении монет
+func checkAccountActive(ctx context.Context, tx *sql.Tx, userID int) error {
+	var status string
+	err := tx.QueryRowContext(ctx, "SELECT status FROM users WHERE id = $1", userID).Scan(&status)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrUserNotFound
+		}
+		return err
+	}
+	if status != models.StatusActive {
+		return ErrAccountInactive
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+package repository
+
+import (
+	"avito-shop-service/internal/models"
+	"context"
+	"database/sql"
+	"sort"
+)
+
+type AdjustmentRepository interface {
+	CreateAdjustments(ctx context.Context, adjustments []models.Adjustment) ([]models.Adjustment, error)
+}
+
+type PostgresAdjustmentRepository struct {
+	db *sql.DB
+}
+
+func NewPostgresAdjustmentRepository(db *sql.DB) *PostgresAdjustmentRepository {
+	return &PostgresAdjustmentRepository{db: db}
+}
+
+// CreateAdjustments применяет корректировки балансов в одной транзакции:
+// либо проводятся все, либо ни одной. Корректировки возвращаются в порядке запроса
+func (r *PostgresAdjustmentRepository) CreateAdjustments(ctx context.Context, adjustments []models.Adjustment) ([]models.Adjustment, error) {
+	// Пользователи блокируются по возрастанию id, как в lockUsers: параллельные
+	// пакеты с общими получателями ждут друг друга, а не взаимоблокируются
+	created := make([]models.Adjustment, len(adjustments))
+	copy(created, adjustments)
+	order := make([]int, len(created))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		return created[order[i]].UserID < created[order[j]].UserID
+	})
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, i := range order {
+		if err := adjustInTx(ctx, tx, &created[i]); err != nil {
+			rollback(ctx, tx)
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return created, nil
+}
+
+// Корректировка баланса одного пользователя внутри открытой транзакции
+func adjustInTx(ctx context.Context, tx *sql.Tx, a *models.Adjustment) error {
+	available, err := lockAvailableBalance(ctx, tx, a.UserID, 0)
+	if err != nil {
+		return err
+	}
+	if err := checkAccountNotClosed(ctx, tx, a.UserID); err != nil {
+		return err
+	}
+
+	// Списание не может затронуть зарезервированные монеты и увести баланс в минус
+	if available+a.Amount < 0 {
+		return ErrInsufficientFunds
+	}
+
+	if _, err := tx.ExecContext(ctx, "UPDATE users SET coins = coins + $1 WHERE id = $2", a.Amount, a.UserID); err != nil {
+		return err
+	}
+
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO coin_adjustments (user_id, admin_id, amount, reason, reference)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, created_at
+	`, a.UserID, a.AdminID, a.Amount, a.Reason, a.Reference).Scan(&a.ID, &a.CreatedAt)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return ErrDuplicateAdjustment
+		}
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,84 @@
+package repository
+
+import (
+	"avito-shop-service/internal/models"
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Начисление и отмена не затрагивают закрытый аккаунт
+func TestClosedAccountNotCredited(t *testing.T) {
+	db := connectTestDB(t)
+	ctx := context.Background()
+
+	admin, sender, closed := createTestUser(t, db), createTestUser(t, db), createTestUser(t, db)
+
+	var transactionID int
+	err := db.QueryRow("INSERT INTO transactions (from_user_id, to_user_id, amount) VALUES ($1, $2, 100) RETURNING id",
+		closed, sender).Scan(&transactionID)
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Exec("DELETE FROM transactions WHERE id = $1", transactionID) })
+	_, err = db.Exec("UPDATE users SET status = 'closed', coins = 0 WHERE id = $1", closed)
+	require.NoError(t, err)
+
+	_, err = NewPostgresAdjustmentRepository(db).CreateAdjustments(ctx, []models.Adjustment{{
+		UserID: closed, AdminID: admin, Amount: 500, Reason: "bonus",
+		Reference: fmt.Sprintf("test-%d", time.Now().UnixNano()),
+	}})
+	assert.ErrorIs(t, err, ErrAccountClosed)
+
+	_, err = NewPostgresReversalRepository(db).ReverseTransfer(ctx, transactionID, admin, models.ReversalPolicyAllowNegative, "wrong recipient")
+	assert.ErrorIs(t, err, ErrAccountClosed)
+
+	var coins int
+	require.NoError(t, db.QueryRow("SELECT coins FROM users WHERE id = $1", closed).Scan(&coins))
+	assert.Equal(t, 0, coins)
+}
+
+// Пакеты с общими получателями в разном порядке проводятся без взаимной блокировки,
+// корректировки возвращаются в порядке запроса
+func TestConcurrentAdjustmentsOppositeOrder(t *testing.T) {
+	db := connectTestDB(t)
+	repo := NewPostgresAdjustmentRepository(db)
+
+	admin, first, second := createTestUser(t, db), createTestUser(t, db), createTestUser(t, db)
+	batch := func(userIDs ...int) []models.Adjustment {
+		adjustments := make([]models.Adjustment, 0, len(userIDs))
+		for _, userID := range userIDs {
+			adjustments = append(adjustments, models.Adjustment{
+				UserID: userID, AdminID: admin, Amount: 10, Reason: "bonus",
+				Reference: fmt.Sprintf("test-%d-%d", userID, time.Now().UnixNano()),
+			})
+		}
+		return adjustments
+	}
+
+	const rounds = 20
+	var wg sync.WaitGroup
+	errs := make(chan error, 2*rounds)
+	for i := 0; i < rounds; i++ {
+		for _, userIDs := range [][]int{{first, second}, {second, first}} {
+			wg.Add(1)
+			go func(userIDs []int) {
+				defer wg.Done()
+				created, err := repo.CreateAdjustments(context.Background(), batch(userIDs...))
+				if err == nil && (created[0].UserID != userIDs[0] || created[1].UserID != userIDs[1]) {
+					err = fmt.Errorf("adjustments returned out of order: %+v", created)
+				}
+				errs <- err
+			}(userIDs)
+		}
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		assert.NoError(t, err)
+	}
+}
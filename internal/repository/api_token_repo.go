@@ -0,0 +1,123 @@
+package repository
+
+import (
+	"avito-shop-service/internal/models"
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+type APITokenRepository interface {
+	CreateToken(ctx context.Context, token *models.APIToken, tokenHash string) error
+	GetTokens(ctx context.Context, userID int) ([]models.APIToken, error)
+	GetTokenByHash(ctx context.Context, tokenHash string) (*models.APIToken, error)
+	RevokeToken(ctx context.Context, tokenID, userID int) error
+	TouchToken(ctx context.Context, tokenID int) error
+}
+
+type PostgresAPITokenRepository struct {
+	db *sql.DB
+}
+
+func NewPostgresAPITokenRepository(db *sql.DB) *PostgresAPITokenRepository {
+	return &PostgresAPITokenRepository{db: db}
+}
+
+const apiTokenColumns = "id, user_id, name, prefix, scopes, expires_at, last_used_at, revoked_at, created_at"
+
+// CreateToken сохраняет токен; в базу попадает только хеш
+func (r *PostgresAPITokenRepository) CreateToken(ctx context.Context, token *models.APIToken, tokenHash string) error {
+	err := r.db.QueryRowContext(ctx, `
+		INSERT INTO api_tokens (user_id, name, token_hash, prefix, scopes, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, created_at
+	`, token.UserID, token.Name, tokenHash, token.Prefix, pq.Array(token.Scopes), token.ExpiresAt).Scan(&token.ID, &token.CreatedAt)
+	if isForeignKeyViolation(err) {
+		return ErrUserNotFound
+	}
+	return err
+}
+
+// GetTokens возвращает все токены пользователя, включая отозванные
+func (r *PostgresAPITokenRepository) GetTokens(ctx context.Context, userID int) ([]models.APIToken, error) {
+	rows, err := r.db.QueryContext(ctx, "SELECT "+apiTokenColumns+" FROM api_tokens WHERE user_id = $1 ORDER BY created_at DESC", userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tokens []models.APIToken
+	for rows.Next() {
+		token, err := scanAPIToken(rows)
+		if err != nil {
+			return nil, err
+		}
+		tokens = append(tokens, *token)
+	}
+	return tokens, rows.Err()
+}
+
+// GetTokenByHash возвращает токен по хешу или nil
+func (r *PostgresAPITokenRepository) GetTokenByHash(ctx context.Context, tokenHash string) (*models.APIToken, error) {
+	token, err := scanAPIToken(r.db.QueryRowContext(ctx, "SELECT "+apiTokenColumns+" FROM api_tokens WHERE token_hash = $1", tokenHash))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	return token, err
+}
+
+// RevokeToken отзывает токен пользователя
+func (r *PostgresAPITokenRepository) RevokeToken(ctx context.Context, tokenID, userID int) error {
+	res, err := r.db.ExecContext(ctx,
+		"UPDATE api_tokens SET revoked_at = NOW() WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL",
+		tokenID, userID,
+	)
+	if err != nil {
+		return err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrAPITokenNotFound
+	}
+	return nil
+}
+
+// TouchToken обновляет время последнего использования не чаще раза в минуту
+func (r *PostgresAPITokenRepository) TouchToken(ctx context.Context, tokenID int) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE api_tokens SET last_used_at = NOW()
+		WHERE id = $1 AND (last_used_at IS NULL OR last_used_at < NOW() - INTERVAL '1 minute')
+	`, tokenID)
+	return err
+}
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanAPIToken(row rowScanner) (*models.APIToken, error) {
+	var token models.APIToken
+	var expiresAt, lastUsedAt, revokedAt sql.NullTime
+	err := row.Scan(&token.ID, &token.UserID, &token.Name, &token.Prefix, pq.Array(&token.Scopes),
+		&expiresAt, &lastUsedAt, &revokedAt, &token.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	token.ExpiresAt = nullTimePtr(expiresAt)
+	token.LastUsedAt = nullTimePtr(lastUsedAt)
+	token.RevokedAt = nullTimePtr(revokedAt)
+	return &token, nil
+}
+
+func nullTimePtr(t sql.NullTime) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+	return &t.Time
+}
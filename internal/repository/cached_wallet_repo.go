@@ -0,0 +1,78 @@
+package repository
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// CachingWalletRepository кеширует цены магазина поверх другого WalletRepository.
+// Остальные методы, в том числе чтение балансов, передаются без кеширования: балансы
+// меняются и в других репозиториях (холды, корректировки, отмены), а устаревшее значение
+// приводило бы к ошибочным отказам.
+// Изменение цены через этот репозиторий сразу сбрасывает запись; изменения, сделанные
+// на других экземплярах сервиса, становятся видны не позже чем через ttl.
+// Кешированная цена служит для показа и ранней проверки товара: покупка списывает цену,
+// прочитанную в своей транзакции
+
+type CachingWalletRepository struct {
+	WalletRepository
+
+	ttl time.Duration
+	now func() time.Time
+
+	mu     sync.RWMutex
+	prices map[string]cachedPrice
+	// Увеличивается при каждом изменении цены; чтение, начатое до изменения, не попадает в кеш
+	generation uint64
+}
+
+type cachedPrice struct {
+	price   int
+	expires time.Time
+}
+
+func NewCachingWalletRepository(next WalletRepository, ttl time.Duration) *CachingWalletRepository {
+	return &CachingWalletRepository{
+		WalletRepository: next,
+		ttl:              ttl,
+		now:              time.Now,
+		prices:           make(map[string]cachedPrice),
+	}
+}
+
+// GetItemPrice возвращает цену из кеша или читает ее из базы. Отсутствующие товары
+// не кешируются, чтобы запросы произвольных названий не заполняли память
+func (r *CachingWalletRepository) GetItemPrice(ctx context.Context, itemName string) (int, error) {
+	r.mu.RLock()
+	cached, ok := r.prices[itemName]
+	generation := r.generation
+	r.mu.RUnlock()
+	if ok && r.now().Before(cached.expires) {
+		return cached.price, nil
+	}
+
+	price, err := r.WalletRepository.GetItemPrice(ctx, itemName)
+	if err != nil {
+		return 0, err
+	}
+
+	r.mu.Lock()
+	if r.generation == generation {
+		r.prices[itemName] = cachedPrice{price: price, expires: r.now().Add(r.ttl)}
+	}
+	r.mu.Unlock()
+	return price, nil
+}
+
+// SetItemPrice сохраняет цену и сбрасывает ее в кеше
+func (r *CachingWalletRepository) SetItemPrice(ctx context.Context, itemName string, price int) error {
+	err := r.WalletRepository.SetItemPrice(ctx, itemName, price)
+
+	// Запись сбрасывается и при ошибке: изменение могло примениться
+	r.mu.Lock()
+	delete(r.prices, itemName)
+	r.generation++
+	r.mu.Unlock()
+	return err
+}
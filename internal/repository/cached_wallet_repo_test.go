@@ -0,0 +1,166 @@
+package repository
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Кошелек, считающий обращения к базе данных
+type countingWalletRepository struct {
+	WalletRepository
+
+	queries atomic.Int64
+	price   atomic.Int64
+	balance atomic.Int64
+}
+
+func newCountingWalletRepository(price int) *countingWalletRepository {
+	repo := &countingWalletRepository{}
+	repo.price.Store(int64(price))
+	return repo
+}
+
+func (r *countingWalletRepository) GetItemPrice(_ context.Context, itemName string) (int, error) {
+	r.queries.Add(1)
+	if itemName == "unknown" {
+		return 0, ErrItemNotFound
+	}
+	return int(r.price.Load()), nil
+}
+
+func (r *countingWalletRepository) SetItemPrice(_ context.Context, _ string, price int) error {
+	r.queries.Add(1)
+	r.price.Store(int64(price))
+	return nil
+}
+
+func (r *countingWalletRepository) GetBalance(_ context.Context, _ int) (int, error) {
+	r.queries.Add(1)
+	return int(r.balance.Load()), nil
+}
+
+// Транзакция покупки считается одним обращением
+func (r *countingWalletRepository) PurchaseItem(_ context.Context, _ int, _ string, _ int) error {
+	r.queries.Add(1)
+	return nil
+}
+
+// Кеш с управляемыми часами
+func newTestCachingWalletRepository(next WalletRepository, ttl time.Duration) (*CachingWalletRepository, *time.Time) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	repo := NewCachingWalletRepository(next, ttl)
+	repo.now = func() time.Time { return now }
+	return repo, &now
+}
+
+func TestCachedPriceSkipsDatabase(t *testing.T) {
+	db := newCountingWalletRepository(80)
+	repo, _ := newTestCachingWalletRepository(db, time.Minute)
+
+	for i := 0; i < 3; i++ {
+		price, err := repo.GetItemPrice(context.Background(), "cup")
+		assert.NoError(t, err)
+		assert.Equal(t, 80, price)
+	}
+	assert.Equal(t, int64(1), db.queries.Load())
+}
+
+// Изменение цены администратором сразу видно покупателям
+func TestSetItemPriceInvalidatesCache(t *testing.T) {
+	db := newCountingWalletRepository(80)
+	repo, _ := newTestCachingWalletRepository(db, time.Minute)
+
+	_, err := repo.GetItemPrice(context.Background(), "cup")
+	assert.NoError(t, err)
+
+	assert.NoError(t, repo.SetItemPrice(context.Background(), "cup", 120))
+
+	price, err := repo.GetItemPrice(context.Background(), "cup")
+	assert.NoError(t, err)
+	assert.Equal(t, 120, price)
+}
+
+func TestUnknownItemNotCached(t *testing.T) {
+	db := newCountingWalletRepository(80)
+	repo, _ := newTestCachingWalletRepository(db, time.Minute)
+
+	for i := 0; i < 2; i++ {
+		_, err := repo.GetItemPrice(context.Background(), "unknown")
+		assert.ErrorIs(t, err, ErrItemNotFound)
+	}
+	assert.Equal(t, int64(2), db.queries.Load())
+}
+
+func TestCachedPriceExpires(t *testing.T) {
+	db := newCountingWalletRepository(80)
+	repo, now := newTestCachingWalletRepository(db, time.Minute)
+
+	_, err := repo.GetItemPrice(context.Background(), "cup")
+	assert.NoError(t, err)
+
+	*now = now.Add(59 * time.Second)
+	_, err = repo.GetItemPrice(context.Background(), "cup")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), db.queries.Load())
+
+	*now = now.Add(time.Second)
+	_, err = repo.GetItemPrice(context.Background(), "cup")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), db.queries.Load())
+}
+
+// Балансы не кешируются: их меняют и другие репозитории (холды, корректировки, отмены)
+func TestBalanceNotCached(t *testing.T) {
+	db := newCountingWalletRepository(80)
+	repo, _ := newTestCachingWalletRepository(db, time.Minute)
+
+	db.balance.Store(1000)
+	balance, err := repo.GetBalance(context.Background(), 1)
+	assert.NoError(t, err)
+	assert.Equal(t, 1000, balance)
+
+	// Баланс изменен в обход кеширующего репозитория
+	db.balance.Store(400)
+	balance, err = repo.GetBalance(context.Background(), 1)
+	assert.NoError(t, err)
+	assert.Equal(t, 400, balance)
+	assert.Equal(t, int64(2), db.queries.Load())
+}
+
+// Число обращений к базе данных на одну покупку без кеша цен и с ним.
+// Покупка так же, как в WalletService.PurchaseItem: проверка товара, затем транзакция
+func BenchmarkPurchaseQueries(b *testing.B) {
+	benchmarks := []struct {
+		name   string
+		cached bool
+	}{
+		{name: "uncached"},
+		{name: "cached", cached: true},
+	}
+
+	for _, bm := range benchmarks {
+		b.Run(bm.name, func(b *testing.B) {
+			db := newCountingWalletRepository(80)
+			var repo WalletRepository = db
+			if bm.cached {
+				repo = NewCachingWalletRepository(db, time.Minute)
+			}
+			ctx := context.Background()
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if _, err := repo.GetItemPrice(ctx, "cup"); err != nil {
+					b.Fatal(err)
+				}
+				if err := repo.PurchaseItem(ctx, 1, "cup", 1); err != nil {
+					b.Fatal(err)
+				}
+			}
+			b.ReportMetric(float64(db.queries.Load())/float64(b.N), "queries/op")
+		})
+	}
+}
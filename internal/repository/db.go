@@ -2,31 +2,121 @@ package repository
 
 import (
 	"avito-shop-service/config"
+	"context"
 	"database/sql"
 	"fmt"
-	"log"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/XSAM/otelsql"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
 
 	// Импортируем PostgreSQL
 	_ "github.com/lib/pq"
 )
 
-// Подключение к БД
-func ConnectDB(cfg *config.Config) *sql.DB {
-	dsn := fmt.Sprintf(
-		"host=%s port=%s user=%s password=%s dbname=%s sslmode=disable",
-		cfg.DBHost, cfg.DBPort, cfg.DBUser, cfg.DBPassword, cfg.DBName,
-	)
+// Пауза между попытками подключения при старте: растет вдвое до maxConnectBackoff
+const (
+	initialConnectBackoff = 500 * time.Millisecond
+	maxConnectBackoff     = 5 * time.Second
+)
 
-	db, err := sql.Open("postgres", dsn)
+// Подключение к БД. Пока база недоступна, подключение повторяется с растущей паузой
+// в течение cfg.DBConnectRetryTimeout (0 - одна попытка); отмена ctx прерывает ожидание
+func ConnectDB(ctx context.Context, cfg *config.Config) (*sql.DB, error) {
+	// Каждый SQL-запрос получает спан в трассе запроса; параметры запросов в спаны не попадают
+	db, err := otelsql.Open("postgres", buildDSN(cfg),
+		otelsql.WithAttributes(semconv.DBSystemPostgreSQL),
+		otelsql.WithSpanOptions(otelsql.SpanOptions{
+			OmitConnResetSession: true,
+			OmitConnectorConnect: true,
+			OmitRows:             true,
+		}),
+	)
 	if err != nil {
-		log.Fatal("Error connecting to DB:", err)
+		return nil, fmt.Errorf("open DB: %w", err)
 	}
 
-	err = db.Ping()
-	if err != nil {
-		log.Fatal("DB is not reachable:", err)
+	db.SetMaxOpenConns(cfg.DBMaxOpenConns)
+	db.SetMaxIdleConns(cfg.DBMaxIdleConns)
+	db.SetConnMaxLifetime(cfg.DBConnMaxLifetime)
+	db.SetConnMaxIdleTime(cfg.DBConnMaxIdleTime)
+
+	if err := pingWithRetry(ctx, db, cfg.DBConnectRetryTimeout); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("DB is not reachable: %w", err)
 	}
 
-	log.Println("Connected to the database")
-	return db
+	slog.Info("connected to the database")
+	return db, nil
+}
+
+func pingWithRetry(ctx context.Context, db *sql.DB, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	backoff := initialConnectBackoff
+
+	for attempt := 1; ; attempt++ {
+		err := db.PingContext(ctx)
+		if err == nil {
+			return nil
+		}
+		remaining := time.Until(deadline)
+		if ctx.Err() != nil || remaining <= 0 {
+			return err
+		}
+
+		// Последняя попытка делается в момент истечения срока
+		wait := min(backoff, remaining)
+		slog.Warn("DB is not reachable, retrying",
+			slog.Int("attempt", attempt),
+			slog.Duration("backoff", wait),
+			slog.Any("error", err),
+		)
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(wait):
+		}
+		backoff = min(backoff*2, maxConnectBackoff)
+	}
+}
+
+// Строка подключения в формате key=value. Значения экранируются,
+// поэтому пароль может содержать пробелы и кавычки; DBParams добавляются в конец как есть
+func buildDSN(cfg *config.Config) string {
+	params := []struct{ key, value string }{
+		{"host", cfg.DBHost},
+		{"port", cfg.DBPort},
+		{"user", cfg.DBUser},
+		{"password", cfg.DBPassword},
+		{"dbname", cfg.DBName},
+		{"sslmode", cfg.DBSSLMode},
+		{"sslrootcert", cfg.DBSSLRootCert},
+		{"application_name", cfg.DBApplicationName},
+	}
+	if cfg.DBConnectTimeout > 0 {
+		// libpq принимает таймаут в целых секундах
+		seconds := max(int(cfg.DBConnectTimeout/time.Second), 1)
+		params = append(params, struct{ key, value string }{"connect_timeout", strconv.Itoa(seconds)})
+	}
+
+	parts := make([]string, 0, len(params)+1)
+	for _, p := range params {
+		if p.value == "" {
+			continue
+		}
+		parts = append(parts, p.key+"="+quoteDSNValue(p.value))
+	}
+	if cfg.DBParams != "" {
+		parts = append(parts, cfg.DBParams)
+	}
+	return strings.Join(parts, " ")
+}
+
+func quoteDSNValue(value string) string {
+	replacer := strings.NewReplacer(`\`, `\\`, `'`, `\'`)
+	return "'" + replacer.Replace(value) + "'"
 }
@@ -0,0 +1,64 @@
+package repository
+
+import (
+	"errors"
+
+	"github.com/lib/pq"
+)
+
+var (
+	ErrUserNotFound      = errors.New("user not found")
+	ErrItemNotFound      = errors.New("item not found")
+	ErrInsufficientFunds = errors.New("insufficient funds")
+
+	ErrHoldNotFound       = errors.New("hold not found")
+	ErrHoldNotActive      = errors.New("hold is not active")
+	ErrHoldExpired        = errors.New("hold expired")
+	ErrHoldAmountExceeded = errors.New("amount exceeds held funds")
+
+	ErrDuplicateAdjustment = errors.New("adjustment with this reference already exists")
+
+	ErrTransactionNotFound   = errors.New("transaction not found")
+	ErrAlreadyReversed       = errors.New("transaction already reversed")
+	ErrCannotReverseReversal = errors.New("reversal transaction cannot be reversed")
+	ErrInvalidReversalPolicy = errors.New("invalid reversal policy")
+
+	ErrFlagNotFound    = errors.New("fraud flag not found")
+	ErrTransfersFrozen = errors.New("outgoing transfers are frozen pending review")
+
+	ErrAccountInactive = errors.New("account is not active")
+	ErrAccountClosed   = errors.New("account is closed")
+
+	ErrBalanceTransferBlocked = errors.New("balance of a frozen account cannot be transferred")
+
+	ErrInvalidResetToken = errors.New("reset token is invalid or expired")
+
+	ErrTOTPAlreadyEnabled = errors.New("two-factor authentication is already enabled")
+	ErrTOTPNotEnrolled    = errors.New("two-factor authentication is not enrolled")
+
+	ErrInvalidLoginState = errors.New("login state is invalid or expired")
+	ErrUsernameTaken     = errors.New("username is already taken")
+
+	ErrAPITokenNotFound = errors.New("api token not found")
+
+	ErrSessionNotFound = errors.New("session not found")
+)
+
+// Коды ошибок PostgreSQL
+const (
+	pqUniqueViolation     = "23505"
+	pqForeignKeyViolation = "23503"
+)
+
+func isPQError(err error, code pq.ErrorCode) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == code
+}
+
+func isUniqueViolation(err error) bool {
+	return isPQError(err, pqUniqueViolation)
+}
+
+func isForeignKeyViolation(err error) bool {
+	return isPQError(err, pqForeignKeyViolation)
+}
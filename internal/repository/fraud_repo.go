@@ -0,0 +1,258 @@
+package repository
+
+import (
+	"avito-shop-service/internal/models"
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+type FraudRepository interface {
+	FindDrainedNewAccounts(ctx context.Context, params models.FraudScanParams) ([]models.DrainedAccount, error)
+	FindFunnelRecipients(ctx context.Context, params models.FraudScanParams) ([]models.FunnelRecipient, error)
+	FindCircularFlows(ctx context.Context, params models.FraudScanParams) ([][]int, error)
+	CreateFlag(ctx context.Context, flag *models.FraudFlag, dedupWindow time.Duration) (bool, error)
+	GetFlags(ctx context.Context, status string) ([]models.FraudFlag, error)
+	ReviewFlag(ctx context.Context, flagID, adminID int, status string) (*models.FraudFlag, error)
+	CountBlockingFlags(ctx context.Context, userID int) (int, error)
+	SetTransfersFrozen(ctx context.Context, userID int, frozen bool) error
+	FreezeForFraud(ctx context.Context, userID int) error
+	LiftFraudFreeze(ctx context.Context, userID int) error
+}
+
+type PostgresFraudRepository struct {
+	db *sql.DB
+}
+
+func NewPostgresFraudRepository(db *sql.DB) *PostgresFraudRepository {
+	return &PostgresFraudRepository{db: db}
+}
+
+// FindDrainedNewAccounts ищет аккаунты, которые вскоре после регистрации перевели одному получателю
+// не меньше DrainMinAmount монет
+func (r *PostgresFraudRepository) FindDrainedNewAccounts(ctx context.Context, params models.FraudScanParams) ([]models.DrainedAccount, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT t.from_user_id, t.to_user_id, SUM(t.amount)
+		FROM transactions t
+		JOIN users u ON u.id = t.from_user_id
+		WHERE t.created_at > NOW() - $1 * INTERVAL '1 second'
+			AND t.created_at <= u.created_at + $2 * INTERVAL '1 second'
+			AND t.reversal_of IS NULL
+		GROUP BY t.from_user_id, t.to_user_id
+		HAVING SUM(t.amount) >= $3
+	`, seconds(params.Lookback), seconds(params.NewAccountAge), params.DrainMinAmount)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var accounts []models.DrainedAccount
+	for rows.Next() {
+		var a models.DrainedAccount
+		if err := rows.Scan(&a.UserID, &a.RecipientID, &a.Amount); err != nil {
+			return nil, err
+		}
+		accounts = append(accounts, a)
+	}
+	return accounts, rows.Err()
+}
+
+// FindFunnelRecipients ищет пользователей, получивших переводы от FunnelMinSenders и более новых аккаунтов
+func (r *PostgresFraudRepository) FindFunnelRecipients(ctx context.Context, params models.FraudScanParams) ([]models.FunnelRecipient, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT t.to_user_id, COUNT(DISTINCT t.from_user_id), SUM(t.amount)
+		FROM transactions t
+		JOIN users u ON u.id = t.from_user_id
+		WHERE t.created_at > NOW() - $1 * INTERVAL '1 second'
+			AND t.created_at <= u.created_at + $2 * INTERVAL '1 second'
+			AND t.reversal_of IS NULL
+		GROUP BY t.to_user_id
+		HAVING COUNT(DISTINCT t.from_user_id) >= $3
+	`, seconds(params.Lookback), seconds(params.NewAccountAge), params.FunnelMinSenders)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var recipients []models.FunnelRecipient
+	for rows.Next() {
+		var f models.FunnelRecipient
+		if err := rows.Scan(&f.UserID, &f.Senders, &f.Amount); err != nil {
+			return nil, err
+		}
+		recipients = append(recipients, f)
+	}
+	return recipients, rows.Err()
+}
+
+// FindCircularFlows ищет циклы из двух и трех переводов (A→B→A, A→B→C→A), идущих друг за другом.
+// Каждый цикл возвращается один раз, начиная с участника с наименьшим id.
+func (r *PostgresFraudRepository) FindCircularFlows(ctx context.Context, params models.FraudScanParams) ([][]int, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		WITH recent AS (
+			SELECT from_user_id, to_user_id, created_at
+			FROM transactions
+			WHERE created_at > NOW() - $1 * INTERVAL '1 second'
+				AND reversal_of IS NULL
+				AND amount >= $2
+		)
+		SELECT DISTINCT t1.from_user_id, t1.to_user_id, 0
+		FROM recent t1
+		JOIN recent t2 ON t2.from_user_id = t1.to_user_id AND t2.to_user_id = t1.from_user_id
+			AND t2.created_at >= t1.created_at
+		WHERE t1.from_user_id < t1.to_user_id
+		UNION
+		SELECT DISTINCT t1.from_user_id, t1.to_user_id, t2.to_user_id
+		FROM recent t1
+		JOIN recent t2 ON t2.from_user_id = t1.to_user_id AND t2.created_at >= t1.created_at
+		JOIN recent t3 ON t3.from_user_id = t2.to_user_id AND t3.to_user_id = t1.from_user_id
+			AND t3.created_at >= t2.created_at
+		WHERE t2.to_user_id <> t1.from_user_id
+			AND t1.from_user_id < t1.to_user_id AND t1.from_user_id < t2.to_user_id
+	`, seconds(params.Lookback), params.CycleMinAmount)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var cycles [][]int
+	for rows.Next() {
+		var a, b, c int
+		if err := rows.Scan(&a, &b, &c); err != nil {
+			return nil, err
+		}
+		cycle := []int{a, b}
+		if c != 0 {
+			cycle = append(cycle, c)
+		}
+		cycles = append(cycles, cycle)
+	}
+	return cycles, rows.Err()
+}
+
+// CreateFlag сохраняет отметку, если у пользователя нет открытой отметки с тем же шаблоном
+// и никакой отметки с этим шаблоном за dedupWindow. Возвращает true, если отметка создана.
+func (r *PostgresFraudRepository) CreateFlag(ctx context.Context, flag *models.FraudFlag, dedupWindow time.Duration) (bool, error) {
+	err := r.db.QueryRowContext(ctx, `
+		INSERT INTO fraud_flags (user_id, pattern, details)
+		SELECT $1, $2, $3
+		WHERE NOT EXISTS (
+			SELECT 1 FROM fraud_flags
+			WHERE user_id = $1 AND pattern = $2
+				AND (status = 'open' OR created_at > NOW() - $4 * INTERVAL '1 second')
+		)
+		RETURNING id, status, created_at
+	`, flag.UserID, flag.Pattern, flag.Details, seconds(dedupWindow)).Scan(&flag.ID, &flag.Status, &flag.CreatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// GetFlags возвращает отметки с указанным статусом (все, если статус пустой)
+func (r *PostgresFraudRepository) GetFlags(ctx context.Context, status string) ([]models.FraudFlag, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT `+fraudFlagColumns+`
+		FROM fraud_flags
+		WHERE $1 = '' OR status = $1
+		ORDER BY created_at DESC
+	`, status)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanFraudFlags(rows)
+}
+
+const fraudFlagColumns = "id, user_id, pattern, details, status, created_at, reviewed_by, reviewed_at"
+
+func scanFraudFlags(rows *sql.Rows) ([]models.FraudFlag, error) {
+	var flags []models.FraudFlag
+	for rows.Next() {
+		var f models.FraudFlag
+		var reviewedBy sql.NullInt64
+		var reviewedAt sql.NullTime
+		if err := rows.Scan(&f.ID, &f.UserID, &f.Pattern, &f.Details, &f.Status, &f.CreatedAt, &reviewedBy, &reviewedAt); err != nil {
+			return nil, err
+		}
+		f.ReviewedBy = nullIntPtr(reviewedBy)
+		if reviewedAt.Valid {
+			f.ReviewedAt = &reviewedAt.Time
+		}
+		flags = append(flags, f)
+	}
+	return flags, rows.Err()
+}
+
+// ReviewFlag закрывает открытую отметку решением администратора
+func (r *PostgresFraudRepository) ReviewFlag(ctx context.Context, flagID, adminID int, status string) (*models.FraudFlag, error) {
+	flag := &models.FraudFlag{ID: flagID, Status: status, ReviewedBy: &adminID}
+	var reviewedAt time.Time
+	err := r.db.QueryRowContext(ctx, `
+		UPDATE fraud_flags SET status = $1, reviewed_by = $2, reviewed_at = NOW()
+		WHERE id = $3 AND status = 'open'
+		RETURNING user_id, pattern, details, created_at, reviewed_at
+	`, status, adminID, flagID).Scan(&flag.UserID, &flag.Pattern, &flag.Details, &flag.CreatedAt, &reviewedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrFlagNotFound
+		}
+		return nil, err
+	}
+	flag.ReviewedAt = &reviewedAt
+	return flag, nil
+}
+
+// CountBlockingFlags возвращает количество непроверенных и подтвержденных отметок пользователя
+func (r *PostgresFraudRepository) CountBlockingFlags(ctx context.Context, userID int) (int, error) {
+	var count int
+	err := r.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM fraud_flags WHERE user_id = $1 AND status IN ('open', 'confirmed')", userID).Scan(&count)
+	return count, err
+}
+
+// SetTransfersFrozen блокирует или разблокирует исходящие переводы пользователя по решению
+// администратора. Ручное решение заменяет автоматическую блокировку: снять ее может только администратор
+func (r *PostgresFraudRepository) SetTransfersFrozen(ctx context.Context, userID int, frozen bool) error {
+	return r.updateFreeze(ctx, "UPDATE users SET transfers_frozen = $1, transfers_frozen_by_fraud = FALSE WHERE id = $2", frozen, userID)
+}
+
+// FreezeForFraud блокирует исходящие переводы по отметке анализа. Уже действующая
+// ручная блокировка остается ручной
+func (r *PostgresFraudRepository) FreezeForFraud(ctx context.Context, userID int) error {
+	return r.updateFreeze(ctx, `
+		UPDATE users
+		SET transfers_frozen = TRUE, transfers_frozen_by_fraud = transfers_frozen_by_fraud OR NOT transfers_frozen
+		WHERE id = $1`, userID)
+}
+
+// LiftFraudFreeze снимает блокировку, только если ее выставил анализ мошенничества
+func (r *PostgresFraudRepository) LiftFraudFreeze(ctx context.Context, userID int) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE users SET transfers_frozen = FALSE, transfers_frozen_by_fraud = FALSE
+		WHERE id = $1 AND transfers_frozen_by_fraud`, userID)
+	return err
+}
+
+func (r *PostgresFraudRepository) updateFreeze(ctx context.Context, query string, args ...interface{}) error {
+	res, err := r.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrUserNotFound
+	}
+	return nil
+}
+
+func seconds(d time.Duration) int64 {
+	return int64(d / time.Second)
+}
@@ -0,0 +1,99 @@
+package repository
+
+import (
+	"avito-shop-service/config"
+	"context"
+	"database/sql"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Подключение к тестовой базе с миграциями; без базы тест пропускается
+func connectTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+	cfg := config.LoadConfig()
+	cfg.DBConnectRetryTimeout = 0
+	db, err := ConnectDB(context.Background(), cfg)
+	if err != nil {
+		t.Skip(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func createTestUser(t *testing.T, db *sql.DB) int {
+	t.Helper()
+	var userID int
+	err := db.QueryRow("INSERT INTO users (username, password_hash, coins) VALUES ($1, '', 1000) RETURNING id",
+		fmt.Sprintf("test-%d", time.Now().UnixNano())).Scan(&userID)
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Exec("DELETE FROM users WHERE id = $1", userID) })
+	return userID
+}
+
+func transfersFrozen(t *testing.T, db *sql.DB, userID int) bool {
+	t.Helper()
+	var frozen bool
+	require.NoError(t, db.QueryRow("SELECT transfers_frozen FROM users WHERE id = $1", userID).Scan(&frozen))
+	return frozen
+}
+
+// Анализ снимает только собственную блокировку; ручная блокировка остается
+func TestFraudFreezeSource(t *testing.T) {
+	db := connectTestDB(t)
+	repo := NewPostgresFraudRepository(db)
+	ctx := context.Background()
+
+	tests := []struct {
+		name       string
+		freeze     func(userID int) error
+		wantFrozen bool
+	}{
+		{
+			name:       "fraud freeze is lifted",
+			freeze:     func(userID int) error { return repo.FreezeForFraud(ctx, userID) },
+			wantFrozen: false,
+		},
+		{
+			name:       "manual freeze stays",
+			freeze:     func(userID int) error { return repo.SetTransfersFrozen(ctx, userID, true) },
+			wantFrozen: true,
+		},
+		{
+			name: "manual freeze before fraud flag stays",
+			freeze: func(userID int) error {
+				if err := repo.SetTransfersFrozen(ctx, userID, true); err != nil {
+					return err
+				}
+				return repo.FreezeForFraud(ctx, userID)
+			},
+			wantFrozen: true,
+		},
+		{
+			name: "manual freeze after fraud flag stays",
+			freeze: func(userID int) error {
+				if err := repo.FreezeForFraud(ctx, userID); err != nil {
+					return err
+				}
+				return repo.SetTransfersFrozen(ctx, userID, true)
+			},
+			wantFrozen: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			userID := createTestUser(t, db)
+			require.NoError(t, tt.freeze(userID))
+			assert.True(t, transfersFrozen(t, db, userID))
+
+			// Последняя отметка отклонена
+			require.NoError(t, repo.LiftFraudFreeze(ctx, userID))
+			assert.Equal(t, tt.wantFrozen, transfersFrozen(t, db, userID))
+		})
+	}
+}
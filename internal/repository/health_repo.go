@@ -0,0 +1,37 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+)
+
+// ExpectedSchemaVersion - номер последней миграции, на которую рассчитан код.
+// Увеличивается вместе с добавлением миграции в internal/db/migrations
+const ExpectedSchemaVersion = 21
+
+type HealthRepository interface {
+	Ping(ctx context.Context) error
+	SchemaVersion(ctx context.Context) (int, error)
+}
+
+type PostgresHealthRepository struct {
+	db *sql.DB
+}
+
+func NewPostgresHealthRepository(db *sql.DB) *PostgresHealthRepository {
+	return &PostgresHealthRepository{db: db}
+}
+
+// Ping проверяет соединение с базой данных
+func (r *PostgresHealthRepository) Ping(ctx context.Context) error {
+	return r.db.PingContext(ctx)
+}
+
+// SchemaVersion возвращает номер последней примененной миграции
+func (r *PostgresHealthRepository) SchemaVersion(ctx context.Context) (int, error) {
+	var version sql.NullInt64
+	if err := r.db.QueryRowContext(ctx, "SELECT MAX(version) FROM schema_migrations").Scan(&version); err != nil {
+		return 0, err
+	}
+	return int(version.Int64), nil
+}
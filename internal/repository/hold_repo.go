@@ -0,0 +1,224 @@
+package repository
+
+import (
+	"avito-shop-service/internal/models"
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+type HoldRepository interface {
+	CreateHold(ctx context.Context, userID, amount int, reason string, expiresAt time.Time) (*models.Hold, error)
+	GetHolds(ctx context.Context, userID int) ([]models.Hold, error)
+	CaptureTransfer(ctx context.Context, holdID, userID, toUserID, amount int, check models.TransferCheck) error
+	CapturePurchase(ctx context.Context, holdID, userID int, itemName string, quantity int) error
+	ReleaseHold(ctx context.Context, holdID, userID int) error
+}
+
+type PostgresHoldRepository struct {
+	db *sql.DB
+}
+
+func NewPostgresHoldRepository(db *sql.DB) *PostgresHoldRepository {
+	return &PostgresHoldRepository{db: db}
+}
+
+// Статус холда с учетом истечения срока
+const holdStatusColumn = `CASE WHEN status = 'active' AND expires_at <= NOW() THEN 'expired' ELSE status END`
+
+// CreateHold резервирует часть доступного баланса пользователя
+func (r *PostgresHoldRepository) CreateHold(ctx context.Context, userID, amount int, reason string, expiresAt time.Time) (*models.Hold, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	available, err := lockAvailableBalance(ctx, tx, userID, 0)
+	if err != nil {
+		rollback(ctx, tx)
+		return nil, err
+	}
+	if err := checkAccountActive(ctx, tx, userID); err != nil {
+		rollback(ctx, tx)
+		return nil, err
+	}
+	if available < amount {
+		rollback(ctx, tx)
+		return nil, ErrInsufficientFunds
+	}
+
+	hold := &models.Hold{
+		UserID:    userID,
+		Amount:    amount,
+		Status:    models.HoldStatusActive,
+		Reason:    reason,
+		ExpiresAt: expiresAt,
+	}
+	err = tx.QueryRowContext(ctx,
+		"INSERT INTO holds (user_id, amount, reason, expires_at) VALUES ($1, $2, $3, $4) RETURNING id, created_at",
+		userID, amount, reason, expiresAt,
+	).Scan(&hold.ID, &hold.CreatedAt)
+	if err != nil {
+		rollback(ctx, tx)
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return hold, nil
+}
+
+// GetHolds возвращает все холды пользователя, начиная с последних
+func (r *PostgresHoldRepository) GetHolds(ctx context.Context, userID int) ([]models.Hold, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT id, user_id, amount, captured_amount, `+holdStatusColumn+`, reason, expires_at, created_at, settled_at
+		FROM holds
+		WHERE user_id = $1
+		ORDER BY created_at DESC
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var holds []models.Hold
+	for rows.Next() {
+		var h models.Hold
+		var captured sql.NullInt64
+		var settledAt sql.NullTime
+		if err := rows.Scan(&h.ID, &h.UserID, &h.Amount, &captured, &h.Status, &h.Reason, &h.ExpiresAt, &h.CreatedAt, &settledAt); err != nil {
+			return nil, err
+		}
+		if captured.Valid {
+			v := int(captured.Int64)
+			h.CapturedAmount = &v
+		}
+		if settledAt.Valid {
+			h.SettledAt = &settledAt.Time
+		}
+		holds = append(holds, h)
+	}
+
+	return holds, rows.Err()
+}
+
+// CaptureTransfer списывает зарезервированные монеты переводом другому пользователю
+func (r *PostgresHoldRepository) CaptureTransfer(ctx context.Context, holdID, userID, toUserID, amount int, check models.TransferCheck) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	if err := lockActiveHold(ctx, tx, holdID, userID, amount); err != nil {
+		rollback(ctx, tx)
+		return err
+	}
+
+	if err := transferInTx(ctx, tx, userID, toUserID, amount, holdID, check); err != nil {
+		rollback(ctx, tx)
+		return err
+	}
+
+	if err := settleHold(ctx, tx, holdID, models.HoldStatusCaptured, amount); err != nil {
+		rollback(ctx, tx)
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// CapturePurchase списывает зарезервированные монеты покупкой товара по текущей цене магазина
+func (r *PostgresHoldRepository) CapturePurchase(ctx context.Context, holdID, userID int, itemName string, quantity int) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	price, err := lockItemPrice(ctx, tx, itemName)
+	if err != nil {
+		rollback(ctx, tx)
+		return err
+	}
+
+	totalPrice := price * quantity
+	if err := lockActiveHold(ctx, tx, holdID, userID, totalPrice); err != nil {
+		rollback(ctx, tx)
+		return err
+	}
+
+	if err := purchaseInTx(ctx, tx, userID, itemName, price, quantity, holdID); err != nil {
+		rollback(ctx, tx)
+		return err
+	}
+
+	if err := settleHold(ctx, tx, holdID, models.HoldStatusCaptured, totalPrice); err != nil {
+		rollback(ctx, tx)
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// ReleaseHold снимает резерв, возвращая монеты в доступный баланс
+func (r *PostgresHoldRepository) ReleaseHold(ctx context.Context, holdID, userID int) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	if err := lockActiveHold(ctx, tx, holdID, userID, 0); err != nil {
+		rollback(ctx, tx)
+		return err
+	}
+
+	if err := settleHold(ctx, tx, holdID, models.HoldStatusReleased, 0); err != nil {
+		rollback(ctx, tx)
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Блокирует холд и проверяет, что он принадлежит пользователю, активен и покрывает сумму списания
+func lockActiveHold(ctx context.Context, tx *sql.Tx, holdID, userID, amount int) error {
+	var heldAmount int
+	var status string
+	var expired bool
+	err := tx.QueryRowContext(ctx,
+		"SELECT amount, status, expires_at <= NOW() FROM holds WHERE id = $1 AND user_id = $2 FOR UPDATE",
+		holdID, userID,
+	).Scan(&heldAmount, &status, &expired)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrHoldNotFound
+		}
+		return err
+	}
+
+	if status != models.HoldStatusActive {
+		return ErrHoldNotActive
+	}
+	if expired {
+		return ErrHoldExpired
+	}
+	if amount > heldAmount {
+		return ErrHoldAmountExceeded
+	}
+	return nil
+}
+
+// Закрывает холд; незадействованный остаток при захвате освобождается автоматически
+func settleHold(ctx context.Context, tx *sql.Tx, holdID int, status string, capturedAmount int) error {
+	var captured sql.NullInt64
+	if status == models.HoldStatusCaptured {
+		captured = sql.NullInt64{Int64: int64(capturedAmount), Valid: true}
+	}
+
+	_, err := tx.ExecContext(ctx,
+		"UPDATE holds SET status = $1, captured_amount = $2, settled_at = NOW() WHERE id = $3",
+		status, captured, holdID,
+	)
+	return err
+}
@@ -0,0 +1,106 @@
+package repository
+
+import (
+	"avito-shop-service/internal/models"
+	"context"
+	"database/sql"
+	"errors"
+)
+
+type IdentityRepository interface {
+	SaveLoginState(ctx context.Context, state *models.OIDCLoginState) error
+	ConsumeLoginState(ctx context.Context, state string) (*models.OIDCLoginState, error)
+	FindUserByIdentity(ctx context.Context, issuer, subject string) (*models.User, error)
+	CreateUserWithIdentity(ctx context.Context, user *models.User, identity *models.Identity) error
+}
+
+type PostgresIdentityRepository struct {
+	db *sql.DB
+}
+
+func NewPostgresIdentityRepository(db *sql.DB) *PostgresIdentityRepository {
+	return &PostgresIdentityRepository{db: db}
+}
+
+// SaveLoginState сохраняет параметры начатого входа. Просроченные записи удаляются попутно.
+func (r *PostgresIdentityRepository) SaveLoginState(ctx context.Context, state *models.OIDCLoginState) error {
+	if _, err := r.db.ExecContext(ctx, "DELETE FROM oidc_login_states WHERE expires_at <= NOW()"); err != nil {
+		return err
+	}
+
+	_, err := r.db.ExecContext(ctx,
+		"INSERT INTO oidc_login_states (state, code_verifier, nonce, expires_at) VALUES ($1, $2, $3, $4)",
+		state.State, state.CodeVerifier, state.Nonce, state.ExpiresAt,
+	)
+	return err
+}
+
+// ConsumeLoginState возвращает и удаляет параметры входа; каждый state используется один раз
+func (r *PostgresIdentityRepository) ConsumeLoginState(ctx context.Context, state string) (*models.OIDCLoginState, error) {
+	loginState := &models.OIDCLoginState{State: state}
+	err := r.db.QueryRowContext(ctx, `
+		DELETE FROM oidc_login_states WHERE state = $1 AND expires_at > NOW()
+		RETURNING code_verifier, nonce, expires_at
+	`, state).Scan(&loginState.CodeVerifier, &loginState.Nonce, &loginState.ExpiresAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrInvalidLoginState
+		}
+		return nil, err
+	}
+	return loginState, nil
+}
+
+// FindUserByIdentity возвращает пользователя, связанного с внешней учетной записью, или nil
+func (r *PostgresIdentityRepository) FindUserByIdentity(ctx context.Context, issuer, subject string) (*models.User, error) {
+	user := &models.User{}
+	err := r.db.QueryRowContext(ctx, `
+		SELECT u.id, u.username, u.password_hash, u.coins, u.role, u.status, u.token_version, u.created_at
+		FROM user_identities i
+		JOIN users u ON u.id = i.user_id
+		WHERE i.issuer = $1 AND i.subject = $2
+	`, issuer, subject).Scan(&user.ID, &user.Username, &user.PasswordHash, &user.Coins, &user.Role, &user.Status, &user.TokenVersion, &user.CreatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return user, nil
+}
+
+// CreateUserWithIdentity создает пользователя и связывает его с внешней учетной записью
+func (r *PostgresIdentityRepository) CreateUserWithIdentity(ctx context.Context, user *models.User, identity *models.Identity) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO users (username, password_hash, coins) VALUES ($1, $2, $3)
+		RETURNING id, role, status, token_version, created_at
+	`, user.Username, user.PasswordHash, user.Coins).Scan(&user.ID, &user.Role, &user.Status, &user.TokenVersion, &user.CreatedAt)
+	if err != nil {
+		rollback(ctx, tx)
+		if isUniqueViolation(err) {
+			return ErrUsernameTaken
+		}
+		return err
+	}
+
+	identity.UserID = user.ID
+	var email sql.NullString
+	if identity.Email != "" {
+		email = sql.NullString{String: identity.Email, Valid: true}
+	}
+	err = tx.QueryRowContext(ctx,
+		"INSERT INTO user_identities (user_id, issuer, subject, email) VALUES ($1, $2, $3, $4) RETURNING id, created_at",
+		user.ID, identity.Issuer, identity.Subject, email,
+	).Scan(&identity.ID, &identity.CreatedAt)
+	if err != nil {
+		rollback(ctx, tx)
+		return err
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,85 @@
+package repository
+
+import (
+	"avito-shop-service/internal/models"
+	"context"
+	"database/sql"
+	"errors"
+)
+
+type LimitRepository interface {
+	GetOverride(ctx context.Context, userID int) (*models.TransferLimitOverride, error)
+	SetOverride(ctx context.Context, userID, adminID int, override models.TransferLimitOverride) error
+	DeleteOverride(ctx context.Context, userID int) error
+}
+
+type PostgresLimitRepository struct {
+	db *sql.DB
+}
+
+func NewPostgresLimitRepository(db *sql.DB) *PostgresLimitRepository {
+	return &PostgresLimitRepository{db: db}
+}
+
+// GetOverride возвращает индивидуальные лимиты пользователя или nil, если они не заданы
+func (r *PostgresLimitRepository) GetOverride(ctx context.Context, userID int) (*models.TransferLimitOverride, error) {
+	var maxAmount, maxDailyVolume, maxPerHour, maxRecipients sql.NullInt64
+	err := r.db.QueryRowContext(ctx, `
+		SELECT max_amount, max_daily_volume, max_per_hour, max_recipients_per_day
+		FROM transfer_limits WHERE user_id = $1
+	`, userID).Scan(&maxAmount, &maxDailyVolume, &maxPerHour, &maxRecipients)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &models.TransferLimitOverride{
+		MaxAmount:           nullIntPtr(maxAmount),
+		MaxDailyVolume:      nullIntPtr(maxDailyVolume),
+		MaxPerHour:          nullIntPtr(maxPerHour),
+		MaxRecipientsPerDay: nullIntPtr(maxRecipients),
+	}, nil
+}
+
+// SetOverride задает индивидуальные лимиты пользователя
+func (r *PostgresLimitRepository) SetOverride(ctx context.Context, userID, adminID int, override models.TransferLimitOverride) error {
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO transfer_limits (user_id, max_amount, max_daily_volume, max_per_hour, max_recipients_per_day, updated_by)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (user_id) DO UPDATE SET
+			max_amount = EXCLUDED.max_amount,
+			max_daily_volume = EXCLUDED.max_daily_volume,
+			max_per_hour = EXCLUDED.max_per_hour,
+			max_recipients_per_day = EXCLUDED.max_recipients_per_day,
+			updated_by = EXCLUDED.updated_by,
+			updated_at = NOW()
+	`, userID, intPtrArg(override.MaxAmount), intPtrArg(override.MaxDailyVolume),
+		intPtrArg(override.MaxPerHour), intPtrArg(override.MaxRecipientsPerDay), adminID)
+	if isForeignKeyViolation(err) {
+		return ErrUserNotFound
+	}
+	return err
+}
+
+// DeleteOverride возвращает пользователю лимиты по умолчанию
+func (r *PostgresLimitRepository) DeleteOverride(ctx context.Context, userID int) error {
+	_, err := r.db.ExecContext(ctx, "DELETE FROM transfer_limits WHERE user_id = $1", userID)
+	return err
+}
+
+func nullIntPtr(v sql.NullInt64) *int {
+	if !v.Valid {
+		return nil
+	}
+	i := int(v.Int64)
+	return &i
+}
+
+func intPtrArg(v *int) interface{} {
+	if v == nil {
+		return nil
+	}
+	return *v
+}
@@ -0,0 +1,83 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+type PasswordResetRepository interface {
+	CreateResetToken(ctx context.Context, userID, adminID int, tokenHash string, expiresAt time.Time) error
+	ResetPassword(ctx context.Context, tokenHash, passwordHash string) (int, error)
+}
+
+type PostgresPasswordResetRepository struct {
+	db *sql.DB
+}
+
+func NewPostgresPasswordResetRepository(db *sql.DB) *PostgresPasswordResetRepository {
+	return &PostgresPasswordResetRepository{db: db}
+}
+
+// CreateResetToken сохраняет хеш одноразового токена сброса пароля.
+// Ранее выданные неиспользованные токены пользователя аннулируются.
+func (r *PostgresPasswordResetRepository) CreateResetToken(ctx context.Context, userID, adminID int, tokenHash string, expiresAt time.Time) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, "UPDATE password_reset_tokens SET used_at = NOW() WHERE user_id = $1 AND used_at IS NULL", userID)
+	if err != nil {
+		rollback(ctx, tx)
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx,
+		"INSERT INTO password_reset_tokens (user_id, token_hash, issued_by, expires_at) VALUES ($1, $2, $3, $4)",
+		userID, tokenHash, adminID, expiresAt,
+	)
+	if err != nil {
+		rollback(ctx, tx)
+		if isForeignKeyViolation(err) {
+			return ErrUserNotFound
+		}
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// ResetPassword погашает токен сброса и устанавливает новый пароль. Возвращает id пользователя.
+func (r *PostgresPasswordResetRepository) ResetPassword(ctx context.Context, tokenHash, passwordHash string) (int, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+
+	var userID int
+	err = tx.QueryRowContext(ctx, `
+		UPDATE password_reset_tokens SET used_at = NOW()
+		WHERE token_hash = $1 AND used_at IS NULL AND expires_at > NOW()
+		RETURNING user_id
+	`, tokenHash).Scan(&userID)
+	if err != nil {
+		rollback(ctx, tx)
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, ErrInvalidResetToken
+		}
+		return 0, err
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE users SET password_hash = $1, token_version = token_version + 1, password_changed_at = NOW()
+		WHERE id = $2
+	`, passwordHash, userID)
+	if err != nil {
+		rollback(ctx, tx)
+		return 0, err
+	}
+
+	return userID, tx.Commit()
+}
@@ -0,0 +1,132 @@
+package repository
+
+import (
+	"avito-shop-service/internal/models"
+	"context"
+	"database/sql"
+	"errors"
+)
+
+type ReversalRepository interface {
+	ReverseTransfer(ctx context.Context, transactionID, adminID int, policy, reason string) (*models.Transaction, error)
+}
+
+type PostgresReversalRepository struct {
+	db *sql.DB
+}
+
+func NewPostgresReversalRepository(db *sql.DB) *PostgresReversalRepository {
+	return &PostgresReversalRepository{db: db}
+}
+
+// ReverseTransfer создает компенсирующую транзакцию от получателя к отправителю исходного перевода.
+// Сумма зависит от политики, если получатель уже потратил часть монет.
+func (r *PostgresReversalRepository) ReverseTransfer(ctx context.Context, transactionID, adminID int, policy, reason string) (*models.Transaction, error) {
+	switch policy {
+	case models.ReversalPolicyStrict, models.ReversalPolicyPartial, models.ReversalPolicyAllowNegative:
+	default:
+		return nil, ErrInvalidReversalPolicy
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	reversal, err := reverseInTx(ctx, tx, transactionID, adminID, policy, reason)
+	if err != nil {
+		rollback(ctx, tx)
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return reversal, nil
+}
+
+func reverseInTx(ctx context.Context, tx *sql.Tx, transactionID, adminID int, policy, reason string) (*models.Transaction, error) {
+	// Блокировка исходной транзакции исключает параллельную двойную отмену
+	var original models.Transaction
+	var reversalOf sql.NullInt64
+	err := tx.QueryRowContext(ctx,
+		"SELECT id, from_user_id, to_user_id, amount, reversal_of FROM transactions WHERE id = $1 FOR UPDATE",
+		transactionID,
+	).Scan(&original.ID, &original.FromUserID, &original.ToUserID, &original.Amount, &reversalOf)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrTransactionNotFound
+		}
+		return nil, err
+	}
+	if reversalOf.Valid {
+		return nil, ErrCannotReverseReversal
+	}
+
+	var reversed bool
+	err = tx.QueryRowContext(ctx, "SELECT EXISTS (SELECT 1 FROM transactions WHERE reversal_of = $1)", transactionID).Scan(&reversed)
+	if err != nil {
+		return nil, err
+	}
+	if reversed {
+		return nil, ErrAlreadyReversed
+	}
+
+	if err := lockUsers(ctx, tx, original.FromUserID, original.ToUserID); err != nil {
+		return nil, err
+	}
+	// Монеты не возвращаются на закрытый аккаунт и не списываются с него
+	for _, userID := range []int{original.FromUserID, original.ToUserID} {
+		if err := checkAccountNotClosed(ctx, tx, userID); err != nil {
+			return nil, err
+		}
+	}
+
+	available, err := lockAvailableBalance(ctx, tx, original.ToUserID, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	amount := original.Amount
+	switch policy {
+	case models.ReversalPolicyStrict:
+		if available < amount {
+			return nil, ErrInsufficientFunds
+		}
+	case models.ReversalPolicyPartial:
+		if available < amount {
+			amount = available
+		}
+		if amount <= 0 {
+			return nil, ErrInsufficientFunds
+		}
+	case models.ReversalPolicyAllowNegative:
+		// Баланс получателя может стать отрицательным
+	default:
+		return nil, ErrInvalidReversalPolicy
+	}
+
+	if _, err := tx.ExecContext(ctx, "UPDATE users SET coins = coins - $1 WHERE id = $2", amount, original.ToUserID); err != nil {
+		return nil, err
+	}
+	if _, err := tx.ExecContext(ctx, "UPDATE users SET coins = coins + $1 WHERE id = $2", amount, original.FromUserID); err != nil {
+		return nil, err
+	}
+
+	reversal := &models.Transaction{
+		FromUserID: original.ToUserID,
+		ToUserID:   original.FromUserID,
+		Amount:     amount,
+		ReversalOf: &original.ID,
+	}
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO transactions (from_user_id, to_user_id, amount, reversal_of, reversed_by, reversal_reason)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, created_at
+	`, reversal.FromUserID, reversal.ToUserID, amount, original.ID, adminID, reason).Scan(&reversal.ID, &reversal.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return reversal, nil
+}
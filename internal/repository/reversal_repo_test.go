@@ -0,0 +1,17 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Неизвестная политика отклоняется до начала транзакции
+func TestReverseTransferUnknownPolicy(t *testing.T) {
+	repo := NewPostgresReversalRepository(nil)
+
+	_, err := repo.ReverseTransfer(context.Background(), 10, 1, "forgive", "wrong recipient")
+
+	assert.ErrorIs(t, err, ErrInvalidReversalPolicy)
+}
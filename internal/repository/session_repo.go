@@ -0,0 +1,98 @@
+package repository
+
+import (
+	"avito-shop-service/internal/models"
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+type SessionRepository interface {
+	CreateSession(ctx context.Context, session *models.Session) error
+	GetActiveSessions(ctx context.Context, userID int) ([]models.Session, error)
+	TouchSession(ctx context.Context, sessionID string) (*SessionState, error)
+	RevokeSession(ctx context.Context, sessionID string, userID int) error
+}
+
+// Состояние сессии для проверки токена
+type SessionState struct {
+	UserID    int
+	ExpiresAt time.Time
+	Revoked   bool
+}
+
+type PostgresSessionRepository struct {
+	db *sql.DB
+}
+
+func NewPostgresSessionRepository(db *sql.DB) *PostgresSessionRepository {
+	return &PostgresSessionRepository{db: db}
+}
+
+// CreateSession сохраняет новую сессию
+func (r *PostgresSessionRepository) CreateSession(ctx context.Context, session *models.Session) error {
+	return r.db.QueryRowContext(ctx, `
+		INSERT INTO sessions (id, user_id, user_agent, ip, expires_at) VALUES ($1, $2, $3, $4, $5)
+		RETURNING created_at, last_seen_at
+	`, session.ID, session.UserID, session.UserAgent, session.IP, session.ExpiresAt).Scan(&session.CreatedAt, &session.LastSeenAt)
+}
+
+// GetActiveSessions возвращает неотозванные и неистекшие сессии пользователя
+func (r *PostgresSessionRepository) GetActiveSessions(ctx context.Context, userID int) ([]models.Session, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT id, user_id, user_agent, ip, created_at, last_seen_at, expires_at
+		FROM sessions
+		WHERE user_id = $1 AND revoked_at IS NULL AND expires_at > NOW()
+		ORDER BY last_seen_at DESC
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var sessions []models.Session
+	for rows.Next() {
+		var s models.Session
+		if err := rows.Scan(&s.ID, &s.UserID, &s.UserAgent, &s.IP, &s.CreatedAt, &s.LastSeenAt, &s.ExpiresAt); err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, s)
+	}
+	return sessions, rows.Err()
+}
+
+// TouchSession отмечает активность сессии и возвращает ее состояние
+func (r *PostgresSessionRepository) TouchSession(ctx context.Context, sessionID string) (*SessionState, error) {
+	state := &SessionState{}
+	err := r.db.QueryRowContext(ctx, `
+		UPDATE sessions SET last_seen_at = NOW() WHERE id = $1
+		RETURNING user_id, expires_at, revoked_at IS NOT NULL
+	`, sessionID).Scan(&state.UserID, &state.ExpiresAt, &state.Revoked)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrSessionNotFound
+		}
+		return nil, err
+	}
+	return state, nil
+}
+
+// RevokeSession отзывает сессию пользователя
+func (r *PostgresSessionRepository) RevokeSession(ctx context.Context, sessionID string, userID int) error {
+	res, err := r.db.ExecContext(ctx,
+		"UPDATE sessions SET revoked_at = NOW() WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL",
+		sessionID, userID,
+	)
+	if err != nil {
+		return err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrSessionNotFound
+	}
+	return nil
+}
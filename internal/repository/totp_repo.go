@@ -0,0 +1,176 @@
+package repository
+
+import (
+	"avito-shop-service/internal/models"
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+type TOTPRepository interface {
+	SaveSecret(ctx context.Context, userID int, secret string) error
+	GetTOTP(ctx context.Context, userID int) (*models.TOTP, error)
+	Enable(ctx context.Context, userID int, step int64, recoveryCodeHashes []string) error
+	UseStep(ctx context.Context, userID int, step int64) (bool, error)
+	UseRecoveryCode(ctx context.Context, userID int, codeHash string) (bool, error)
+	RecordFailure(ctx context.Context, userID, maxAttempts int, lockedUntil time.Time) error
+	Disable(ctx context.Context, userID int) error
+}
+
+type PostgresTOTPRepository struct {
+	db *sql.DB
+}
+
+func NewPostgresTOTPRepository(db *sql.DB) *PostgresTOTPRepository {
+	return &PostgresTOTPRepository{db: db}
+}
+
+// SaveSecret сохраняет новый неподтвержденный секрет, заменяя прежний неподтвержденный
+func (r *PostgresTOTPRepository) SaveSecret(ctx context.Context, userID int, secret string) error {
+	res, err := r.db.ExecContext(ctx, `
+		INSERT INTO user_totp (user_id, secret) VALUES ($1, $2)
+		ON CONFLICT (user_id) DO UPDATE SET secret = EXCLUDED.secret, last_used_step = 0, created_at = NOW()
+		WHERE user_totp.enabled_at IS NULL
+	`, userID, secret)
+	if err != nil {
+		if isForeignKeyViolation(err) {
+			return ErrUserNotFound
+		}
+		return err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrTOTPAlreadyEnabled
+	}
+	return nil
+}
+
+// GetTOTP возвращает настройки TOTP пользователя или nil, если подключение не начиналось
+func (r *PostgresTOTPRepository) GetTOTP(ctx context.Context, userID int) (*models.TOTP, error) {
+	totp := &models.TOTP{UserID: userID}
+	var enabledAt, lockedUntil sql.NullTime
+	err := r.db.QueryRowContext(ctx,
+		"SELECT secret, enabled_at, last_used_step, locked_until FROM user_totp WHERE user_id = $1",
+		userID,
+	).Scan(&totp.Secret, &enabledAt, &totp.LastUsedStep, &lockedUntil)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if enabledAt.Valid {
+		totp.EnabledAt = &enabledAt.Time
+	}
+	if lockedUntil.Valid {
+		totp.LockedUntil = &lockedUntil.Time
+	}
+	return totp, nil
+}
+
+// Enable подтверждает подключение и заменяет коды восстановления
+func (r *PostgresTOTPRepository) Enable(ctx context.Context, userID int, step int64, recoveryCodeHashes []string) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	res, err := tx.ExecContext(ctx,
+		"UPDATE user_totp SET enabled_at = NOW(), last_used_step = $1 WHERE user_id = $2 AND enabled_at IS NULL",
+		step, userID,
+	)
+	if err != nil {
+		rollback(ctx, tx)
+		return err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		rollback(ctx, tx)
+		return err
+	}
+	if affected == 0 {
+		rollback(ctx, tx)
+		return ErrTOTPAlreadyEnabled
+	}
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM totp_recovery_codes WHERE user_id = $1", userID); err != nil {
+		rollback(ctx, tx)
+		return err
+	}
+	for _, hash := range recoveryCodeHashes {
+		if _, err := tx.ExecContext(ctx, "INSERT INTO totp_recovery_codes (user_id, code_hash) VALUES ($1, $2)", userID, hash); err != nil {
+			rollback(ctx, tx)
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// UseStep отмечает временной шаг как использованный и сбрасывает счетчик неверных кодов.
+// Возвращает false, если код этого или более позднего шага уже применялся.
+func (r *PostgresTOTPRepository) UseStep(ctx context.Context, userID int, step int64) (bool, error) {
+	res, err := r.db.ExecContext(ctx,
+		"UPDATE user_totp SET last_used_step = $1, failed_attempts = 0 WHERE user_id = $2 AND last_used_step < $1",
+		step, userID,
+	)
+	if err != nil {
+		return false, err
+	}
+	affected, err := res.RowsAffected()
+	return affected > 0, err
+}
+
+// UseRecoveryCode погашает код восстановления и сбрасывает счетчик неверных кодов.
+// Возвращает false, если код не найден или уже использован.
+func (r *PostgresTOTPRepository) UseRecoveryCode(ctx context.Context, userID int, codeHash string) (bool, error) {
+	res, err := r.db.ExecContext(ctx, `
+		WITH used AS (
+			UPDATE totp_recovery_codes SET used_at = NOW()
+			WHERE user_id = $1 AND code_hash = $2 AND used_at IS NULL
+			RETURNING user_id
+		)
+		UPDATE user_totp SET failed_attempts = 0 WHERE user_id IN (SELECT user_id FROM used)
+	`, userID, codeHash)
+	if err != nil {
+		return false, err
+	}
+	affected, err := res.RowsAffected()
+	return affected > 0, err
+}
+
+// RecordFailure учитывает неверный код. На maxAttempts-й ошибке подряд проверка кодов
+// приостанавливается до lockedUntil, а счетчик начинается заново
+func (r *PostgresTOTPRepository) RecordFailure(ctx context.Context, userID, maxAttempts int, lockedUntil time.Time) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE user_totp SET
+			failed_attempts = CASE WHEN failed_attempts + 1 >= $2 THEN 0 ELSE failed_attempts + 1 END,
+			locked_until = CASE WHEN failed_attempts + 1 >= $2 THEN $3 ELSE locked_until END
+		WHERE user_id = $1
+	`, userID, maxAttempts, lockedUntil)
+	return err
+}
+
+// Disable отключает двухфакторную аутентификацию и удаляет коды восстановления
+func (r *PostgresTOTPRepository) Disable(ctx context.Context, userID int) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM totp_recovery_codes WHERE user_id = $1", userID); err != nil {
+		rollback(ctx, tx)
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, "DELETE FROM user_totp WHERE user_id = $1", userID); err != nil {
+		rollback(ctx, tx)
+		return err
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,40 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Проверка приостанавливается на maxAttempts-й ошибке, успешный код сбрасывает счетчик
+func TestTOTPRecordFailure(t *testing.T) {
+	db := connectTestDB(t)
+	repo := NewPostgresTOTPRepository(db)
+	ctx := context.Background()
+	userID := createTestUser(t, db)
+	lockedUntil := time.Now().Add(time.Hour).UTC().Truncate(time.Second)
+
+	require.NoError(t, repo.SaveSecret(ctx, userID, "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"))
+	require.NoError(t, repo.Enable(ctx, userID, 1, nil))
+
+	require.NoError(t, repo.RecordFailure(ctx, userID, 3, lockedUntil))
+	require.NoError(t, repo.RecordFailure(ctx, userID, 3, lockedUntil))
+	_, err := repo.UseStep(ctx, userID, 2)
+	require.NoError(t, err)
+	require.NoError(t, repo.RecordFailure(ctx, userID, 3, lockedUntil))
+	require.NoError(t, repo.RecordFailure(ctx, userID, 3, lockedUntil))
+
+	totp, err := repo.GetTOTP(ctx, userID)
+	require.NoError(t, err)
+	assert.Nil(t, totp.LockedUntil)
+
+	require.NoError(t, repo.RecordFailure(ctx, userID, 3, lockedUntil))
+
+	totp, err = repo.GetTOTP(ctx, userID)
+	require.NoError(t, err)
+	require.NotNil(t, totp.LockedUntil)
+	assert.True(t, lockedUntil.Equal(*totp.LockedUntil))
+}
@@ -2,15 +2,19 @@ package repository
 
 import (
 	"avito-shop-service/internal/models"
+	"context"
 	"database/sql"
 	"errors"
 	"fmt"
 )
 
 type UserRepositoryInterface interface {
-	CreateUser(user *models.User) error
-	GetUserByUsername(username string) (*models.User, error)
-	UpdateCoins(userID int, amount int) error
+	CreateUser(ctx context.Context, user *models.User) error
+	GetUserByUsername(ctx context.Context, username string) (*models.User, error)
+	GetUserByID(ctx context.Context, userID int) (*models.User, error)
+	UpdateCoins(ctx context.Context, userID int, amount int) error
+	UpdatePassword(ctx context.Context, userID int, passwordHash string) error
+	RehashPassword(ctx context.Context, userID int, oldHash, newHash string) error
 }
 
 type UserRepository struct {
@@ -22,17 +26,35 @@ func NewUserRepository(db *sql.DB) *UserRepository {
 }
 
 // CreateUser создает нового пользователя в базе данных
-func (r *UserRepository) CreateUser(user *models.User) error {
-	_, err := r.db.Exec("INSERT INTO users (username, password_hash, coins) VALUES ($1, $2, $3)",
+func (r *UserRepository) CreateUser(ctx context.Context, user *models.User) error {
+	_, err := r.db.ExecContext(ctx, "INSERT INTO users (username, password_hash, coins) VALUES ($1, $2, $3)",
 		user.Username, user.PasswordHash, user.Coins)
+	if isUniqueViolation(err) {
+		return ErrUsernameTaken
+	}
 	return err
 }
 
 // GetUserByUsername возвращает пользователя по логину
-func (r *UserRepository) GetUserByUsername(username string) (*models.User, error) {
+func (r *UserRepository) GetUserByUsername(ctx context.Context, username string) (*models.User, error) {
+	user := &models.User{}
+	err := r.db.QueryRowContext(ctx, "SELECT id, username, password_hash, coins, role, status, token_version, created_at FROM users WHERE username = $1", username).
+		Scan(&user.ID, &user.Username, &user.PasswordHash, &user.Coins, &user.Role, &user.Status, &user.TokenVersion, &user.CreatedAt)
+
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return user, nil
+}
+
+// GetUserByID возвращает пользователя по идентификатору
+func (r *UserRepository) GetUserByID(ctx context.Context, userID int) (*models.User, error) {
 	user := &models.User{}
-	err := r.db.QueryRow("SELECT id, username, password_hash, coins, created_at FROM users WHERE username = $1", username).
-		Scan(&user.ID, &user.Username, &user.PasswordHash, &user.Coins, &user.CreatedAt)
+	err := r.db.QueryRowContext(ctx, "SELECT id, username, password_hash, coins, role, status, token_version, created_at FROM users WHERE id = $1", userID).
+		Scan(&user.ID, &user.Username, &user.PasswordHash, &user.Coins, &user.Role, &user.Status, &user.TokenVersion, &user.CreatedAt)
 
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
@@ -44,11 +66,27 @@ func (r *UserRepository) GetUserByUsername(username string) (*models.User, error
 }
 
 // UpdateCoins обновляет баланс пользователя
-func (r *UserRepository) UpdateCoins(userID int, amount int) error {
+func (r *UserRepository) UpdateCoins(ctx context.Context, userID int, amount int) error {
 	if amount < 0 {
 		return fmt.Errorf("amount cannot be negative")
 	}
 
-	_, err := r.db.Exec("UPDATE users SET coins = coins + $1 WHERE id = $2", amount, userID)
+	_, err := r.db.ExecContext(ctx, "UPDATE users SET coins = coins + $1 WHERE id = $2", amount, userID)
+	return err
+}
+
+// UpdatePassword сохраняет новый хеш пароля и отзывает ранее выданные токены
+func (r *UserRepository) UpdatePassword(ctx context.Context, userID int, passwordHash string) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE users SET password_hash = $1, token_version = token_version + 1, password_changed_at = NOW()
+		WHERE id = $2
+	`, passwordHash, userID)
+	return err
+}
+
+// RehashPassword заменяет хеш того же пароля, полученный устаревшим алгоритмом. Токены не отзываются.
+// Если пароль успели сменить, хеш не перезаписывается.
+func (r *UserRepository) RehashPassword(ctx context.Context, userID int, oldHash, newHash string) error {
+	_, err := r.db.ExecContext(ctx, "UPDATE users SET password_hash = $1 WHERE id = $2 AND password_hash = $3", newHash, userID, oldHash)
 	return err
 }
@@ -2,18 +2,23 @@ package repository
 
 import (
 	"avito-shop-service/internal/models"
+	"context"
 	"database/sql"
 	"errors"
-	"log"
+	"log/slog"
 )
 
 type WalletRepository interface {
-	GetBalance(userID int) (int, error)
-	Transfer(fromUserID, toUserID, amount int) error
-	GetTransactions(userID int) ([]models.Transaction, error)
-	PurchaseItem(userID int, itemName string, price int, quantity int) error
-	GetInventory(userID int) ([]models.Item, error)
-	GetItemPrice(itemName string) (int, error)
+	GetBalance(ctx context.Context, userID int) (int, error)
+	GetAvailableBalance(ctx context.Context, userID int) (int, error)
+	Transfer(ctx context.Context, fromUserID, toUserID, amount int, check models.TransferCheck) error
+	GetTransactions(ctx context.Context, userID int) ([]models.Transaction, error)
+	PurchaseItem(ctx context.Context, userID int, itemName string, quantity int) error
+	GetInventory(ctx context.Context, userID int) ([]models.Item, error)
+	GetItemPrice(ctx context.Context, itemName string) (int, error)
+	SetItemPrice(ctx context.Context, itemName string, price int) error
+	GetAdjustments(ctx context.Context, userID int) ([]models.Adjustment, error)
+	GetInfo(ctx context.Context, userID int) (*models.InfoResponse, error)
 }
 
 type PostgresWalletRepository struct {
@@ -25,89 +30,63 @@ func NewPostgresWalletRepository(db *sql.DB) *PostgresWalletRepository {
 }
 
 // Получение баланса пользователя
-func (r *PostgresWalletRepository) GetBalance(userID int) (int, error) {
+func (r *PostgresWalletRepository) GetBalance(ctx context.Context, userID int) (int, error) {
 	var balance int
-	err := r.db.QueryRow("SELECT coins FROM users WHERE id = $1", userID).Scan(&balance)
+	err := r.db.QueryRowContext(ctx, "SELECT coins FROM users WHERE id = $1", userID).Scan(&balance)
 
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			return 0, errors.New("user not found")
+			return 0, ErrUserNotFound
 		}
 		return 0, err
 	}
 	return balance, nil
 }
 
-// Обновление баланса пользователя
-func (r *PostgresWalletRepository) UpdateBalance(userID int, amount int) error {
-	_, err := r.db.Exec("UPDATE users SET coins = coins + $1 WHERE id = $2", amount, userID)
-	return err
-}
-
-// Перевод монет между пользователями
-func (r *PostgresWalletRepository) Transfer(fromUserID, toUserID, amount int) error {
-	tx, err := r.db.Begin()
-	if err != nil {
-		return err
-	}
+// Получение доступного баланса (за вычетом активных холдов)
+func (r *PostgresWalletRepository) GetAvailableBalance(ctx context.Context, userID int) (int, error) {
+	var balance int
+	err := r.db.QueryRowContext(ctx, `
+		SELECT u.coins - COALESCE((
+			SELECT SUM(h.amount) FROM holds h
+			WHERE h.user_id = u.id AND h.status = 'active' AND h.expires_at > NOW()
+		), 0)
+		FROM users u WHERE u.id = $1
+	`, userID).Scan(&balance)
 
-	// Проверяем баланс отправителя
-	var senderBalance int
-	err = tx.QueryRow("SELECT coins FROM users WHERE id = $1", fromUserID).Scan(&senderBalance)
 	if err != nil {
-		if err := tx.Rollback(); err != nil {
-			log.Printf("rollback failed: %v", err)
-		}
-		return err
-	}
-
-	if senderBalance < amount {
-		if err := tx.Rollback(); err != nil {
-			log.Printf("rollback failed: %v", err)
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, ErrUserNotFound
 		}
-
-		return errors.New("insufficient funds")
+		return 0, err
 	}
+	return balance, nil
+}
 
-	// Вычитаем монеты у отправителя
-	_, err = tx.Exec("UPDATE users SET coins = coins - $1 WHERE id = $2", amount, fromUserID)
-	if err != nil {
-		if err := tx.Rollback(); err != nil {
-			log.Printf("rollback failed: %v", err)
-		}
-
-		return err
-	}
+// Обновление баланса пользователя
+func (r *PostgresWalletRepository) UpdateBalance(ctx context.Context, userID int, amount int) error {
+	_, err := r.db.ExecContext(ctx, "UPDATE users SET coins = coins + $1 WHERE id = $2", amount, userID)
+	return err
+}
 
-	// Добавляем монеты получателю
-	_, err = tx.Exec("UPDATE users SET coins = coins + $1 WHERE id = $2", amount, toUserID)
+// Перевод монет между пользователями; check (если задан) проверяет лимиты отправителя
+func (r *PostgresWalletRepository) Transfer(ctx context.Context, fromUserID, toUserID, amount int, check models.TransferCheck) error {
+	tx, err := r.db.BeginTx(ctx, nil)
 	if err != nil {
-		if err := tx.Rollback(); err != nil {
-			log.Printf("rollback failed: %v", err)
-		}
-
 		return err
 	}
 
-	// Записываем транзакцию в таблицу transactions
-	_, err = tx.Exec(
-		"INSERT INTO transactions (from_user_id, to_user_id, amount) VALUES ($1, $2, $3)",
-		fromUserID, toUserID, amount,
-	)
-	if err != nil {
-		if err := tx.Rollback(); err != nil {
-			log.Printf("rollback failed: %v", err)
-		}
-
+	if err := transferInTx(ctx, tx, fromUserID, toUserID, amount, 0, check); err != nil {
+		rollback(ctx, tx)
 		return err
 	}
 
 	return tx.Commit()
 }
 
-func (r *PostgresWalletRepository) GetTransactions(userID int) ([]models.Transaction, error) {
-	rows, err := r.db.Query(`
-		SELECT id, from_user_id, to_user_id, amount, created_at 
+func (r *PostgresWalletRepository) GetTransactions(ctx context.Context, userID int) ([]models.Transaction, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT id, from_user_id, to_user_id, amount, reversal_of, created_at
 		FROM transactions 
 		WHERE from_user_id = $1 OR to_user_id = $1 
 		ORDER BY created_at DESC
@@ -121,73 +100,171 @@ func (r *PostgresWalletRepository) GetTransactions(userID int) ([]models.Transac
 	var transactions []models.Transaction
 	for rows.Next() {
 		var t models.Transaction
-		if err := rows.Scan(&t.ID, &t.FromUserID, &t.ToUserID, &t.Amount, &t.CreatedAt); err != nil {
+		var reversalOf sql.NullInt64
+		if err := rows.Scan(&t.ID, &t.FromUserID, &t.ToUserID, &t.Amount, &reversalOf, &t.CreatedAt); err != nil {
 			return nil, err
 		}
+		if reversalOf.Valid {
+			v := int(reversalOf.Int64)
+			t.ReversalOf = &v
+		}
 		transactions = append(transactions, t)
 	}
 
 	return transactions, nil
 }
 
-// Получение цены товара из базы данных
-func (r *PostgresWalletRepository) GetItemPrice(itemName string) (int, error) {
-	var price int
-	err := r.db.QueryRow("SELECT price FROM shop WHERE item = $1", itemName).Scan(&price)
+// Получение административных корректировок баланса пользователя
+func (r *PostgresWalletRepository) GetAdjustments(ctx context.Context, userID int) ([]models.Adjustment, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT id, user_id, COALESCE(admin_id, 0), amount, reason, reference, created_at
+		FROM coin_adjustments
+		WHERE user_id = $1
+		ORDER BY created_at DESC
+	`, userID)
 	if err != nil {
-		if err == sql.ErrNoRows {
-			return 0, errors.New("item not found")
+		return nil, err
+	}
+	defer rows.Close()
+
+	var adjustments []models.Adjustment
+	for rows.Next() {
+		var a models.Adjustment
+		if err := rows.Scan(&a.ID, &a.UserID, &a.AdminID, &a.Amount, &a.Reason, &a.Reference, &a.CreatedAt); err != nil {
+			return nil, err
 		}
-		return 0, err
+		adjustments = append(adjustments, a)
 	}
-	return price, nil
+
+	return adjustments, rows.Err()
 }
 
-// Покупка товара
-func (r *PostgresWalletRepository) PurchaseItem(userID int, itemName string, price int, quantity int) error {
-	// Начинаем транзакцию
-	tx, err := r.db.Begin()
+// Баланс, инвентарь, переводы и корректировки читаются одним запросом. Все части запроса
+// видят один снимок базы, поэтому покупка или перевод, завершенные во время чтения,
+// либо видны целиком, либо не видны вовсе. Части различаются по столбцу kind
+const infoSnapshotQuery = `
+	WITH account AS (
+		SELECT u.coins, u.coins - COALESCE((
+			SELECT SUM(h.amount) FROM holds h
+			WHERE h.user_id = u.id AND h.status = 'active' AND h.expires_at > NOW()
+		), 0) AS available
+		FROM users u WHERE u.id = $1
+	)
+	SELECT kind, id, first_user_id, second_user_id, amount, quantity, reversal_of, label, reference, created_at
+	FROM (
+		SELECT 0 AS part, 'account' AS kind, 0 AS id, 0 AS first_user_id, 0 AS second_user_id,
+			coins AS amount, available AS quantity, NULL::int AS reversal_of,
+			'' AS label, '' AS reference, NULL::timestamp AS created_at
+		FROM account
+		UNION ALL
+		SELECT 1, 'item', 0, 0, 0, price, SUM(quantity), NULL, item, '', NULL
+		FROM purchases WHERE user_id = $1
+		GROUP BY item, price
+		UNION ALL
+		SELECT 2, 'transaction', id, from_user_id, to_user_id, amount, 0, reversal_of, '', '', created_at
+		FROM transactions WHERE from_user_id = $1 OR to_user_id = $1
+		UNION ALL
+		SELECT 3, 'adjustment', id, user_id, COALESCE(admin_id, 0), amount, 0, NULL, reason, reference, created_at
+		FROM coin_adjustments WHERE user_id = $1
+	) info
+	ORDER BY part, created_at DESC
+`
+
+// Согласованный снимок данных для /api/info за одно обращение к базе
+func (r *PostgresWalletRepository) GetInfo(ctx context.Context, userID int) (*models.InfoResponse, error) {
+	rows, err := r.db.QueryContext(ctx, infoSnapshotQuery, userID)
 	if err != nil {
-		return err
+		return nil, err
 	}
+	defer rows.Close()
 
-	// Проверяем баланс пользователя
-	var userBalance int
-	err = tx.QueryRow("SELECT coins FROM users WHERE id = $1", userID).Scan(&userBalance)
-	if err != nil {
-		if err := tx.Rollback(); err != nil {
-			log.Printf("rollback failed: %v", err)
+	info := &models.InfoResponse{}
+	found := false
+	for rows.Next() {
+		var (
+			kind, label, reference                     string
+			id, firstUserID, secondUserID, amount, qty int
+			reversalOf                                 sql.NullInt64
+			createdAt                                  sql.NullTime
+		)
+		if err := rows.Scan(&kind, &id, &firstUserID, &secondUserID, &amount, &qty, &reversalOf, &label, &reference, &createdAt); err != nil {
+			return nil, err
 		}
 
-		return err
-	}
-
-	totalPrice := price * quantity
-	if userBalance < totalPrice {
-		if err := tx.Rollback(); err != nil {
-			log.Printf("rollback failed: %v", err)
+		switch kind {
+		case "account":
+			found = true
+			info.Balance = amount
+			info.AvailableBalance = qty
+		case "item":
+			info.Inventory = append(info.Inventory, models.Item{Name: label, Price: amount, Quantity: qty})
+		case "transaction":
+			t := models.Transaction{ID: id, FromUserID: firstUserID, ToUserID: secondUserID, Amount: amount, CreatedAt: createdAt.Time}
+			if reversalOf.Valid {
+				v := int(reversalOf.Int64)
+				t.ReversalOf = &v
+			}
+			info.Transactions = append(info.Transactions, t)
+		case "adjustment":
+			info.Adjustments = append(info.Adjustments, models.Adjustment{
+				ID:        id,
+				UserID:    firstUserID,
+				AdminID:   secondUserID,
+				Amount:    amount,
+				Reason:    label,
+				Reference: reference,
+				CreatedAt: createdAt.Time,
+			})
 		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
 
-		return errors.New("insufficient funds")
+	if !found {
+		return nil, ErrUserNotFound
 	}
+	return info, nil
+}
 
-	// Обновляем баланс пользователя
-	_, err = tx.Exec("UPDATE users SET coins = coins - $1 WHERE id = $2", totalPrice, userID)
+// Получение цены товара из базы данных
+func (r *PostgresWalletRepository) GetItemPrice(ctx context.Context, itemName string) (int, error) {
+	var price int
+	err := r.db.QueryRowContext(ctx, "SELECT price FROM shop WHERE item = $1", itemName).Scan(&price)
 	if err != nil {
-		if err := tx.Rollback(); err != nil {
-			log.Printf("rollback failed: %v", err)
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, ErrItemNotFound
 		}
+		return 0, err
+	}
+	return price, nil
+}
+
+// Добавление товара в магазин или изменение его цены
+func (r *PostgresWalletRepository) SetItemPrice(ctx context.Context, itemName string, price int) error {
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO shop (item, price) VALUES ($1, $2)
+		ON CONFLICT (item) DO UPDATE SET price = EXCLUDED.price
+	`, itemName, price)
+	return err
+}
 
+// Покупка товара по текущей цене магазина
+func (r *PostgresWalletRepository) PurchaseItem(ctx context.Context, userID int, itemName string, quantity int) error {
+	// Начинаем транзакцию
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
 		return err
 	}
 
-	// Записываем покупку в таблицу purchases
-	_, err = tx.Exec("INSERT INTO purchases (user_id, item, price, quantity) VALUES ($1, $2, $3, $4)", userID, itemName, price, quantity)
+	price, err := lockItemPrice(ctx, tx, itemName)
 	if err != nil {
-		if err := tx.Rollback(); err != nil {
-			log.Printf("rollback failed: %v", err)
-		}
+		rollback(ctx, tx)
+		return err
+	}
 
+	if err := purchaseInTx(ctx, tx, userID, itemName, price, quantity, 0); err != nil {
+		rollback(ctx, tx)
 		return err
 	}
 
@@ -196,8 +273,8 @@ func (r *PostgresWalletRepository) PurchaseItem(userID int, itemName string, pri
 }
 
 // Получение инвентаря пользователя
-func (r *PostgresWalletRepository) GetInventory(userID int) ([]models.Item, error) {
-	rows, err := r.db.Query(`SELECT item, price, SUM(quantity) as quantity
+func (r *PostgresWalletRepository) GetInventory(ctx context.Context, userID int) ([]models.Item, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT item, price, SUM(quantity) as quantity
         FROM purchases 
         WHERE user_id = $1 
         GROUP BY item, price`, userID)
@@ -217,3 +294,169 @@ func (r *PostgresWalletRepository) GetInventory(userID int) ([]models.Item, erro
 
 	return inventory, nil
 }
+
+func rollback(ctx context.Context, tx *sql.Tx) {
+	// После отмены контекста database/sql откатывает транзакцию сам
+	if err := tx.Rollback(); err != nil && !errors.Is(err, sql.ErrTxDone) {
+		slog.ErrorContext(ctx, "rollback failed", slog.Any("error", err))
+	}
+}
+
+// Блокирует строку пользователя до конца транзакции и возвращает доступный баланс.
+// Холд excludeHoldID не учитывается: его средства расходуются в этой же транзакции.
+func lockAvailableBalance(ctx context.Context, tx *sql.Tx, userID int, excludeHoldID int) (int, error) {
+	var coins int
+	err := tx.QueryRowContext(ctx, "SELECT coins FROM users WHERE id = $1 FOR UPDATE", userID).Scan(&coins)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, ErrUserNotFound
+		}
+		return 0, err
+	}
+
+	var held int
+	err = tx.QueryRowContext(ctx, `
+		SELECT COALESCE(SUM(amount), 0) FROM holds
+		WHERE user_id = $1 AND status = 'active' AND expires_at > NOW() AND id <> $2
+	`, userID, excludeHoldID).Scan(&held)
+	if err != nil {
+		return 0, err
+	}
+
+	return coins - held, nil
+}
+
+// Блокирует строки пользователей в порядке возрастания id, чтобы встречные переводы не взаимоблокировались
+func lockUsers(ctx context.Context, tx *sql.Tx, firstUserID, secondUserID int) error {
+	rows, err := tx.QueryContext(ctx, "SELECT id FROM users WHERE id IN ($1, $2) ORDER BY id FOR UPDATE", firstUserID, secondUserID)
+	if err != nil {
+		return err
+	}
+	locked := 0
+	for rows.Next() {
+		locked++
+	}
+	if err := rows.Close(); err != nil {
+		return err
+	}
+	if locked < 2 {
+		return ErrUserNotFound
+	}
+	return nil
+}
+
+// Перевод монет внутри открытой транзакции
+func transferInTx(ctx context.Context, tx *sql.Tx, fromUserID, toUserID, amount int, excludeHoldID int, check models.TransferCheck) error {
+	if err := lockUsers(ctx, tx, fromUserID, toUserID); err != nil {
+		return err
+	}
+
+	// Статистика читается после блокировки отправителя: параллельный перевод того же
+	// отправителя ждет здесь и видит уже завершенный перевод
+	if check != nil {
+		stats, err := transferStatsInTx(ctx, tx, fromUserID, toUserID)
+		if err != nil {
+			return err
+		}
+		if err := check(stats); err != nil {
+			return err
+		}
+	}
+
+	// Отправитель и получатель должны быть активны
+	if err := checkAccountActive(ctx, tx, fromUserID); err != nil {
+		return err
+	}
+	if err := checkAccountActive(ctx, tx, toUserID); err != nil {
+		return err
+	}
+
+	// Исходящие переводы могут быть заблокированы до проверки администратором
+	var frozen bool
+	if err := tx.QueryRowContext(ctx, "SELECT transfers_frozen FROM users WHERE id = $1", fromUserID).Scan(&frozen); err != nil {
+		return err
+	}
+	if frozen {
+		return ErrTransfersFrozen
+	}
+
+	// Проверяем баланс отправителя
+	senderBalance, err := lockAvailableBalance(ctx, tx, fromUserID, excludeHoldID)
+	if err != nil {
+		return err
+	}
+	if senderBalance < amount {
+		return ErrInsufficientFunds
+	}
+
+	// Вычитаем монеты у отправителя
+	if _, err := tx.ExecContext(ctx, "UPDATE users SET coins = coins - $1 WHERE id = $2", amount, fromUserID); err != nil {
+		return err
+	}
+
+	// Добавляем монеты получателю
+	if _, err := tx.ExecContext(ctx, "UPDATE users SET coins = coins + $1 WHERE id = $2", amount, toUserID); err != nil {
+		return err
+	}
+
+	// Записываем транзакцию в таблицу transactions
+	_, err = tx.ExecContext(ctx,
+		"INSERT INTO transactions (from_user_id, to_user_id, amount) VALUES ($1, $2, $3)",
+		fromUserID, toUserID, amount,
+	)
+	return err
+}
+
+// Исходящие переводы за последний час и последние сутки.
+// Компенсирующие транзакции отмены в лимиты не входят.
+func transferStatsInTx(ctx context.Context, tx *sql.Tx, fromUserID, toUserID int) (models.TransferStats, error) {
+	var stats models.TransferStats
+	err := tx.QueryRowContext(ctx, `
+		SELECT
+			COUNT(*) FILTER (WHERE created_at > NOW() - INTERVAL '1 hour'),
+			COALESCE(SUM(amount), 0),
+			COUNT(DISTINCT to_user_id),
+			COALESCE(BOOL_OR(to_user_id = $2), false)
+		FROM transactions
+		WHERE from_user_id = $1 AND reversal_of IS NULL AND created_at > NOW() - INTERVAL '1 day'
+	`, fromUserID, toUserID).Scan(&stats.HourlyCount, &stats.DailyVolume, &stats.DailyRecipients, &stats.RecipientSeenToday)
+	return stats, err
+}
+
+// Цена товара внутри транзакции покупки. Блокировка строки магазина не дает изменить цену
+// до конца транзакции, поэтому списывается и записывается в покупку именно эта цена
+func lockItemPrice(ctx context.Context, tx *sql.Tx, itemName string) (int, error) {
+	var price int
+	err := tx.QueryRowContext(ctx, "SELECT price FROM shop WHERE item = $1 FOR SHARE", itemName).Scan(&price)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, ErrItemNotFound
+	}
+	return price, err
+}
+
+// Покупка товара внутри открытой транзакции
+func purchaseInTx(ctx context.Context, tx *sql.Tx, userID int, itemName string, price int, quantity int, excludeHoldID int) error {
+	// Проверяем баланс пользователя
+	userBalance, err := lockAvailableBalance(ctx, tx, userID, excludeHoldID)
+	if err != nil {
+		return err
+	}
+
+	if err := checkAccountActive(ctx, tx, userID); err != nil {
+		return err
+	}
+
+	totalPrice := price * quantity
+	if userBalance < totalPrice {
+		return ErrInsufficientFunds
+	}
+
+	// Обновляем баланс пользователя
+	if _, err := tx.ExecContext(ctx, "UPDATE users SET coins = coins - $1 WHERE id = $2", totalPrice, userID); err != nil {
+		return err
+	}
+
+	// Записываем покупку в таблицу purchases
+	_, err = tx.ExecContext(ctx, "INSERT INTO purchases (user_id, item, price, quantity) VALUES ($1, $2, $3, $4)", userID, itemName, price, quantity)
+	return err
+}
@@ -0,0 +1,182 @@
+package service
+
+import (
+	"avito-shop-service/internal/models"
+	"avito-shop-service/internal/repository"
+	"avito-shop-service/internal/tracing"
+	"context"
+	"errors"
+	"strings"
+	"time"
+)
+
+var (
+	ErrInvalidStatus      = errors.New("invalid account status")
+	ErrInvalidDisposition = errors.New("invalid balance disposition")
+
+	ErrBeneficiaryInactive = errors.New("beneficiary account is not active")
+)
+
+type AccountService struct {
+	accountRepo repository.AccountRepository
+	userRepo    repository.UserRepositoryInterface
+	walletRepo  repository.WalletRepository
+	holdRepo    repository.HoldRepository
+	userCache   *UserStateCache
+}
+
+func NewAccountService(
+	accountRepo repository.AccountRepository,
+	userRepo repository.UserRepositoryInterface,
+	walletRepo repository.WalletRepository,
+	holdRepo repository.HoldRepository,
+	userCache *UserStateCache,
+) *AccountService {
+	return &AccountService{accountRepo: accountRepo, userRepo: userRepo, walletRepo: walletRepo, holdRepo: holdRepo, userCache: userCache}
+}
+
+// SetStatus меняет состояние аккаунта с обязательным указанием причины.
+// Закрытие выполняется только через CloseAccount, так как требует распоряжения балансом.
+func (s *AccountService) SetStatus(ctx context.Context, adminID, userID int, status, reason string) (*models.StatusChange, error) {
+	ctx, span := tracing.Start(ctx, "AccountService.SetStatus")
+	defer span.End()
+
+	switch status {
+	case models.StatusActive, models.StatusFrozen, models.StatusSuspended:
+	default:
+		return nil, ErrInvalidStatus
+	}
+
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return nil, ErrMissingReason
+	}
+
+	change, err := s.accountRepo.SetStatus(ctx, userID, adminID, status, reason)
+	if err != nil {
+		return nil, err
+	}
+	// Заблокированный пользователь теряет доступ на этом экземпляре сразу
+	s.userCache.Forget(userID)
+	return change, nil
+}
+
+// GetStatusHistory возвращает журнал изменений состояния аккаунта
+func (s *AccountService) GetStatusHistory(ctx context.Context, userID int) ([]models.StatusChange, error) {
+	ctx, span := tracing.Start(ctx, "AccountService.GetStatusHistory")
+	defer span.End()
+
+	return s.accountRepo.GetStatusHistory(ctx, userID)
+}
+
+// CloseAccount закрывает аккаунт userID по инициативе actorID (сам пользователь или администратор).
+// Остаток баланса списывается в фонд или переводится пользователю beneficiaryID. Остаток
+// замороженного аккаунта переводится только по решению администратора (override).
+func (s *AccountService) CloseAccount(ctx context.Context, actorID, userID int, disposition string, beneficiaryID int, reason string, override bool) (*models.AccountClosure, error) {
+	ctx, span := tracing.Start(ctx, "AccountService.CloseAccount")
+	defer span.End()
+
+	switch disposition {
+	case models.DispositionForfeit:
+		beneficiaryID = 0
+	case models.DispositionTransfer:
+		if beneficiaryID == 0 || beneficiaryID == userID {
+			return nil, ErrInvalidDisposition
+		}
+	default:
+		return nil, ErrInvalidDisposition
+	}
+
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		if actorID != userID {
+			return nil, ErrMissingReason
+		}
+		reason = "closed by user"
+	}
+
+	// Пользователь не может снять заморозку со своего аккаунта
+	override = override && actorID != userID && disposition == models.DispositionTransfer
+
+	// Окончательная проверка выполняется в транзакции закрытия; здесь замороженный
+	// пользователь получает отказ без блокировок
+	if disposition == models.DispositionTransfer && !override {
+		user, err := s.userRepo.GetUserByID(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+		if user == nil {
+			return nil, repository.ErrUserNotFound
+		}
+		if user.Status == models.StatusFrozen {
+			return nil, repository.ErrBalanceTransferBlocked
+		}
+	}
+
+	closure := &models.AccountClosure{
+		UserID:        userID,
+		Disposition:   disposition,
+		BeneficiaryID: beneficiaryID,
+		Reason:        reason,
+		ClosedBy:      actorID,
+		Override:      override,
+	}
+	if err := s.accountRepo.CloseAccount(ctx, closure); err != nil {
+		// Закрываемый аккаунт проверяется отдельно (ErrAccountClosed), неактивным может оказаться только получатель остатка
+		if errors.Is(err, repository.ErrAccountInactive) {
+			return nil, ErrBeneficiaryInactive
+		}
+		return nil, err
+	}
+	s.userCache.Forget(userID)
+	return closure, nil
+}
+
+// Export собирает все данные пользователя в один архив
+func (s *AccountService) Export(ctx context.Context, userID int) (*models.UserExport, error) {
+	ctx, span := tracing.Start(ctx, "AccountService.Export")
+	defer span.End()
+
+	user, err := s.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, repository.ErrUserNotFound
+	}
+
+	export := &models.UserExport{ExportedAt: time.Now().UTC(), User: user, Balance: user.Coins}
+
+	if export.AvailableBalance, err = s.walletRepo.GetAvailableBalance(ctx, userID); err != nil {
+		return nil, err
+	}
+	if export.Purchases, err = s.accountRepo.GetPurchases(ctx, userID); err != nil {
+		return nil, err
+	}
+	if export.Transactions, err = s.walletRepo.GetTransactions(ctx, userID); err != nil {
+		return nil, err
+	}
+	if export.Adjustments, err = s.walletRepo.GetAdjustments(ctx, userID); err != nil {
+		return nil, err
+	}
+	if export.Holds, err = s.holdRepo.GetHolds(ctx, userID); err != nil {
+		return nil, err
+	}
+	if export.StatusHistory, err = s.accountRepo.GetStatusHistory(ctx, userID); err != nil {
+		return nil, err
+	}
+	if export.Identities, err = s.accountRepo.GetIdentities(ctx, userID); err != nil {
+		return nil, err
+	}
+	if export.Sessions, err = s.accountRepo.GetSessions(ctx, userID); err != nil {
+		return nil, err
+	}
+	if export.APITokens, err = s.accountRepo.GetAPITokens(ctx, userID); err != nil {
+		return nil, err
+	}
+	if export.FraudFlags, err = s.accountRepo.GetFraudFlags(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	return export, nil
+}
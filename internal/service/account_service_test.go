@@ -0,0 +1,215 @@
+package service
+
+import (
+	"avito-shop-service/internal/models"
+	"avito-shop-service/internal/repository"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// Mock AccountRepository
+type MockAccountRepository struct {
+	mock.Mock
+}
+
+func (m *MockAccountRepository) SetStatus(ctx context.Context, userID, adminID int, status, reason string) (*models.StatusChange, error) {
+	args := m.Called(userID, adminID, status, reason)
+	change := args.Get(0)
+	if change == nil {
+		return nil, args.Error(1)
+	}
+	return change.(*models.StatusChange), args.Error(1)
+}
+
+func (m *MockAccountRepository) GetStatusHistory(ctx context.Context, userID int) ([]models.StatusChange, error) {
+	args := m.Called(userID)
+	return args.Get(0).([]models.StatusChange), args.Error(1)
+}
+
+func (m *MockAccountRepository) CloseAccount(ctx context.Context, closure *models.AccountClosure) error {
+	args := m.Called(closure)
+	return args.Error(0)
+}
+
+func (m *MockAccountRepository) GetPurchases(ctx context.Context, userID int) ([]models.Purchase, error) {
+	args := m.Called(userID)
+	return args.Get(0).([]models.Purchase), args.Error(1)
+}
+
+func (m *MockAccountRepository) GetIdentities(ctx context.Context, userID int) ([]models.Identity, error) {
+	args := m.Called(userID)
+	return args.Get(0).([]models.Identity), args.Error(1)
+}
+
+func (m *MockAccountRepository) GetSessions(ctx context.Context, userID int) ([]models.Session, error) {
+	args := m.Called(userID)
+	return args.Get(0).([]models.Session), args.Error(1)
+}
+
+func (m *MockAccountRepository) GetAPITokens(ctx context.Context, userID int) ([]models.APIToken, error) {
+	args := m.Called(userID)
+	return args.Get(0).([]models.APIToken), args.Error(1)
+}
+
+func (m *MockAccountRepository) GetFraudFlags(ctx context.Context, userID int) ([]models.FraudFlag, error) {
+	args := m.Called(userID)
+	return args.Get(0).([]models.FraudFlag), args.Error(1)
+}
+
+func newTestAccountService(accountRepo *MockAccountRepository) *AccountService {
+	return NewAccountService(accountRepo, new(MockUserRepository), new(MockWalletRepository), new(MockHoldRepository), NewUserStateCache(time.Minute))
+}
+
+func TestSetStatus(t *testing.T) {
+	mockRepo := new(MockAccountRepository)
+	service := newTestAccountService(mockRepo)
+
+	change := &models.StatusChange{UserID: 2, OldStatus: models.StatusActive, NewStatus: models.StatusSuspended, Reason: "left the company"}
+	mockRepo.On("SetStatus", 2, 1, models.StatusSuspended, "left the company").Return(change, nil)
+
+	result, err := service.SetStatus(context.Background(), 1, 2, models.StatusSuspended, "left the company")
+
+	assert.NoError(t, err)
+	assert.Equal(t, change, result)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestSetStatusValidation(t *testing.T) {
+	service := newTestAccountService(new(MockAccountRepository))
+
+	_, err := service.SetStatus(context.Background(), 1, 2, "deleted", "cleanup")
+	assert.ErrorIs(t, err, ErrInvalidStatus)
+
+	_, err = service.SetStatus(context.Background(), 1, 2, models.StatusClosed, "cleanup")
+	assert.ErrorIs(t, err, ErrInvalidStatus)
+
+	_, err = service.SetStatus(context.Background(), 1, 2, models.StatusFrozen, "")
+	assert.ErrorIs(t, err, ErrMissingReason)
+}
+
+// Пользователь закрывает свой аккаунт, переводя остаток коллеге
+func TestCloseAccountTransfer(t *testing.T) {
+	mockRepo := new(MockAccountRepository)
+	mockUsers := new(MockUserRepository)
+	service := NewAccountService(mockRepo, mockUsers, new(MockWalletRepository), new(MockHoldRepository), NewUserStateCache(time.Minute))
+
+	mockUsers.On("GetUserByID", 2).Return(&models.User{ID: 2, Status: models.StatusActive}, nil)
+	mockRepo.On("CloseAccount", mock.MatchedBy(func(c *models.AccountClosure) bool {
+		return c.UserID == 2 && c.ClosedBy == 2 && c.BeneficiaryID == 3 && c.Reason == "closed by user"
+	})).Return(nil)
+
+	closure, err := service.CloseAccount(context.Background(), 2, 2, models.DispositionTransfer, 3, "", false)
+
+	assert.NoError(t, err)
+	assert.Equal(t, models.DispositionTransfer, closure.Disposition)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestCloseAccountValidation(t *testing.T) {
+	service := newTestAccountService(new(MockAccountRepository))
+
+	_, err := service.CloseAccount(context.Background(), 2, 2, models.DispositionTransfer, 2, "", false)
+	assert.ErrorIs(t, err, ErrInvalidDisposition)
+
+	_, err = service.CloseAccount(context.Background(), 2, 2, "donate", 0, "", false)
+	assert.ErrorIs(t, err, ErrInvalidDisposition)
+
+	// Администратор обязан указать причину
+	_, err = service.CloseAccount(context.Background(), 1, 2, models.DispositionForfeit, 0, "", false)
+	assert.ErrorIs(t, err, ErrMissingReason)
+}
+
+// Замороженный пользователь не может вывести баланс, закрыв аккаунт с переводом остатка
+func TestCloseFrozenAccountTransfer(t *testing.T) {
+	mockRepo := new(MockAccountRepository)
+	mockUsers := new(MockUserRepository)
+	service := NewAccountService(mockRepo, mockUsers, new(MockWalletRepository), new(MockHoldRepository), NewUserStateCache(time.Minute))
+
+	mockUsers.On("GetUserByID", 2).Return(&models.User{ID: 2, Status: models.StatusFrozen}, nil)
+
+	// Флаг override самого пользователя не учитывается
+	_, err := service.CloseAccount(context.Background(), 2, 2, models.DispositionTransfer, 3, "", true)
+	assert.ErrorIs(t, err, repository.ErrBalanceTransferBlocked)
+	mockRepo.AssertNotCalled(t, "CloseAccount", mock.Anything)
+
+	// Остаток можно списать в фонд
+	mockRepo.On("CloseAccount", mock.MatchedBy(func(c *models.AccountClosure) bool {
+		return c.Disposition == models.DispositionForfeit && !c.Override
+	})).Return(nil).Once()
+	_, err = service.CloseAccount(context.Background(), 2, 2, models.DispositionForfeit, 0, "", false)
+	assert.NoError(t, err)
+}
+
+// Блокировка исходящих переводов проверяется в транзакции закрытия
+func TestCloseAccountTransfersFrozen(t *testing.T) {
+	mockRepo := new(MockAccountRepository)
+	mockUsers := new(MockUserRepository)
+	service := NewAccountService(mockRepo, mockUsers, new(MockWalletRepository), new(MockHoldRepository), NewUserStateCache(time.Minute))
+
+	mockUsers.On("GetUserByID", 2).Return(&models.User{ID: 2, Status: models.StatusActive}, nil)
+	mockRepo.On("CloseAccount", mock.Anything).Return(repository.ErrBalanceTransferBlocked)
+
+	_, err := service.CloseAccount(context.Background(), 2, 2, models.DispositionTransfer, 3, "", false)
+	assert.ErrorIs(t, err, repository.ErrBalanceTransferBlocked)
+
+	domainErr, ok := ClassifyError(err)
+	assert.True(t, ok)
+	assert.Equal(t, KindForbidden, domainErr.Kind)
+}
+
+// Администратор может перевести остаток замороженного аккаунта явным решением
+func TestCloseFrozenAccountAdminOverride(t *testing.T) {
+	mockRepo := new(MockAccountRepository)
+	mockUsers := new(MockUserRepository)
+	service := NewAccountService(mockRepo, mockUsers, new(MockWalletRepository), new(MockHoldRepository), NewUserStateCache(time.Minute))
+
+	mockRepo.On("CloseAccount", mock.MatchedBy(func(c *models.AccountClosure) bool {
+		return c.UserID == 2 && c.ClosedBy == 1 && c.Override
+	})).Return(nil)
+
+	closure, err := service.CloseAccount(context.Background(), 1, 2, models.DispositionTransfer, 3, "refund to employer", true)
+
+	assert.NoError(t, err)
+	assert.True(t, closure.Override)
+	mockUsers.AssertNotCalled(t, "GetUserByID", 2)
+	mockRepo.AssertExpectations(t)
+}
+
+// Выгрузка собирает данные из всех репозиториев
+func TestExport(t *testing.T) {
+	mockAccounts := new(MockAccountRepository)
+	mockUsers := new(MockUserRepository)
+	mockWallet := new(MockWalletRepository)
+	mockHolds := new(MockHoldRepository)
+	service := NewAccountService(mockAccounts, mockUsers, mockWallet, mockHolds, NewUserStateCache(time.Minute))
+
+	user := &models.User{ID: 1, Username: "testuser", Coins: 700, Status: models.StatusActive}
+	mockUsers.On("GetUserByID", 1).Return(user, nil)
+	mockWallet.On("GetAvailableBalance", 1).Return(600, nil)
+	mockAccounts.On("GetPurchases", 1).Return([]models.Purchase{{Item: "cup", Price: 20, Quantity: 1}}, nil)
+	mockWallet.On("GetTransactions", 1).Return([]models.Transaction{{FromUserID: 1, ToUserID: 2, Amount: 280}}, nil)
+	mockWallet.On("GetAdjustments", 1).Return([]models.Adjustment{}, nil)
+	mockHolds.On("GetHolds", 1).Return([]models.Hold{{ID: 3, Amount: 100}}, nil)
+	mockAccounts.On("GetStatusHistory", 1).Return([]models.StatusChange{}, nil)
+	mockAccounts.On("GetIdentities", 1).Return([]models.Identity{{Issuer: "https://sso.example.com", Email: "user@example.com"}}, nil)
+	mockAccounts.On("GetSessions", 1).Return([]models.Session{{ID: "s1", IP: "10.0.0.1", UserAgent: "curl/8.0"}}, nil)
+	mockAccounts.On("GetAPITokens", 1).Return([]models.APIToken{{ID: 5, Name: "bot"}}, nil)
+	mockAccounts.On("GetFraudFlags", 1).Return([]models.FraudFlag{}, nil)
+
+	export, err := service.Export(context.Background(), 1)
+
+	assert.NoError(t, err)
+	assert.Equal(t, user, export.User)
+	assert.Equal(t, 700, export.Balance)
+	assert.Equal(t, 600, export.AvailableBalance)
+	assert.Len(t, export.Purchases, 1)
+	assert.Len(t, export.Holds, 1)
+	assert.Equal(t, "user@example.com", export.Identities[0].Email)
+	assert.Equal(t, "10.0.0.1", export.Sessions[0].IP)
+	assert.Len(t, export.APITokens, 1)
+	mockAccounts.AssertExpectations(t)
+}
@@ -0,0 +1,116 @@
+package service
+
+import (
+	"avito-shop-service/internal/models"
+	"avito-shop-service/internal/repository"
+	"avito-shop-service/internal/tracing"
+	"context"
+	"errors"
+	"strings"
+)
+
+// Максимальное количество получателей в одном массовом начислении
+const MaxBulkGrantSize = 1000
+
+var (
+	ErrInvalidAdjustment  = errors.New("invalid adjustment amount")
+	ErrMissingReason      = errors.New("reason and reference are required")
+	ErrInvalidBulkRequest = errors.New("invalid bulk grant recipients")
+)
+
+type AdminService struct {
+	adjustmentRepo repository.AdjustmentRepository
+	reversalRepo   repository.ReversalRepository
+}
+
+func NewAdminService(adjustmentRepo repository.AdjustmentRepository, reversalRepo repository.ReversalRepository) *AdminService {
+	return &AdminService{adjustmentRepo: adjustmentRepo, reversalRepo: reversalRepo}
+}
+
+// AdjustCoins начисляет (amount > 0) или списывает (amount < 0) монеты пользователю
+func (s *AdminService) AdjustCoins(ctx context.Context, adminID, userID, amount int, reason, reference string) (*models.Adjustment, error) {
+	ctx, span := tracing.Start(ctx, "AdminService.AdjustCoins")
+	defer span.End()
+
+	if amount == 0 {
+		return nil, ErrInvalidAdjustment
+	}
+
+	reason, reference = strings.TrimSpace(reason), strings.TrimSpace(reference)
+	if reason == "" || reference == "" {
+		return nil, ErrMissingReason
+	}
+
+	created, err := s.adjustmentRepo.CreateAdjustments(ctx, []models.Adjustment{{
+		UserID:    userID,
+		AdminID:   adminID,
+		Amount:    amount,
+		Reason:    reason,
+		Reference: reference,
+	}})
+	if err != nil {
+		return nil, err
+	}
+	return &created[0], nil
+}
+
+// BulkGrant начисляет одинаковую сумму нескольким пользователям в одной транзакции.
+// Повторный запуск с тем же reference отклоняется, поэтому премию нельзя начислить дважды.
+func (s *AdminService) BulkGrant(ctx context.Context, adminID int, userIDs []int, amount int, reason, reference string) ([]models.Adjustment, error) {
+	ctx, span := tracing.Start(ctx, "AdminService.BulkGrant")
+	defer span.End()
+
+	if amount <= 0 {
+		return nil, ErrInvalidAdjustment
+	}
+
+	reason, reference = strings.TrimSpace(reason), strings.TrimSpace(reference)
+	if reason == "" || reference == "" {
+		return nil, ErrMissingReason
+	}
+
+	if len(userIDs) == 0 || len(userIDs) > MaxBulkGrantSize {
+		return nil, ErrInvalidBulkRequest
+	}
+
+	seen := make(map[int]bool, len(userIDs))
+	adjustments := make([]models.Adjustment, 0, len(userIDs))
+	for _, userID := range userIDs {
+		if seen[userID] {
+			continue
+		}
+		seen[userID] = true
+
+		adjustments = append(adjustments, models.Adjustment{
+			UserID:    userID,
+			AdminID:   adminID,
+			Amount:    amount,
+			Reason:    reason,
+			Reference: reference,
+		})
+	}
+
+	return s.adjustmentRepo.CreateAdjustments(ctx, adjustments)
+}
+
+// ReverseTransfer отменяет перевод компенсирующей транзакцией. Если политика не указана,
+// отмена выполняется только на полную сумму.
+func (s *AdminService) ReverseTransfer(ctx context.Context, adminID, transactionID int, policy, reason string) (*models.Transaction, error) {
+	ctx, span := tracing.Start(ctx, "AdminService.ReverseTransfer")
+	defer span.End()
+
+	switch policy {
+	case "":
+		policy = models.ReversalPolicyStrict
+	case models.ReversalPolicyStrict, models.ReversalPolicyPartial, models.ReversalPolicyAllowNegative:
+	default:
+		return nil, repository.ErrInvalidReversalPolicy
+	}
+
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return nil, ErrMissingReason
+	}
+
+	return s.reversalRepo.ReverseTransfer(ctx, transactionID, adminID, policy, reason)
+}
@@ -0,0 +1,144 @@
+package service
+
+import (
+	"avito-shop-service/internal/models"
+	"avito-shop-service/internal/repository"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// Mock AdjustmentRepository
+type MockAdjustmentRepository struct {
+	mock.Mock
+}
+
+func (m *MockAdjustmentRepository) CreateAdjustments(ctx context.Context, adjustments []models.Adjustment) ([]models.Adjustment, error) {
+	args := m.Called(adjustments)
+	created := args.Get(0)
+	if created == nil {
+		return nil, args.Error(1)
+	}
+	return created.([]models.Adjustment), args.Error(1)
+}
+
+// Mock ReversalRepository
+type MockReversalRepository struct {
+	mock.Mock
+}
+
+func (m *MockReversalRepository) ReverseTransfer(ctx context.Context, transactionID, adminID int, policy, reason string) (*models.Transaction, error) {
+	args := m.Called(transactionID, adminID, policy, reason)
+	reversal := args.Get(0)
+	if reversal == nil {
+		return nil, args.Error(1)
+	}
+	return reversal.(*models.Transaction), args.Error(1)
+}
+
+// Списание монет администратором
+func TestAdjustCoinsClawback(t *testing.T) {
+	mockRepo := new(MockAdjustmentRepository)
+	service := NewAdminService(mockRepo, new(MockReversalRepository))
+
+	expected := []models.Adjustment{{UserID: 2, AdminID: 1, Amount: -100, Reason: "duplicate bonus", Reference: "INC-42"}}
+	mockRepo.On("CreateAdjustments", expected).Return(expected, nil)
+
+	adjustment, err := service.AdjustCoins(context.Background(), 1, 2, -100, " duplicate bonus ", "INC-42")
+
+	assert.NoError(t, err)
+	assert.Equal(t, -100, adjustment.Amount)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestAdjustCoinsRequiresReason(t *testing.T) {
+	service := NewAdminService(new(MockAdjustmentRepository), new(MockReversalRepository))
+
+	_, err := service.AdjustCoins(context.Background(), 1, 2, 100, "", "INC-42")
+	assert.ErrorIs(t, err, ErrMissingReason)
+
+	_, err = service.AdjustCoins(context.Background(), 1, 2, 0, "bonus", "INC-42")
+	assert.ErrorIs(t, err, ErrInvalidAdjustment)
+}
+
+// Массовое начисление пропускает повторяющихся получателей
+func TestBulkGrantDeduplicatesRecipients(t *testing.T) {
+	mockRepo := new(MockAdjustmentRepository)
+	service := NewAdminService(mockRepo, new(MockReversalRepository))
+
+	mockRepo.On("CreateAdjustments", mock.MatchedBy(func(adjustments []models.Adjustment) bool {
+		return len(adjustments) == 2 && adjustments[0].UserID == 2 && adjustments[1].UserID == 3
+	})).Return([]models.Adjustment{{UserID: 2}, {UserID: 3}}, nil)
+
+	created, err := service.BulkGrant(context.Background(), 1, []int{2, 3, 2}, 500, "Q3 bonus", "bonus-2026-q3")
+
+	assert.NoError(t, err)
+	assert.Len(t, created, 2)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestBulkGrantRejectsDeduction(t *testing.T) {
+	service := NewAdminService(new(MockAdjustmentRepository), new(MockReversalRepository))
+
+	_, err := service.BulkGrant(context.Background(), 1, []int{2}, -500, "Q3 bonus", "bonus-2026-q3")
+
+	assert.ErrorIs(t, err, ErrInvalidAdjustment)
+}
+
+// Отмена перевода по умолчанию выполняется со строгой политикой
+func TestReverseTransferDefaultPolicy(t *testing.T) {
+	mockReversals := new(MockReversalRepository)
+	service := NewAdminService(new(MockAdjustmentRepository), mockReversals)
+
+	originalID := 10
+	reversal := &models.Transaction{ID: 11, FromUserID: 3, ToUserID: 2, Amount: 500, ReversalOf: &originalID}
+	mockReversals.On("ReverseTransfer", 10, 1, models.ReversalPolicyStrict, "wrong recipient").Return(reversal, nil)
+
+	result, err := service.ReverseTransfer(context.Background(), 1, 10, "", "wrong recipient")
+
+	assert.NoError(t, err)
+	assert.Equal(t, reversal, result)
+	mockReversals.AssertExpectations(t)
+}
+
+func TestReverseTransferValidation(t *testing.T) {
+	service := NewAdminService(new(MockAdjustmentRepository), new(MockReversalRepository))
+
+	_, err := service.ReverseTransfer(context.Background(), 1, 10, "forgive", "wrong recipient")
+	assert.ErrorIs(t, err, repository.ErrInvalidReversalPolicy)
+
+	_, err = service.ReverseTransfer(context.Background(), 1, 10, models.ReversalPolicyPartial, " ")
+	assert.ErrorIs(t, err, ErrMissingReason)
+}
+
+// Начисление на закрытый аккаунт отклоняется: его баланс уже учтен при закрытии
+func TestAdjustCoinsClosedAccount(t *testing.T) {
+	mockRepo := new(MockAdjustmentRepository)
+	service := NewAdminService(mockRepo, new(MockReversalRepository))
+
+	mockRepo.On("CreateAdjustments", mock.Anything).Return(nil, repository.ErrAccountClosed)
+
+	_, err := service.AdjustCoins(context.Background(), 1, 2, 100, "bonus", "INC-43")
+	assert.ErrorIs(t, err, repository.ErrAccountClosed)
+
+	_, err = service.BulkGrant(context.Background(), 1, []int{2, 3}, 100, "Q3 bonus", "bonus-2026-q3")
+	assert.ErrorIs(t, err, repository.ErrAccountClosed)
+
+	domainErr, _ := ClassifyError(err)
+	assert.Equal(t, KindConflict, domainErr.Kind)
+}
+
+// Отмена перевода, затрагивающего закрытый аккаунт, отклоняется
+func TestReverseTransferClosedAccount(t *testing.T) {
+	mockReversals := new(MockReversalRepository)
+	service := NewAdminService(new(MockAdjustmentRepository), mockReversals)
+
+	mockReversals.On("ReverseTransfer", 10, 1, models.ReversalPolicyAllowNegative, "wrong recipient").
+		Return(nil, repository.ErrAccountClosed)
+
+	_, err := service.ReverseTransfer(context.Background(), 1, 10, models.ReversalPolicyAllowNegative, "wrong recipient")
+
+	assert.ErrorIs(t, err, repository.ErrAccountClosed)
+}
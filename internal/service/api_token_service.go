@@ -0,0 +1,158 @@
+package service
+
+import (
+	"avito-shop-service/internal/models"
+	"avito-shop-service/internal/repository"
+	"avito-shop-service/internal/tracing"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"log/slog"
+	"strings"
+	"time"
+)
+
+const (
+	// Префикс отличает персональные токены от JWT и упрощает поиск утекших токенов
+	APITokenPrefix = "shop_pat_"
+
+	MaxAPITokenTTL     = 365 * 24 * time.Hour
+	maxAPITokenNameLen = 100
+)
+
+var (
+	ErrInvalidScope     = errors.New("unknown or empty token scopes")
+	ErrInvalidTokenName = errors.New("token name must be 1-100 characters")
+	ErrInvalidTokenTTL  = errors.New("token lifetime must be positive and at most 365 days")
+	ErrAPITokenInvalid  = errors.New("api token is invalid, expired or revoked")
+)
+
+type APITokenService struct {
+	tokenRepo repository.APITokenRepository
+}
+
+func NewAPITokenService(tokenRepo repository.APITokenRepository) *APITokenService {
+	return &APITokenService{tokenRepo: tokenRepo}
+}
+
+// CreateToken выпускает персональный токен. Значение токена возвращается только один раз.
+// Нулевой ttl - токен без срока действия.
+func (s *APITokenService) CreateToken(ctx context.Context, userID int, name string, scopes []string, ttl time.Duration) (*models.APIToken, string, error) {
+	ctx, span := tracing.Start(ctx, "APITokenService.CreateToken")
+	defer span.End()
+
+	name = strings.TrimSpace(name)
+	if name == "" || len(name) > maxAPITokenNameLen {
+		return nil, "", ErrInvalidTokenName
+	}
+	if ttl < 0 || ttl > MaxAPITokenTTL {
+		return nil, "", ErrInvalidTokenTTL
+	}
+
+	scopes, err := normalizeScopes(scopes)
+	if err != nil {
+		return nil, "", err
+	}
+
+	secret, err := randomToken(32)
+	if err != nil {
+		return nil, "", err
+	}
+	raw := APITokenPrefix + secret
+
+	token := &models.APIToken{
+		UserID: userID,
+		Name:   name,
+		Prefix: raw[:len(APITokenPrefix)+6],
+		Scopes: scopes,
+	}
+	if ttl > 0 {
+		expiresAt := time.Now().Add(ttl)
+		token.ExpiresAt = &expiresAt
+	}
+
+	if err := s.tokenRepo.CreateToken(ctx, token, hashAPIToken(raw)); err != nil {
+		return nil, "", err
+	}
+	return token, raw, nil
+}
+
+// GetTokens возвращает токены пользователя
+func (s *APITokenService) GetTokens(ctx context.Context, userID int) ([]models.APIToken, error) {
+	ctx, span := tracing.Start(ctx, "APITokenService.GetTokens")
+	defer span.End()
+
+	return s.tokenRepo.GetTokens(ctx, userID)
+}
+
+// RevokeToken отзывает токен пользователя
+func (s *APITokenService) RevokeToken(ctx context.Context, userID, tokenID int) error {
+	ctx, span := tracing.Start(ctx, "APITokenService.RevokeToken")
+	defer span.End()
+
+	return s.tokenRepo.RevokeToken(ctx, tokenID, userID)
+}
+
+// Authenticate проверяет персональный токен и отмечает его использование
+func (s *APITokenService) Authenticate(ctx context.Context, raw string) (*models.APIToken, error) {
+	ctx, span := tracing.Start(ctx, "APITokenService.Authenticate")
+	defer span.End()
+
+	if !IsAPIToken(raw) {
+		return nil, ErrAPITokenInvalid
+	}
+
+	token, err := s.tokenRepo.GetTokenByHash(ctx, hashAPIToken(raw))
+	if err != nil {
+		return nil, err
+	}
+	if token == nil || token.RevokedAt != nil || (token.ExpiresAt != nil && !token.ExpiresAt.After(time.Now())) {
+		return nil, ErrAPITokenInvalid
+	}
+
+	// Ошибка записи времени использования не должна отклонять запрос
+	if err := s.tokenRepo.TouchToken(ctx, token.ID); err != nil {
+		slog.WarnContext(ctx, "failed to update last use of api token", slog.Int("token_id", token.ID), slog.Any("error", err))
+	}
+	return token, nil
+}
+
+// IsAPIToken сообщает, что строка является персональным токеном, а не JWT
+func IsAPIToken(raw string) bool {
+	return strings.HasPrefix(raw, APITokenPrefix)
+}
+
+// Проверяет области доступа, убирая повторы
+func normalizeScopes(scopes []string) ([]string, error) {
+	if len(scopes) == 0 {
+		return nil, ErrInvalidScope
+	}
+
+	seen := make(map[string]bool, len(scopes))
+	result := make([]string, 0, len(scopes))
+	for _, scope := range scopes {
+		if !isKnownScope(scope) {
+			return nil, ErrInvalidScope
+		}
+		if !seen[scope] {
+			seen[scope] = true
+			result = append(result, scope)
+		}
+	}
+	return result, nil
+}
+
+func isKnownScope(scope string) bool {
+	for _, known := range models.APITokenScopes {
+		if scope == known {
+			return true
+		}
+	}
+	return false
+}
+
+func hashAPIToken(raw string) string {
+	sum := sha256.Sum256([]byte(raw))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,109 @@
+package service
+
+import (
+	"avito-shop-service/internal/models"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// Mock APITokenRepository
+type MockAPITokenRepository struct {
+	mock.Mock
+}
+
+func (m *MockAPITokenRepository) CreateToken(ctx context.Context, token *models.APIToken, tokenHash string) error {
+	args := m.Called(token, tokenHash)
+	return args.Error(0)
+}
+
+func (m *MockAPITokenRepository) GetTokens(ctx context.Context, userID int) ([]models.APIToken, error) {
+	args := m.Called(userID)
+	return args.Get(0).([]models.APIToken), args.Error(1)
+}
+
+func (m *MockAPITokenRepository) GetTokenByHash(ctx context.Context, tokenHash string) (*models.APIToken, error) {
+	args := m.Called(tokenHash)
+	token := args.Get(0)
+	if token == nil {
+		return nil, args.Error(1)
+	}
+	return token.(*models.APIToken), args.Error(1)
+}
+
+func (m *MockAPITokenRepository) RevokeToken(ctx context.Context, tokenID, userID int) error {
+	args := m.Called(tokenID, userID)
+	return args.Error(0)
+}
+
+func (m *MockAPITokenRepository) TouchToken(ctx context.Context, tokenID int) error {
+	args := m.Called(tokenID)
+	return args.Error(0)
+}
+
+// В базу сохраняется хеш, по которому затем находится выданный токен
+func TestCreateAndAuthenticateAPIToken(t *testing.T) {
+	mockRepo := new(MockAPITokenRepository)
+	service := NewAPITokenService(mockRepo)
+
+	var storedHash string
+	mockRepo.On("CreateToken", mock.AnythingOfType("*models.APIToken"), mock.AnythingOfType("string")).
+		Run(func(args mock.Arguments) { storedHash = args.String(1) }).
+		Return(nil)
+
+	token, raw, err := service.CreateToken(context.Background(), 1, " slack bot ", []string{models.ScopeCoinsSend, models.ScopeCoinsSend}, 30*24*time.Hour)
+
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(raw, APITokenPrefix))
+	assert.True(t, strings.HasPrefix(raw, token.Prefix))
+	assert.Equal(t, "slack bot", token.Name)
+	assert.Equal(t, []string{models.ScopeCoinsSend}, token.Scopes)
+	assert.NotContains(t, storedHash, raw)
+
+	mockRepo.On("GetTokenByHash", storedHash).Return(&models.APIToken{ID: 5, UserID: 1, Scopes: token.Scopes, ExpiresAt: token.ExpiresAt}, nil)
+	mockRepo.On("TouchToken", 5).Return(nil)
+
+	authenticated, err := service.Authenticate(context.Background(), raw)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, authenticated.UserID)
+	assert.True(t, authenticated.HasScope(models.ScopeCoinsSend))
+	assert.False(t, authenticated.HasScope(models.ScopeShopBuy))
+	mockRepo.AssertExpectations(t)
+}
+
+func TestAuthenticateRevokedOrExpiredAPIToken(t *testing.T) {
+	mockRepo := new(MockAPITokenRepository)
+	service := NewAPITokenService(mockRepo)
+
+	past := time.Now().Add(-time.Minute)
+	mockRepo.On("GetTokenByHash", hashAPIToken(APITokenPrefix+"revoked")).Return(&models.APIToken{ID: 1, RevokedAt: &past}, nil)
+	mockRepo.On("GetTokenByHash", hashAPIToken(APITokenPrefix+"expired")).Return(&models.APIToken{ID: 2, ExpiresAt: &past}, nil)
+	mockRepo.On("GetTokenByHash", hashAPIToken(APITokenPrefix+"unknown")).Return(nil, nil)
+
+	for _, raw := range []string{"revoked", "expired", "unknown"} {
+		_, err := service.Authenticate(context.Background(), APITokenPrefix+raw)
+		assert.ErrorIs(t, err, ErrAPITokenInvalid, raw)
+	}
+	mockRepo.AssertNotCalled(t, "TouchToken", mock.Anything)
+}
+
+func TestCreateAPITokenValidation(t *testing.T) {
+	service := NewAPITokenService(new(MockAPITokenRepository))
+
+	_, _, err := service.CreateToken(context.Background(), 1, "bot", nil, 0)
+	assert.ErrorIs(t, err, ErrInvalidScope)
+
+	_, _, err = service.CreateToken(context.Background(), 1, "bot", []string{"coins:steal"}, 0)
+	assert.ErrorIs(t, err, ErrInvalidScope)
+
+	_, _, err = service.CreateToken(context.Background(), 1, "  ", []string{models.ScopeInfoRead}, 0)
+	assert.ErrorIs(t, err, ErrInvalidTokenName)
+
+	_, _, err = service.CreateToken(context.Background(), 1, "bot", []string{models.ScopeInfoRead}, 2*MaxAPITokenTTL)
+	assert.ErrorIs(t, err, ErrInvalidTokenTTL)
+}
@@ -1,28 +1,92 @@
 package service
 
 import (
+	"avito-shop-service/internal/metrics"
 	"avito-shop-service/internal/models"
 	"avito-shop-service/internal/repository"
+	"avito-shop-service/internal/tracing"
+	"context"
 	"errors"
+	"fmt"
+	"log/slog"
 	"time"
 
 	"github.com/golang-jwt/jwt"
-	"golang.org/x/crypto/bcrypt"
 )
 
+var (
+	ErrAccountDisabled = errors.New("account is disabled")
+	ErrInvalidPassword = errors.New("invalid password")
+	// Неверный пароль при входе: ответ не должен раскрывать, что пользователь существует
+	ErrInvalidCredentials = errors.New("invalid username or password")
+	ErrTokenRevoked       = errors.New("token has been revoked")
+	ErrInvalidToken       = errors.New("invalid token")
+)
+
+const (
+	// Начальный баланс нового пользователя
+	initialCoins = 1000
+
+	accessTokenTTL    = 72 * time.Hour
+	challengeTokenTTL = 5 * time.Minute
+
+	// Назначение токена подтверждения второго фактора; токены доступа этого поля не содержат
+	tokenTypeChallenge = "2fa"
+)
+
+// SessionStore создает и проверяет сессии, к которым привязаны токены доступа
+type SessionStore interface {
+	Create(ctx context.Context, userID int, client models.ClientInfo, expiresAt time.Time) (string, error)
+	Validate(ctx context.Context, sessionID string, userID int) error
+}
+
+// TwoFactorVerifier проверяет второй фактор при входе
+type TwoFactorVerifier interface {
+	IsEnabled(ctx context.Context, userID int) (bool, error)
+	Verify(ctx context.Context, userID int, code string) error
+}
+
 type AuthService struct {
-	userRepo  repository.UserRepositoryInterface
-	secretKey string
+	userRepo       repository.UserRepositoryInterface
+	secretKey      string
+	passwordPolicy *PasswordPolicy
+	hasher         PasswordHasher
+	twoFactor      TwoFactorVerifier
+	sessions       SessionStore
+	userCache      *UserStateCache
 }
 
-func NewAuthService(userRepo repository.UserRepositoryInterface, secretKey string) *AuthService {
-	return &AuthService{userRepo: userRepo, secretKey: secretKey}
+func NewAuthService(
+	userRepo repository.UserRepositoryInterface,
+	secretKey string,
+	passwordPolicy *PasswordPolicy,
+	hasher PasswordHasher,
+	twoFactor TwoFactorVerifier,
+	sessions SessionStore,
+	userCache *UserStateCache,
+) *AuthService {
+	return &AuthService{
+		userRepo:       userRepo,
+		secretKey:      secretKey,
+		passwordPolicy: passwordPolicy,
+		hasher:         hasher,
+		twoFactor:      twoFactor,
+		sessions:       sessions,
+		userCache:      userCache,
+	}
 }
 
 // Register создает нового пользователя с хешированным паролем
-func (s *AuthService) Register(username, password string) error {
+func (s *AuthService) Register(ctx context.Context, username, password string) error {
+	ctx, span := tracing.Start(ctx, "AuthService.Register")
+	defer span.End()
+
+	if err := s.passwordPolicy.Validate(password); err != nil {
+		return err
+	}
+
 	// Генерация хеша пароля
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	hashedPassword, err := s.hasher.Hash(password)
 	if err != nil {
 		return err
 	}
@@ -30,58 +94,282 @@ func (s *AuthService) Register(username, password string) error {
 	// Создание пользователя с хешированным паролем
 	user := &models.User{
 		Username:     username,
-		PasswordHash: string(hashedPassword),
-		Coins:        1000, // Начальный баланс
+		PasswordHash: hashedPassword,
+		Coins:        initialCoins,
 	}
 
 	// Сохраняем пользователя в базе данных
-	return s.userRepo.CreateUser(user)
+	return s.userRepo.CreateUser(ctx, user)
 }
 
-// Login выполняет проверку пользователя и создает JWT-токен
-func (s *AuthService) Login(username, password string) (string, error) {
-	user, err := s.userRepo.GetUserByUsername(username)
+// Login выполняет проверку пользователя и создает JWT-токен.
+// Пользователь с двухфакторной аутентификацией получает токен подтверждения, который
+// обменивается на JWT-токен через CompleteTwoFactor.
+func (s *AuthService) Login(ctx context.Context, username, password string, client models.ClientInfo) (*models.LoginResult, error) {
+	ctx, span := tracing.Start(ctx, "AuthService.Login")
+	defer span.End()
+
+	user, err := s.userRepo.GetUserByUsername(ctx, username)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 	if user == nil {
-		return "", errors.New("user not found")
+		return nil, repository.ErrUserNotFound
 	}
 
 	// Сравниваем хеш пароля
-	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)); err != nil {
-		return "", errors.New("invalid password")
+	if err := checkPassword(s.hasher, user.PasswordHash, password); err != nil {
+		if errors.Is(err, ErrInvalidPassword) {
+			metrics.AuthFailures.WithLabelValues(metrics.AuthReasonInvalidPassword).Inc()
+		}
+		return nil, err
 	}
 
-	// Генерация JWT токена
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-		"user_id": user.ID,
-		"exp":     time.Now().Add(time.Hour * 72).Unix(),
-	})
+	// Заблокированные и закрытые аккаунты не могут войти
+	if !user.CanLogin() {
+		metrics.AuthFailures.WithLabelValues(metrics.AuthReasonAccountDisabled).Inc()
+		return nil, ErrAccountDisabled
+	}
+
+	// Хеш, полученный устаревшим алгоритмом или с устаревшей стоимостью, пересчитывается с текущими настройками
+	if s.hasher.NeedsRehash(user.PasswordHash) {
+		s.rehashPassword(ctx, user, password)
+	}
+
+	return s.LoginUser(ctx, user, client)
+}
+
+// LoginUser выдает токен пользователю, личность которого уже подтверждена (паролем или внешним провайдером)
+func (s *AuthService) LoginUser(ctx context.Context, user *models.User, client models.ClientInfo) (*models.LoginResult, error) {
+	ctx, span := tracing.Start(ctx, "AuthService.LoginUser")
+	defer span.End()
+
+	if !user.CanLogin() {
+		return nil, ErrAccountDisabled
+	}
+
+	enabled, err := s.twoFactor.IsEnabled(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	if enabled {
+		challenge, err := s.signToken(user, challengeTokenTTL, tokenTypeChallenge, "")
+		if err != nil {
+			return nil, err
+		}
+		return &models.LoginResult{ChallengeToken: challenge, TwoFactorRequired: true}, nil
+	}
+
+	token, err := s.issueAccessToken(ctx, user, client)
+	if err != nil {
+		return nil, err
+	}
+	return &models.LoginResult{Token: token}, nil
+}
+
+// CompleteTwoFactor проверяет код второго фактора и обменивает токен подтверждения на JWT-токен
+func (s *AuthService) CompleteTwoFactor(ctx context.Context, challengeToken, code string, client models.ClientInfo) (string, error) {
+	ctx, span := tracing.Start(ctx, "AuthService.CompleteTwoFactor")
+	defer span.End()
+
+	claims, err := s.parseClaims(challengeToken, tokenTypeChallenge)
+	if err != nil {
+		return "", err
+	}
+
+	user, err := s.activeUser(ctx, claims.userID)
+	if err != nil {
+		return "", err
+	}
+	if claims.tokenVersion != user.TokenVersion {
+		return "", ErrTokenRevoked
+	}
+
+	if err := s.twoFactor.Verify(ctx, user.ID, code); err != nil {
+		switch {
+		case errors.Is(err, ErrInvalidTOTPCode):
+			metrics.AuthFailures.WithLabelValues(metrics.AuthReasonTwoFactor).Inc()
+		case errors.Is(err, ErrTwoFactorLocked):
+			metrics.AuthFailures.WithLabelValues(metrics.AuthReasonTwoFactorLocked).Inc()
+		}
+		return "", err
+	}
+
+	return s.issueAccessToken(ctx, user, client)
+}
 
-	// Подписываем токен
-	tokenString, err := token.SignedString([]byte(s.secretKey))
+// Создает сессию и выдает привязанный к ней токен доступа
+func (s *AuthService) issueAccessToken(ctx context.Context, user *models.User, client models.ClientInfo) (string, error) {
+	sessionID, err := s.sessions.Create(ctx, user.ID, client, time.Now().Add(accessTokenTTL))
 	if err != nil {
 		return "", err
 	}
+	return s.signToken(user, accessTokenTTL, "", sessionID)
+}
+
+// Генерация и подпись JWT токена
+func (s *AuthService) signToken(user *models.User, ttl time.Duration, tokenType, sessionID string) (string, error) {
+	claims := jwt.MapClaims{
+		"user_id": user.ID,
+		"ver":     user.TokenVersion,
+		"exp":     time.Now().Add(ttl).Unix(),
+	}
+	if tokenType != "" {
+		claims["typ"] = tokenType
+	}
+	if sessionID != "" {
+		claims["sid"] = sessionID
+	}
 
-	return tokenString, nil
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(s.secretKey))
+}
+
+// ValidateUser проверяет, что пользователь существует и его аккаунт допускает работу с API
+func (s *AuthService) ValidateUser(ctx context.Context, userID int) error {
+	ctx, span := tracing.Start(ctx, "AuthService.ValidateUser")
+	defer span.End()
+
+	_, err := s.cachedActiveUser(ctx, userID)
+	return err
+}
+
+// Authenticate проверяет токен, его сессию и состояние аккаунта, возвращает user_id и идентификатор сессии.
+// Токены, выданные до последней смены пароля, и токены отозванных сессий отклоняются.
+func (s *AuthService) Authenticate(ctx context.Context, tokenStr string) (int, string, error) {
+	ctx, span := tracing.Start(ctx, "AuthService.Authenticate")
+	defer span.End()
+
+	claims, err := s.parseClaims(tokenStr, "")
+	if err != nil {
+		return 0, "", err
+	}
+
+	// Токены, выданные до введения сессий, не содержат sid и действуют до истечения срока
+	if claims.sessionID != "" {
+		if err := s.sessions.Validate(ctx, claims.sessionID, claims.userID); err != nil {
+			return 0, "", err
+		}
+	}
+
+	user, err := s.cachedActiveUser(ctx, claims.userID)
+	if err != nil {
+		return 0, "", err
+	}
+	if claims.tokenVersion != user.TokenVersion {
+		return 0, "", ErrTokenRevoked
+	}
+	return user.ID, claims.sessionID, nil
+}
+
+func (s *AuthService) activeUser(ctx context.Context, userID int) (*models.User, error) {
+	user, err := s.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil || !user.CanLogin() {
+		return nil, ErrAccountDisabled
+	}
+	return user, nil
+}
+
+// Проверка токена на каждом запросе: роль, состояние и версия токенов берутся из кеша
+func (s *AuthService) cachedActiveUser(ctx context.Context, userID int) (*models.User, error) {
+	user, err := s.userCache.get(ctx, userID, s.userRepo.GetUserByID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil || !user.CanLogin() {
+		return nil, ErrAccountDisabled
+	}
+	return user, nil
+}
+
+// VerifyPassword проверяет пароль пользователя, например перед необратимыми действиями
+func (s *AuthService) VerifyPassword(ctx context.Context, userID int, password string) error {
+	ctx, span := tracing.Start(ctx, "AuthService.VerifyPassword")
+	defer span.End()
+
+	user, err := s.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if user == nil {
+		return repository.ErrUserNotFound
+	}
+
+	return checkPassword(s.hasher, user.PasswordHash, password)
+}
+
+// Ошибка пересчета хеша не мешает входу: старый хеш остается рабочим
+func (s *AuthService) rehashPassword(ctx context.Context, user *models.User, password string) {
+	newHash, err := s.hasher.Hash(password)
+	if err == nil {
+		err = s.userRepo.RehashPassword(ctx, user.ID, user.PasswordHash, newHash)
+	}
+	if err != nil {
+		slog.WarnContext(ctx, "failed to rehash password", slog.Int("user_id", user.ID), slog.Any("error", err))
+	}
+}
+
+// IsAdmin проверяет, что пользователь имеет роль администратора
+func (s *AuthService) IsAdmin(ctx context.Context, userID int) (bool, error) {
+	ctx, span := tracing.Start(ctx, "AuthService.IsAdmin")
+	defer span.End()
+
+	user, err := s.userCache.get(ctx, userID, s.userRepo.GetUserByID)
+	if err != nil {
+		return false, err
+	}
+	return user != nil && user.Role == models.RoleAdmin, nil
 }
 
 // ParseToken парсит и проверяет токен, возвращает user_id
 func (s *AuthService) ParseToken(tokenStr string) (int, error) {
-	token, err := jwt.Parse(tokenStr, func(_ *jwt.Token) (interface{}, error) {
+	claims, err := s.parseClaims(tokenStr, "")
+	if err != nil {
+		return 0, err
+	}
+	return claims.userID, nil
+}
+
+type tokenClaims struct {
+	userID       int
+	tokenVersion int
+	sessionID    string
+}
+
+// Разбирает токен и проверяет его назначение: пустой tokenType - токен доступа
+func (s *AuthService) parseClaims(tokenStr, tokenType string) (*tokenClaims, error) {
+	token, err := jwt.Parse(tokenStr, func(t *jwt.Token) (interface{}, error) {
+		if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, errors.New("unexpected signing method")
+		}
 		return []byte(s.secretKey), nil
 	})
 
 	if err != nil {
-		return 0, err
+		return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok || !token.Valid {
+		return nil, ErrInvalidToken
 	}
 
-	if claims, ok := token.Claims.(jwt.MapClaims); ok && token.Valid {
-		userID := int(claims["user_id"].(float64))
-		return userID, nil
+	// Токен подтверждения не может использоваться как токен доступа и наоборот
+	typ, _ := claims["typ"].(string)
+	if typ != tokenType {
+		return nil, ErrInvalidToken
 	}
 
-	return 0, errors.New("invalid token")
+	userID, ok := claims["user_id"].(float64)
+	if !ok {
+		return nil, ErrInvalidToken
+	}
+
+	// Токены, выданные до введения версий, имеют версию 0
+	version, _ := claims["ver"].(float64)
+	sessionID, _ := claims["sid"].(string)
+
+	return &tokenClaims{userID: int(userID), tokenVersion: int(version), sessionID: sessionID}, nil
 }
@@ -1,9 +1,11 @@
-package service_test
+package service
 
 import (
 	"avito-shop-service/internal/models"
-	"avito-shop-service/internal/service"
+	"context"
+	"fmt"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -15,12 +17,12 @@ type MockUserRepository struct {
 	mock.Mock
 }
 
-func (m *MockUserRepository) CreateUser(user *models.User) error {
+func (m *MockUserRepository) CreateUser(ctx context.Context, user *models.User) error {
 	args := m.Called(user)
 	return args.Error(0)
 }
 
-func (m *MockUserRepository) GetUserByUsername(username string) (*models.User, error) {
+func (m *MockUserRepository) GetUserByUsername(ctx context.Context, username string) (*models.User, error) {
 	args := m.Called(username)
 	userData := args.Get(0)
 	if userData == nil {
@@ -29,18 +31,77 @@ func (m *MockUserRepository) GetUserByUsername(username string) (*models.User, e
 	return userData.(*models.User), args.Error(1)
 }
 
-func (m *MockUserRepository) UpdateCoins(userID int, amount int) error {
+func (m *MockUserRepository) GetUserByID(ctx context.Context, userID int) (*models.User, error) {
+	args := m.Called(userID)
+	userData := args.Get(0)
+	if userData == nil {
+		return nil, args.Error(1)
+	}
+	return userData.(*models.User), args.Error(1)
+}
+
+func (m *MockUserRepository) UpdateCoins(ctx context.Context, userID int, amount int) error {
 	args := m.Called(userID, amount)
 	return args.Error(0)
 }
 
+func (m *MockUserRepository) UpdatePassword(ctx context.Context, userID int, passwordHash string) error {
+	args := m.Called(userID, passwordHash)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) RehashPassword(ctx context.Context, userID int, oldHash, newHash string) error {
+	args := m.Called(userID, oldHash, newHash)
+	return args.Error(0)
+}
+
+// Заглушка второго фактора с единственным допустимым кодом
+type stubTwoFactor struct {
+	enabled bool
+	code    string
+}
+
+func (s *stubTwoFactor) IsEnabled(_ context.Context, _ int) (bool, error) {
+	return s.enabled, nil
+}
+
+func (s *stubTwoFactor) Verify(_ context.Context, _ int, code string) error {
+	if code != s.code {
+		return ErrInvalidTOTPCode
+	}
+	return nil
+}
+
+// Заглушка хранилища сессий в памяти
+type stubSessions struct {
+	owners  map[string]int
+	revoked map[string]bool
+}
+
+func newStubSessions() *stubSessions {
+	return &stubSessions{owners: make(map[string]int), revoked: make(map[string]bool)}
+}
+
+func (s *stubSessions) Create(ctx context.Context, userID int, _ models.ClientInfo, _ time.Time) (string, error) {
+	id := fmt.Sprintf("session-%d", len(s.owners)+1)
+	s.owners[id] = userID
+	return id, nil
+}
+
+func (s *stubSessions) Validate(ctx context.Context, sessionID string, userID int) error {
+	if s.revoked[sessionID] || s.owners[sessionID] != userID {
+		return ErrSessionRevoked
+	}
+	return nil
+}
+
 func TestRegisterSuccess(t *testing.T) {
 	mockRepo := new(MockUserRepository)
-	authService := service.NewAuthService(mockRepo, "supersecretkey")
+	authService := NewAuthService(mockRepo, "supersecretkey", &PasswordPolicy{}, NewBcryptHasher(bcrypt.DefaultCost), &stubTwoFactor{}, newStubSessions(), NewUserStateCache(0))
 
 	mockRepo.On("CreateUser", mock.AnythingOfType("*models.User")).Return(nil)
 
-	err := authService.Register("testuser", "password")
+	err := authService.Register(context.Background(), "testuser", "password")
 	assert.NoError(t, err)
 
 	mockRepo.AssertExpectations(t)
@@ -48,27 +109,28 @@ func TestRegisterSuccess(t *testing.T) {
 
 func TestLoginSuccess(t *testing.T) {
 	mockRepo := new(MockUserRepository)
-	authService := service.NewAuthService(mockRepo, "supersecretkey")
+	authService := NewAuthService(mockRepo, "supersecretkey", &PasswordPolicy{}, NewBcryptHasher(bcrypt.DefaultCost), &stubTwoFactor{}, newStubSessions(), NewUserStateCache(0))
 
 	hashedPassword, _ := bcrypt.GenerateFromPassword([]byte("password"), bcrypt.DefaultCost)
 	mockUser := &models.User{
 		ID:           1,
 		Username:     "testuser",
 		PasswordHash: string(hashedPassword),
+		Status:       models.StatusActive,
 	}
 
 	mockRepo.On("GetUserByUsername", "testuser").Return(mockUser, nil)
 
-	token, err := authService.Login("testuser", "password")
+	result, err := authService.Login(context.Background(), "testuser", "password", models.ClientInfo{})
 	assert.NoError(t, err)
-	assert.NotEmpty(t, token)
+	assert.NotEmpty(t, result.Token)
 
 	mockRepo.AssertExpectations(t)
 }
 
 func TestLoginInvalidCredentials(t *testing.T) {
 	mockRepo := new(MockUserRepository)
-	authService := service.NewAuthService(mockRepo, "supersecretkey")
+	authService := NewAuthService(mockRepo, "supersecretkey", &PasswordPolicy{}, NewBcryptHasher(bcrypt.DefaultCost), &stubTwoFactor{}, newStubSessions(), NewUserStateCache(0))
 
 	hashedPassword, _ := bcrypt.GenerateFromPassword([]byte("correct-password"), bcrypt.DefaultCost)
 	mockUser := &models.User{
@@ -79,10 +141,162 @@ func TestLoginInvalidCredentials(t *testing.T) {
 
 	mockRepo.On("GetUserByUsername", "testuser").Return(mockUser, nil)
 
-	token, err := authService.Login("testuser", "wrongpassword")
+	result, err := authService.Login(context.Background(), "testuser", "wrongpassword", models.ClientInfo{})
 	assert.Error(t, err)
 	assert.Equal(t, "invalid password", err.Error())
-	assert.Empty(t, token)
+	assert.Nil(t, result)
+
+	mockRepo.AssertExpectations(t)
+}
+
+func TestLoginSuspendedAccount(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	authService := NewAuthService(mockRepo, "supersecretkey", &PasswordPolicy{}, NewBcryptHasher(bcrypt.DefaultCost), &stubTwoFactor{}, newStubSessions(), NewUserStateCache(0))
+
+	hashedPassword, _ := bcrypt.GenerateFromPassword([]byte("password"), bcrypt.DefaultCost)
+	mockUser := &models.User{
+		ID:           1,
+		Username:     "testuser",
+		PasswordHash: string(hashedPassword),
+		Status:       models.StatusSuspended,
+	}
+
+	mockRepo.On("GetUserByUsername", "testuser").Return(mockUser, nil)
+
+	result, err := authService.Login(context.Background(), "testuser", "password", models.ClientInfo{})
+	assert.ErrorIs(t, err, ErrAccountDisabled)
+	assert.Nil(t, result)
 
 	mockRepo.AssertExpectations(t)
 }
+
+func TestValidateUserFrozenAllowed(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	authService := NewAuthService(mockRepo, "supersecretkey", &PasswordPolicy{}, NewBcryptHasher(bcrypt.DefaultCost), &stubTwoFactor{}, newStubSessions(), NewUserStateCache(0))
+
+	mockRepo.On("GetUserByID", 1).Return(&models.User{ID: 1, Status: models.StatusFrozen}, nil)
+	mockRepo.On("GetUserByID", 2).Return(&models.User{ID: 2, Status: models.StatusClosed}, nil)
+
+	assert.NoError(t, authService.ValidateUser(context.Background(), 1))
+	assert.ErrorIs(t, authService.ValidateUser(context.Background(), 2), ErrAccountDisabled)
+}
+
+// После смены пароля токены с прежней версией отклоняются
+func TestAuthenticateRevokedToken(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	authService := NewAuthService(mockRepo, "supersecretkey", &PasswordPolicy{}, NewBcryptHasher(bcrypt.DefaultCost), &stubTwoFactor{}, newStubSessions(), NewUserStateCache(0))
+
+	hashedPassword, _ := bcrypt.GenerateFromPassword([]byte("password"), bcrypt.DefaultCost)
+	user := &models.User{ID: 1, Username: "testuser", PasswordHash: string(hashedPassword), Status: models.StatusActive}
+
+	mockRepo.On("GetUserByUsername", "testuser").Return(user, nil)
+	mockRepo.On("GetUserByID", 1).Return(user, nil)
+
+	result, err := authService.Login(context.Background(), "testuser", "password", models.ClientInfo{})
+	assert.NoError(t, err)
+
+	userID, _, err := authService.Authenticate(context.Background(), result.Token)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, userID)
+
+	user.TokenVersion++
+	_, _, err = authService.Authenticate(context.Background(), result.Token)
+	assert.ErrorIs(t, err, ErrTokenRevoked)
+}
+
+func TestRegisterPasswordPolicy(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	authService := NewAuthService(mockRepo, "supersecretkey", &PasswordPolicy{MinLength: 8}, NewBcryptHasher(bcrypt.DefaultCost), &stubTwoFactor{}, newStubSessions(), NewUserStateCache(0))
+
+	err := authService.Register(context.Background(), "testuser", "short")
+	assert.ErrorIs(t, err, ErrPasswordTooShort)
+
+	mockRepo.AssertNotCalled(t, "CreateUser", mock.Anything)
+}
+
+// Хеш с устаревшей стоимостью пересчитывается при успешном входе
+func TestLoginRehashesOutdatedHash(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	authService := NewAuthService(mockRepo, "supersecretkey", &PasswordPolicy{}, NewBcryptHasher(bcrypt.MinCost+1), &stubTwoFactor{}, newStubSessions(), NewUserStateCache(0))
+
+	oldHash, _ := bcrypt.GenerateFromPassword([]byte("password"), bcrypt.MinCost)
+	mockUser := &models.User{ID: 1, Username: "testuser", PasswordHash: string(oldHash), Status: models.StatusActive}
+
+	mockRepo.On("GetUserByUsername", "testuser").Return(mockUser, nil)
+	mockRepo.On("RehashPassword", 1, string(oldHash), mock.MatchedBy(func(h string) bool {
+		cost, err := bcrypt.Cost([]byte(h))
+		return err == nil && cost == bcrypt.MinCost+1
+	})).Return(nil)
+
+	result, err := authService.Login(context.Background(), "testuser", "password", models.ClientInfo{})
+	assert.NoError(t, err)
+	assert.NotEmpty(t, result.Token)
+
+	mockRepo.AssertExpectations(t)
+}
+
+// При включенной 2FA вход выдает токен подтверждения, который нельзя использовать для доступа к API
+func TestLoginTwoFactorChallenge(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	authService := NewAuthService(mockRepo, "supersecretkey", &PasswordPolicy{}, NewBcryptHasher(bcrypt.MinCost), &stubTwoFactor{enabled: true, code: "123456"}, newStubSessions(), NewUserStateCache(0))
+
+	hashedPassword, _ := bcrypt.GenerateFromPassword([]byte("password"), bcrypt.MinCost)
+	user := &models.User{ID: 1, Username: "testuser", PasswordHash: string(hashedPassword), Status: models.StatusActive}
+
+	mockRepo.On("GetUserByUsername", "testuser").Return(user, nil)
+	mockRepo.On("GetUserByID", 1).Return(user, nil)
+
+	result, err := authService.Login(context.Background(), "testuser", "password", models.ClientInfo{})
+	assert.NoError(t, err)
+	assert.True(t, result.TwoFactorRequired)
+	assert.Empty(t, result.Token)
+
+	_, _, err = authService.Authenticate(context.Background(), result.ChallengeToken)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+
+	_, err = authService.CompleteTwoFactor(context.Background(), result.ChallengeToken, "000000", models.ClientInfo{})
+	assert.ErrorIs(t, err, ErrInvalidTOTPCode)
+
+	token, err := authService.CompleteTwoFactor(context.Background(), result.ChallengeToken, "123456", models.ClientInfo{})
+	assert.NoError(t, err)
+
+	userID, _, err := authService.Authenticate(context.Background(), token)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, userID)
+
+	// Токен доступа не принимается вместо токена подтверждения
+	_, err = authService.CompleteTwoFactor(context.Background(), token, "123456", models.ClientInfo{})
+	assert.ErrorIs(t, err, ErrInvalidToken)
+}
+
+// Токен отозванной сессии отклоняется, остальные сессии пользователя продолжают работать
+func TestAuthenticateRevokedSession(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	sessions := newStubSessions()
+	authService := NewAuthService(mockRepo, "supersecretkey", &PasswordPolicy{}, NewBcryptHasher(bcrypt.MinCost), &stubTwoFactor{}, sessions, NewUserStateCache(0))
+
+	hashedPassword, _ := bcrypt.GenerateFromPassword([]byte("password"), bcrypt.MinCost)
+	user := &models.User{ID: 1, Username: "testuser", PasswordHash: string(hashedPassword), Status: models.StatusActive}
+
+	mockRepo.On("GetUserByUsername", "testuser").Return(user, nil)
+	mockRepo.On("GetUserByID", 1).Return(user, nil)
+
+	laptop, err := authService.Login(context.Background(), "testuser", "password", models.ClientInfo{UserAgent: "laptop"})
+	assert.NoError(t, err)
+	phone, err := authService.Login(context.Background(), "testuser", "password", models.ClientInfo{UserAgent: "phone"})
+	assert.NoError(t, err)
+
+	_, laptopSession, err := authService.Authenticate(context.Background(), laptop.Token)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, laptopSession)
+
+	sessions.revoked[laptopSession] = true
+
+	_, _, err = authService.Authenticate(context.Background(), laptop.Token)
+	assert.ErrorIs(t, err, ErrSessionRevoked)
+
+	userID, phoneSession, err := authService.Authenticate(context.Background(), phone.Token)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, userID)
+	assert.NotEqual(t, laptopSession, phoneSession)
+}
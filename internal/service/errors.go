@@ -0,0 +1,148 @@
+package service
+
+import (
+	"avito-shop-service/internal/repository"
+	"context"
+	"errors"
+)
+
+// ErrorKind - категория ошибки; по ней транспортный слой выбирает статус ответа
+type ErrorKind int
+
+const (
+	KindInternal        ErrorKind = iota
+	KindInvalid                   // некорректные параметры запроса
+	KindUnauthenticated           // не удалось подтвердить личность
+	KindForbidden                 // действие запрещено для пользователя или аккаунта
+	KindNotFound                  // объект не существует
+	KindConflict                  // действие противоречит текущему состоянию
+	KindUnprocessable             // запрос корректен, но не может быть выполнен
+	KindLimitExceeded             // превышен лимит операций
+	KindTimeout                   // запрос не уложился в отведенное время или был отменен
+	KindUpstream                  // внешняя зависимость недоступна или ответила некорректно
+)
+
+// Коды ошибок API. Клиенты опираются на них, поэтому значения не меняются
+const (
+	CodeInvalidRequest = "invalid_request"
+	CodeUnauthorized   = "unauthorized"
+	CodeForbidden      = "forbidden"
+	CodeInternal       = "internal_error"
+	CodeRequestTimeout = "request_timeout"
+	CodeRateLimited    = "rate_limited"
+
+	CodeInsufficientScope = "insufficient_scope"
+
+	CodeUserNotFound      = "user_not_found"
+	CodeItemNotFound      = "item_not_found"
+	CodeInsufficientFunds = "insufficient_funds"
+	CodeInvalidAmount     = "invalid_amount"
+
+	CodeSSOLoginFailed   = "sso_login_failed"
+	CodeSSOProviderError = "sso_provider_error"
+)
+
+// DomainError - ошибка из каталога: стабильный код, категория и сообщение для клиента
+type DomainError struct {
+	Code    string
+	Kind    ErrorKind
+	Message string
+}
+
+type catalogueEntry struct {
+	err  error
+	code string
+	kind ErrorKind
+}
+
+// Каталог известных ошибок. Сообщение клиенту берется из самой ошибки каталога,
+// поэтому подробности из обернутых ошибок наружу не попадают
+var errorCatalogue = []catalogueEntry{
+	// Пользователи и аккаунты
+	{repository.ErrUserNotFound, CodeUserNotFound, KindNotFound},
+	{repository.ErrUsernameTaken, "username_taken", KindConflict},
+	{repository.ErrAccountInactive, "account_inactive", KindForbidden},
+	{repository.ErrAccountClosed, "account_closed", KindConflict},
+	{ErrAccountDisabled, "account_disabled", KindForbidden},
+	{ErrInvalidStatus, "invalid_status", KindInvalid},
+	{ErrInvalidDisposition, "invalid_disposition", KindInvalid},
+	{ErrBeneficiaryInactive, "beneficiary_inactive", KindUnprocessable},
+	{repository.ErrBalanceTransferBlocked, "balance_transfer_blocked", KindForbidden},
+
+	// Монеты, покупки и переводы
+	{repository.ErrItemNotFound, CodeItemNotFound, KindNotFound},
+	{ErrInvalidAmount, CodeInvalidAmount, KindInvalid},
+	{ErrInvalidQuantity, "invalid_quantity", KindInvalid},
+	{ErrSelfTransfer, "self_transfer", KindInvalid},
+	{ErrInvalidPrice, "invalid_price", KindInvalid},
+	{repository.ErrInsufficientFunds, CodeInsufficientFunds, KindUnprocessable},
+	{repository.ErrTransfersFrozen, "transfers_frozen", KindForbidden},
+	{ErrTransferAmountLimit, "transfer_amount_limit", KindUnprocessable},
+	{ErrDailyVolumeLimit, "daily_volume_limit", KindLimitExceeded},
+	{ErrHourlyTransferLimit, "hourly_transfer_limit", KindLimitExceeded},
+	{ErrDailyRecipientsLimit, "daily_recipients_limit", KindLimitExceeded},
+	{ErrInvalidLimits, "invalid_limits", KindInvalid},
+
+	// Холды
+	{ErrInvalidHoldAmount, CodeInvalidAmount, KindInvalid},
+	{ErrInvalidHoldTTL, "invalid_hold_ttl", KindInvalid},
+	{ErrInvalidCapture, "invalid_capture", KindInvalid},
+	{repository.ErrHoldNotFound, "hold_not_found", KindNotFound},
+	{repository.ErrHoldNotActive, "hold_not_active", KindConflict},
+	{repository.ErrHoldExpired, "hold_expired", KindConflict},
+	{repository.ErrHoldAmountExceeded, "hold_amount_exceeded", KindUnprocessable},
+
+	// Администрирование
+	{ErrInvalidAdjustment, CodeInvalidAmount, KindInvalid},
+	{ErrMissingReason, "missing_reason", KindInvalid},
+	{ErrInvalidBulkRequest, "invalid_recipients", KindInvalid},
+	{repository.ErrInvalidReversalPolicy, "invalid_reversal_policy", KindInvalid},
+	{repository.ErrDuplicateAdjustment, "duplicate_reference", KindConflict},
+	{repository.ErrTransactionNotFound, "transaction_not_found", KindNotFound},
+	{repository.ErrAlreadyReversed, "already_reversed", KindConflict},
+	{repository.ErrCannotReverseReversal, "cannot_reverse_reversal", KindConflict},
+	{ErrInvalidDecision, "invalid_decision", KindInvalid},
+	{repository.ErrFlagNotFound, "fraud_flag_not_found", KindNotFound},
+
+	// Аутентификация и пароли
+	{ErrInvalidToken, "invalid_token", KindUnauthenticated},
+	{ErrTokenRevoked, "token_revoked", KindUnauthenticated},
+	{ErrSessionRevoked, "session_revoked", KindUnauthenticated},
+	{ErrAPITokenInvalid, "invalid_token", KindUnauthenticated},
+	{ErrInvalidPassword, "invalid_password", KindForbidden},
+	{ErrInvalidCredentials, "invalid_credentials", KindUnauthenticated},
+	{ErrPasswordTooShort, "password_too_short", KindInvalid},
+	{ErrPasswordTooLong, "password_too_long", KindInvalid},
+	{ErrPasswordBreached, "password_breached", KindInvalid},
+	{ErrSamePassword, "same_password", KindInvalid},
+	{repository.ErrInvalidResetToken, "invalid_reset_token", KindInvalid},
+	{ErrInvalidTOTPCode, "invalid_two_factor_code", KindUnprocessable},
+	{ErrTwoFactorLocked, "two_factor_locked", KindLimitExceeded},
+	{repository.ErrTOTPAlreadyEnabled, "two_factor_already_enabled", KindConflict},
+	{repository.ErrTOTPNotEnrolled, "two_factor_not_enrolled", KindConflict},
+	{ErrOIDCDisabled, "sso_disabled", KindNotFound},
+	{ErrOIDCLoginFailed, CodeSSOLoginFailed, KindUnauthenticated},
+	{ErrOIDCProvider, CodeSSOProviderError, KindUpstream},
+	{repository.ErrInvalidLoginState, "invalid_login_state", KindInvalid},
+
+	// Персональные токены и сессии
+	{ErrInvalidScope, "invalid_scope", KindInvalid},
+	{ErrInvalidTokenName, "invalid_token_name", KindInvalid},
+	{ErrInvalidTokenTTL, "invalid_token_ttl", KindInvalid},
+	{repository.ErrAPITokenNotFound, "api_token_not_found", KindNotFound},
+	{repository.ErrSessionNotFound, "session_not_found", KindNotFound},
+
+	// Отмена запроса: истек срок обработки или клиент отключился
+	{context.DeadlineExceeded, CodeRequestTimeout, KindTimeout},
+	{context.Canceled, CodeRequestTimeout, KindTimeout},
+}
+
+// ClassifyError находит ошибку в каталоге. Для неизвестных ошибок возвращает false
+func ClassifyError(err error) (DomainError, bool) {
+	for _, entry := range errorCatalogue {
+		if errors.Is(err, entry.err) {
+			return DomainError{Code: entry.code, Kind: entry.kind, Message: entry.err.Error()}, true
+		}
+	}
+	return DomainError{Code: CodeInternal, Kind: KindInternal}, false
+}
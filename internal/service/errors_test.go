@@ -0,0 +1,57 @@
+package service
+
+import (
+	"avito-shop-service/internal/repository"
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClassifyError(t *testing.T) {
+	domainErr, ok := ClassifyError(repository.ErrInsufficientFunds)
+	assert.True(t, ok)
+	assert.Equal(t, CodeInsufficientFunds, domainErr.Code)
+	assert.Equal(t, KindUnprocessable, domainErr.Kind)
+
+	domainErr, ok = ClassifyError(ErrInvalidAmount)
+	assert.True(t, ok)
+	assert.Equal(t, CodeInvalidAmount, domainErr.Code)
+	assert.Equal(t, KindInvalid, domainErr.Kind)
+}
+
+// Обернутая ошибка распознается, но подробности обертки клиенту не отдаются
+func TestClassifyWrappedError(t *testing.T) {
+	err := fmt.Errorf("%w: token endpoint returned 500 Internal Server Error", ErrOIDCLoginFailed)
+
+	domainErr, ok := ClassifyError(err)
+	assert.True(t, ok)
+	assert.Equal(t, CodeSSOLoginFailed, domainErr.Code)
+	assert.Equal(t, ErrOIDCLoginFailed.Error(), domainErr.Message)
+}
+
+// Отмененный или просроченный запрос к базе данных - не внутренняя ошибка
+func TestClassifyContextError(t *testing.T) {
+	domainErr, ok := ClassifyError(fmt.Errorf("get balance: %w", context.DeadlineExceeded))
+	assert.True(t, ok)
+	assert.Equal(t, CodeRequestTimeout, domainErr.Code)
+	assert.Equal(t, KindTimeout, domainErr.Kind)
+}
+
+func TestClassifyUnknownError(t *testing.T) {
+	domainErr, ok := ClassifyError(errors.New("pq: connection refused"))
+	assert.False(t, ok)
+	assert.Equal(t, CodeInternal, domainErr.Code)
+	assert.Equal(t, KindInternal, domainErr.Kind)
+	assert.Empty(t, domainErr.Message)
+}
+
+// У каждой ошибки каталога есть код и категория
+func TestErrorCatalogueComplete(t *testing.T) {
+	for _, entry := range errorCatalogue {
+		assert.NotEmpty(t, entry.code, entry.err.Error())
+		assert.NotEqual(t, KindInternal, entry.kind, entry.err.Error())
+	}
+}
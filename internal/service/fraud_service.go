@@ -0,0 +1,185 @@
+package service
+
+import (
+	"avito-shop-service/internal/models"
+	"avito-shop-service/internal/repository"
+	"avito-shop-service/internal/tracing"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+)
+
+var ErrInvalidDecision = errors.New("decision must be dismissed or confirmed")
+
+// Настройки фонового анализа переводов
+type FraudConfig struct {
+	ScanInterval time.Duration // 0 - фоновый анализ отключен
+	AutoFreeze   bool          // Блокировать исходящие переводы пользователя при новой отметке
+	Params       models.FraudScanParams
+}
+
+type FraudService struct {
+	fraudRepo repository.FraudRepository
+	cfg       FraudConfig
+}
+
+func NewFraudService(fraudRepo repository.FraudRepository, cfg FraudConfig) *FraudService {
+	return &FraudService{fraudRepo: fraudRepo, cfg: cfg}
+}
+
+// Run периодически запускает анализ переводов до отмены контекста
+func (s *FraudService) Run(ctx context.Context) {
+	if s.cfg.ScanInterval <= 0 {
+		slog.InfoContext(ctx, "fraud scanning disabled")
+		return
+	}
+
+	ticker := time.NewTicker(s.cfg.ScanInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			flagged, err := s.Scan(ctx)
+			if ctx.Err() != nil {
+				// Сервис останавливается, прерванный анализ повторится при следующем запуске
+				return
+			}
+			if err != nil {
+				slog.ErrorContext(ctx, "fraud scan failed", slog.Any("error", err))
+				continue
+			}
+			if flagged > 0 {
+				slog.InfoContext(ctx, "fraud scan flagged users", slog.Int("flagged", flagged))
+			}
+		}
+	}
+}
+
+// Scan ищет подозрительные схемы переводов и отмечает участников. Возвращает количество новых отметок.
+func (s *FraudService) Scan(ctx context.Context) (int, error) {
+	ctx, span := tracing.Start(ctx, "FraudService.Scan")
+	defer span.End()
+
+	params := s.cfg.Params
+
+	var flags []models.FraudFlag
+
+	drained, err := s.fraudRepo.FindDrainedNewAccounts(ctx, params)
+	if err != nil {
+		return 0, err
+	}
+	for _, d := range drained {
+		flags = append(flags, models.FraudFlag{
+			UserID:  d.UserID,
+			Pattern: models.FraudPatternDrainedNewAccount,
+			Details: fmt.Sprintf("sent %d coins to user %d within %s of registration", d.Amount, d.RecipientID, params.NewAccountAge),
+		})
+	}
+
+	funnels, err := s.fraudRepo.FindFunnelRecipients(ctx, params)
+	if err != nil {
+		return 0, err
+	}
+	for _, f := range funnels {
+		flags = append(flags, models.FraudFlag{
+			UserID:  f.UserID,
+			Pattern: models.FraudPatternFunnel,
+			Details: fmt.Sprintf("received %d coins from %d new accounts", f.Amount, f.Senders),
+		})
+	}
+
+	cycles, err := s.fraudRepo.FindCircularFlows(ctx, params)
+	if err != nil {
+		return 0, err
+	}
+	for _, cycle := range cycles {
+		for _, userID := range cycle {
+			flags = append(flags, models.FraudFlag{
+				UserID:  userID,
+				Pattern: models.FraudPatternCircularFlow,
+				Details: "circular transfers between users " + formatCycle(cycle),
+			})
+		}
+	}
+
+	created := 0
+	for i := range flags {
+		ok, err := s.fraudRepo.CreateFlag(ctx, &flags[i], params.Lookback)
+		if err != nil {
+			return created, err
+		}
+		if !ok {
+			continue
+		}
+		created++
+
+		if s.cfg.AutoFreeze {
+			if err := s.fraudRepo.FreezeForFraud(ctx, flags[i].UserID); err != nil {
+				return created, err
+			}
+		}
+	}
+
+	return created, nil
+}
+
+// GetFlags возвращает отметки с указанным статусом
+func (s *FraudService) GetFlags(ctx context.Context, status string) ([]models.FraudFlag, error) {
+	ctx, span := tracing.Start(ctx, "FraudService.GetFlags")
+	defer span.End()
+
+	return s.fraudRepo.GetFlags(ctx, status)
+}
+
+// ReviewFlag фиксирует решение администратора. Подтвержденная отметка блокирует исходящие переводы,
+// после отклонения последней открытой отметки (при отсутствии подтвержденных) снимается блокировка,
+// выставленная анализом; ручная блокировка администратора остается.
+func (s *FraudService) ReviewFlag(ctx context.Context, adminID, flagID int, decision string) (*models.FraudFlag, error) {
+	ctx, span := tracing.Start(ctx, "FraudService.ReviewFlag")
+	defer span.End()
+
+	if decision != models.FraudFlagDismissed && decision != models.FraudFlagConfirmed {
+		return nil, ErrInvalidDecision
+	}
+
+	flag, err := s.fraudRepo.ReviewFlag(ctx, flagID, adminID, decision)
+	if err != nil {
+		return nil, err
+	}
+
+	if decision == models.FraudFlagConfirmed {
+		return flag, s.fraudRepo.FreezeForFraud(ctx, flag.UserID)
+	}
+
+	blocking, err := s.fraudRepo.CountBlockingFlags(ctx, flag.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if blocking == 0 {
+		return flag, s.fraudRepo.LiftFraudFreeze(ctx, flag.UserID)
+	}
+	return flag, nil
+}
+
+// SetTransfersFrozen вручную блокирует или разблокирует исходящие переводы пользователя
+func (s *FraudService) SetTransfersFrozen(ctx context.Context, userID int, frozen bool) error {
+	ctx, span := tracing.Start(ctx, "FraudService.SetTransfersFrozen")
+	defer span.End()
+
+	return s.fraudRepo.SetTransfersFrozen(ctx, userID, frozen)
+}
+
+func formatCycle(cycle []int) string {
+	parts := make([]string, 0, len(cycle)+1)
+	for _, userID := range cycle {
+		parts = append(parts, fmt.Sprint(userID))
+	}
+	parts = append(parts, fmt.Sprint(cycle[0]))
+	return strings.Join(parts, " -> ")
+}
@@ -0,0 +1,133 @@
+package service
+
+import (
+	"avito-shop-service/internal/models"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// Mock FraudRepository
+type MockFraudRepository struct {
+	mock.Mock
+}
+
+func (m *MockFraudRepository) FindDrainedNewAccounts(ctx context.Context, params models.FraudScanParams) ([]models.DrainedAccount, error) {
+	args := m.Called(params)
+	return args.Get(0).([]models.DrainedAccount), args.Error(1)
+}
+
+func (m *MockFraudRepository) FindFunnelRecipients(ctx context.Context, params models.FraudScanParams) ([]models.FunnelRecipient, error) {
+	args := m.Called(params)
+	return args.Get(0).([]models.FunnelRecipient), args.Error(1)
+}
+
+func (m *MockFraudRepository) FindCircularFlows(ctx context.Context, params models.FraudScanParams) ([][]int, error) {
+	args := m.Called(params)
+	return args.Get(0).([][]int), args.Error(1)
+}
+
+func (m *MockFraudRepository) CreateFlag(ctx context.Context, flag *models.FraudFlag, dedupWindow time.Duration) (bool, error) {
+	args := m.Called(flag, dedupWindow)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockFraudRepository) GetFlags(ctx context.Context, status string) ([]models.FraudFlag, error) {
+	args := m.Called(status)
+	return args.Get(0).([]models.FraudFlag), args.Error(1)
+}
+
+func (m *MockFraudRepository) ReviewFlag(ctx context.Context, flagID, adminID int, status string) (*models.FraudFlag, error) {
+	args := m.Called(flagID, adminID, status)
+	flag := args.Get(0)
+	if flag == nil {
+		return nil, args.Error(1)
+	}
+	return flag.(*models.FraudFlag), args.Error(1)
+}
+
+func (m *MockFraudRepository) CountBlockingFlags(ctx context.Context, userID int) (int, error) {
+	args := m.Called(userID)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockFraudRepository) SetTransfersFrozen(ctx context.Context, userID int, frozen bool) error {
+	args := m.Called(userID, frozen)
+	return args.Error(0)
+}
+
+func (m *MockFraudRepository) FreezeForFraud(ctx context.Context, userID int) error {
+	args := m.Called(userID)
+	return args.Error(0)
+}
+
+func (m *MockFraudRepository) LiftFraudFreeze(ctx context.Context, userID int) error {
+	args := m.Called(userID)
+	return args.Error(0)
+}
+
+// Все найденные схемы превращаются в отметки; при автоблокировке замораживаются только новые
+func TestFraudScanFlagsAndFreezes(t *testing.T) {
+	mockRepo := new(MockFraudRepository)
+	params := models.FraudScanParams{Lookback: 72 * time.Hour, NewAccountAge: 24 * time.Hour}
+	service := NewFraudService(mockRepo, FraudConfig{AutoFreeze: true, Params: params})
+
+	mockRepo.On("FindDrainedNewAccounts", params).Return([]models.DrainedAccount{{UserID: 5, RecipientID: 9, Amount: 1000}}, nil)
+	mockRepo.On("FindFunnelRecipients", params).Return([]models.FunnelRecipient{{UserID: 9, Senders: 4, Amount: 4000}}, nil)
+	mockRepo.On("FindCircularFlows", params).Return([][]int{{1, 2, 3}}, nil)
+
+	// Отметка для пользователя 2 уже существует
+	mockRepo.On("CreateFlag", mock.MatchedBy(func(f *models.FraudFlag) bool { return f.UserID == 2 }), params.Lookback).Return(false, nil)
+	mockRepo.On("CreateFlag", mock.Anything, params.Lookback).Return(true, nil)
+	for _, userID := range []int{5, 9, 1, 3} {
+		mockRepo.On("FreezeForFraud", userID).Return(nil)
+	}
+
+	flagged, err := service.Scan(context.Background())
+
+	assert.NoError(t, err)
+	assert.Equal(t, 4, flagged)
+	mockRepo.AssertNotCalled(t, "FreezeForFraud", 2)
+	mockRepo.AssertExpectations(t)
+}
+
+// Отклонение последней отметки снимает только блокировку, выставленную анализом
+func TestReviewFlagDismissUnfreezes(t *testing.T) {
+	mockRepo := new(MockFraudRepository)
+	service := NewFraudService(mockRepo, FraudConfig{})
+
+	mockRepo.On("ReviewFlag", 7, 1, models.FraudFlagDismissed).Return(&models.FraudFlag{ID: 7, UserID: 5}, nil)
+	mockRepo.On("CountBlockingFlags", 5).Return(0, nil)
+	mockRepo.On("LiftFraudFreeze", 5).Return(nil)
+
+	_, err := service.ReviewFlag(context.Background(), 1, 7, models.FraudFlagDismissed)
+
+	assert.NoError(t, err)
+	mockRepo.AssertExpectations(t)
+	mockRepo.AssertNotCalled(t, "SetTransfersFrozen", 5, false)
+}
+
+// Подтверждение отметки блокирует переводы как блокировка анализа
+func TestReviewFlagConfirmFreezes(t *testing.T) {
+	mockRepo := new(MockFraudRepository)
+	service := NewFraudService(mockRepo, FraudConfig{})
+
+	mockRepo.On("ReviewFlag", 7, 1, models.FraudFlagConfirmed).Return(&models.FraudFlag{ID: 7, UserID: 5}, nil)
+	mockRepo.On("FreezeForFraud", 5).Return(nil)
+
+	_, err := service.ReviewFlag(context.Background(), 1, 7, models.FraudFlagConfirmed)
+
+	assert.NoError(t, err)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestReviewFlagInvalidDecision(t *testing.T) {
+	service := NewFraudService(new(MockFraudRepository), FraudConfig{})
+
+	_, err := service.ReviewFlag(context.Background(), 1, 7, "maybe")
+
+	assert.ErrorIs(t, err, ErrInvalidDecision)
+}
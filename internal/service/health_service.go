@@ -0,0 +1,90 @@
+package service
+
+import (
+	"avito-shop-service/internal/models"
+	"avito-shop-service/internal/repository"
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+type HealthService struct {
+	healthRepo   repository.HealthRepository
+	timeout      time.Duration
+	shuttingDown atomic.Bool
+}
+
+func NewHealthService(healthRepo repository.HealthRepository, timeout time.Duration) *HealthService {
+	return &HealthService{healthRepo: healthRepo, timeout: timeout}
+}
+
+// SetShuttingDown снимает сервис с балансировки: после вызова readiness всегда отрицательна
+func (s *HealthService) SetShuttingDown() {
+	s.shuttingDown.Store(true)
+}
+
+// Liveness подтверждает, что процесс жив; зависимости не проверяются
+func (s *HealthService) Liveness() models.HealthReport {
+	return models.HealthReport{Status: models.HealthStatusOK}
+}
+
+// Readiness проверяет, что сервис может обрабатывать запросы: база данных доступна,
+// схема не старше ожидаемой и сервис не останавливается
+func (s *HealthService) Readiness(ctx context.Context) models.HealthReport {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	components := map[string]models.ComponentHealth{
+		"database":   s.checkDatabase(ctx),
+		"migrations": s.checkMigrations(ctx),
+		"shutdown":   s.checkShutdown(),
+	}
+
+	report := models.HealthReport{Status: models.HealthStatusOK, Components: components}
+	for _, c := range components {
+		if c.Status != models.HealthStatusOK {
+			report.Status = models.HealthStatusFail
+		}
+	}
+	return report
+}
+
+func (s *HealthService) checkDatabase(ctx context.Context) models.ComponentHealth {
+	if err := s.healthRepo.Ping(ctx); err != nil {
+		return failedComponent(err, "database is unreachable")
+	}
+	return models.ComponentHealth{Status: models.HealthStatusOK}
+}
+
+// Схема новее ожидаемой допустима: во время выкладки старые экземпляры работают
+// с уже обновленной базой
+func (s *HealthService) checkMigrations(ctx context.Context) models.ComponentHealth {
+	version, err := s.healthRepo.SchemaVersion(ctx)
+	if err != nil {
+		return failedComponent(err, "schema version is unknown")
+	}
+
+	health := models.ComponentHealth{Status: models.HealthStatusOK, Version: version, Expected: repository.ExpectedSchemaVersion}
+	if version < repository.ExpectedSchemaVersion {
+		health.Status = models.HealthStatusFail
+		health.Error = fmt.Sprintf("schema version %d is behind expected %d", version, repository.ExpectedSchemaVersion)
+	}
+	return health
+}
+
+func (s *HealthService) checkShutdown() models.ComponentHealth {
+	if s.shuttingDown.Load() {
+		return models.ComponentHealth{Status: models.HealthStatusFail, Error: "shutting down"}
+	}
+	return models.ComponentHealth{Status: models.HealthStatusOK}
+}
+
+// Пробы доступны без аутентификации, поэтому текст ошибок базы наружу не отдается
+func failedComponent(err error, message string) models.ComponentHealth {
+	if errors.Is(err, context.DeadlineExceeded) {
+		message = "check timed out"
+	}
+	return models.ComponentHealth{Status: models.HealthStatusFail, Error: message}
+}
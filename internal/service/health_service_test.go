@@ -0,0 +1,95 @@
+package service
+
+import (
+	"avito-shop-service/internal/models"
+	"avito-shop-service/internal/repository"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockHealthRepository struct {
+	mock.Mock
+}
+
+func (m *MockHealthRepository) Ping(ctx context.Context) error {
+	args := m.Called()
+	return args.Error(0)
+}
+
+func (m *MockHealthRepository) SchemaVersion(ctx context.Context) (int, error) {
+	args := m.Called()
+	return args.Int(0), args.Error(1)
+}
+
+func TestHealthReadiness(t *testing.T) {
+	repo := new(MockHealthRepository)
+	service := NewHealthService(repo, time.Second)
+
+	repo.On("Ping").Return(nil)
+	repo.On("SchemaVersion").Return(repository.ExpectedSchemaVersion, nil)
+
+	report := service.Readiness(context.Background())
+	assert.True(t, report.Healthy())
+	assert.Equal(t, models.HealthStatusOK, report.Components["database"].Status)
+	assert.Equal(t, repository.ExpectedSchemaVersion, report.Components["migrations"].Version)
+	repo.AssertExpectations(t)
+}
+
+// Текст ошибки базы данных наружу не отдается
+func TestHealthReadinessDatabaseDown(t *testing.T) {
+	repo := new(MockHealthRepository)
+	service := NewHealthService(repo, time.Second)
+
+	repo.On("Ping").Return(errors.New("dial tcp 10.0.0.5:5432: connection refused"))
+	repo.On("SchemaVersion").Return(0, errors.New("dial tcp 10.0.0.5:5432: connection refused"))
+
+	report := service.Readiness(context.Background())
+	assert.False(t, report.Healthy())
+	assert.Equal(t, models.HealthStatusFail, report.Components["database"].Status)
+	assert.NotContains(t, report.Components["database"].Error, "10.0.0.5")
+}
+
+func TestHealthReadinessSchemaBehind(t *testing.T) {
+	repo := new(MockHealthRepository)
+	service := NewHealthService(repo, time.Second)
+
+	repo.On("Ping").Return(nil)
+	repo.On("SchemaVersion").Return(repository.ExpectedSchemaVersion-1, nil)
+
+	report := service.Readiness(context.Background())
+	assert.False(t, report.Healthy())
+	assert.Equal(t, models.HealthStatusFail, report.Components["migrations"].Status)
+	assert.Equal(t, models.HealthStatusOK, report.Components["database"].Status)
+}
+
+// Схема новее ожидаемой не мешает работе во время выкладки
+func TestHealthReadinessSchemaAhead(t *testing.T) {
+	repo := new(MockHealthRepository)
+	service := NewHealthService(repo, time.Second)
+
+	repo.On("Ping").Return(nil)
+	repo.On("SchemaVersion").Return(repository.ExpectedSchemaVersion+1, nil)
+
+	assert.True(t, service.Readiness(context.Background()).Healthy())
+}
+
+func TestHealthReadinessShuttingDown(t *testing.T) {
+	repo := new(MockHealthRepository)
+	service := NewHealthService(repo, time.Second)
+
+	repo.On("Ping").Return(nil)
+	repo.On("SchemaVersion").Return(repository.ExpectedSchemaVersion, nil)
+
+	service.SetShuttingDown()
+
+	report := service.Readiness(context.Background())
+	assert.False(t, report.Healthy())
+	assert.Equal(t, models.HealthStatusFail, report.Components["shutdown"].Status)
+	// Liveness при остановке не меняется
+	assert.True(t, service.Liveness().Healthy())
+}
@@ -0,0 +1,115 @@
+package service
+
+import (
+	"avito-shop-service/internal/metrics"
+	"avito-shop-service/internal/models"
+	"avito-shop-service/internal/repository"
+	"avito-shop-service/internal/tracing"
+	"context"
+	"errors"
+	"time"
+)
+
+const (
+	// Срок действия холда, если клиент его не указал
+	DefaultHoldTTL = 24 * time.Hour
+	// Максимальный срок действия холда
+	MaxHoldTTL = 30 * 24 * time.Hour
+)
+
+var (
+	ErrInvalidHoldAmount = errors.New("invalid hold amount")
+	ErrInvalidHoldTTL    = errors.New("invalid hold ttl")
+	ErrInvalidCapture    = errors.New("invalid capture request")
+)
+
+type HoldService struct {
+	holdRepo     repository.HoldRepository
+	walletRepo   repository.WalletRepository
+	limitService *LimitService
+}
+
+func NewHoldService(holdRepo repository.HoldRepository, walletRepo repository.WalletRepository, limitService *LimitService) *HoldService {
+	return &HoldService{holdRepo: holdRepo, walletRepo: walletRepo, limitService: limitService}
+}
+
+// PlaceHold резервирует монеты на балансе пользователя на срок ttl
+func (s *HoldService) PlaceHold(ctx context.Context, userID, amount int, ttl time.Duration, reason string) (*models.Hold, error) {
+	ctx, span := tracing.Start(ctx, "HoldService.PlaceHold")
+	defer span.End()
+
+	if amount <= 0 {
+		return nil, ErrInvalidHoldAmount
+	}
+	if ttl == 0 {
+		ttl = DefaultHoldTTL
+	}
+	if ttl < 0 || ttl > MaxHoldTTL {
+		return nil, ErrInvalidHoldTTL
+	}
+
+	hold, err := s.holdRepo.CreateHold(ctx, userID, amount, reason, time.Now().Add(ttl))
+	if err != nil {
+		countInsufficientFunds(err, metrics.OperationHold)
+		return nil, err
+	}
+	return hold, nil
+}
+
+// GetHolds возвращает холды пользователя
+func (s *HoldService) GetHolds(ctx context.Context, userID int) ([]models.Hold, error) {
+	ctx, span := tracing.Start(ctx, "HoldService.GetHolds")
+	defer span.End()
+
+	return s.holdRepo.GetHolds(ctx, userID)
+}
+
+// CaptureTransfer переводит зарезервированные монеты получателю
+func (s *HoldService) CaptureTransfer(ctx context.Context, userID, holdID, toUserID, amount int) error {
+	ctx, span := tracing.Start(ctx, "HoldService.CaptureTransfer")
+	defer span.End()
+
+	if amount <= 0 || userID == toUserID {
+		return ErrInvalidCapture
+	}
+	check, err := s.limitService.TransferCheck(ctx, userID, amount)
+	if err != nil {
+		return err
+	}
+	if err := s.holdRepo.CaptureTransfer(ctx, holdID, userID, toUserID, amount, check); err != nil {
+		return err
+	}
+
+	metrics.CoinsTransferred.Add(float64(amount))
+	return nil
+}
+
+// CapturePurchase оплачивает покупку зарезервированными монетами
+func (s *HoldService) CapturePurchase(ctx context.Context, userID, holdID int, itemName string, quantity int) error {
+	ctx, span := tracing.Start(ctx, "HoldService.CapturePurchase")
+	defer span.End()
+
+	if quantity <= 0 {
+		return ErrInvalidCapture
+	}
+
+	// Неизвестный товар отклоняется по кешу цен; списывается цена, прочитанная в транзакции
+	if _, err := s.walletRepo.GetItemPrice(ctx, itemName); err != nil {
+		return err
+	}
+
+	if err := s.holdRepo.CapturePurchase(ctx, holdID, userID, itemName, quantity); err != nil {
+		return err
+	}
+
+	metrics.Purchases.WithLabelValues(itemName).Add(float64(quantity))
+	return nil
+}
+
+// Release снимает холд
+func (s *HoldService) Release(ctx context.Context, userID, holdID int) error {
+	ctx, span := tracing.Start(ctx, "HoldService.Release")
+	defer span.End()
+
+	return s.holdRepo.ReleaseHold(ctx, holdID, userID)
+}
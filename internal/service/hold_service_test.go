@@ -0,0 +1,110 @@
+package service
+
+import (
+	"avito-shop-service/internal/models"
+	"avito-shop-service/internal/repository"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// Mock HoldRepository
+type MockHoldRepository struct {
+	mock.Mock
+}
+
+func (m *MockHoldRepository) CreateHold(ctx context.Context, userID, amount int, reason string, expiresAt time.Time) (*models.Hold, error) {
+	args := m.Called(userID, amount, reason, expiresAt)
+	hold := args.Get(0)
+	if hold == nil {
+		return nil, args.Error(1)
+	}
+	return hold.(*models.Hold), args.Error(1)
+}
+
+func (m *MockHoldRepository) GetHolds(ctx context.Context, userID int) ([]models.Hold, error) {
+	args := m.Called(userID)
+	return args.Get(0).([]models.Hold), args.Error(1)
+}
+
+func (m *MockHoldRepository) CaptureTransfer(ctx context.Context, holdID, userID, toUserID, amount int, check models.TransferCheck) error {
+	args := m.Called(holdID, userID, toUserID, amount)
+	return args.Error(0)
+}
+
+func (m *MockHoldRepository) CapturePurchase(ctx context.Context, holdID, userID int, itemName string, quantity int) error {
+	args := m.Called(holdID, userID, itemName, quantity)
+	return args.Error(0)
+}
+
+func (m *MockHoldRepository) ReleaseHold(ctx context.Context, holdID, userID int) error {
+	args := m.Called(holdID, userID)
+	return args.Error(0)
+}
+
+// Создание холда со сроком по умолчанию
+func TestPlaceHoldDefaultTTL(t *testing.T) {
+	mockHolds := new(MockHoldRepository)
+	service := NewHoldService(mockHolds, new(MockWalletRepository), newUnlimitedLimitService())
+
+	hold := &models.Hold{ID: 1, UserID: 1, Amount: 300, Status: models.HoldStatusActive}
+	mockHolds.On("CreateHold", 1, 300, "offer", mock.MatchedBy(func(expiresAt time.Time) bool {
+		return time.Until(expiresAt) > DefaultHoldTTL-time.Minute
+	})).Return(hold, nil)
+
+	result, err := service.PlaceHold(context.Background(), 1, 300, 0, "offer")
+
+	assert.NoError(t, err)
+	assert.Equal(t, hold, result)
+	mockHolds.AssertExpectations(t)
+}
+
+func TestPlaceHoldValidation(t *testing.T) {
+	service := NewHoldService(new(MockHoldRepository), new(MockWalletRepository), newUnlimitedLimitService())
+
+	_, err := service.PlaceHold(context.Background(), 1, 0, time.Hour, "")
+	assert.ErrorIs(t, err, ErrInvalidHoldAmount)
+
+	_, err = service.PlaceHold(context.Background(), 1, 100, MaxHoldTTL+time.Hour, "")
+	assert.ErrorIs(t, err, ErrInvalidHoldTTL)
+}
+
+// Оплата покупки из холда
+func TestCapturePurchase(t *testing.T) {
+	mockHolds := new(MockHoldRepository)
+	mockWallet := new(MockWalletRepository)
+	service := NewHoldService(mockHolds, mockWallet, newUnlimitedLimitService())
+
+	mockWallet.On("GetItemPrice", "cup").Return(20, nil)
+	mockHolds.On("CapturePurchase", 5, 1, "cup", 3).Return(nil)
+
+	err := service.CapturePurchase(context.Background(), 1, 5, "cup", 3)
+
+	assert.NoError(t, err)
+	mockWallet.AssertExpectations(t)
+	mockHolds.AssertExpectations(t)
+}
+
+func TestCapturePurchaseUnknownItem(t *testing.T) {
+	mockHolds := new(MockHoldRepository)
+	mockWallet := new(MockWalletRepository)
+	service := NewHoldService(mockHolds, mockWallet, newUnlimitedLimitService())
+
+	mockWallet.On("GetItemPrice", "yacht").Return(0, repository.ErrItemNotFound)
+
+	err := service.CapturePurchase(context.Background(), 1, 5, "yacht", 1)
+
+	assert.ErrorIs(t, err, repository.ErrItemNotFound)
+	mockHolds.AssertNotCalled(t, "CapturePurchase", 5, 1, "yacht", 1)
+}
+
+func TestCaptureTransferToSelf(t *testing.T) {
+	service := NewHoldService(new(MockHoldRepository), new(MockWalletRepository), newUnlimitedLimitService())
+
+	err := service.CaptureTransfer(context.Background(), 1, 5, 1, 100)
+
+	assert.ErrorIs(t, err, ErrInvalidCapture)
+}
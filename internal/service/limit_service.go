@@ -0,0 +1,110 @@
+package service
+
+import (
+	"avito-shop-service/internal/models"
+	"avito-shop-service/internal/repository"
+	"avito-shop-service/internal/tracing"
+	"context"
+	"errors"
+)
+
+var (
+	ErrTransferAmountLimit  = errors.New("transfer amount exceeds limit")
+	ErrDailyVolumeLimit     = errors.New("daily transfer volume limit exceeded")
+	ErrHourlyTransferLimit  = errors.New("hourly transfer count limit exceeded")
+	ErrDailyRecipientsLimit = errors.New("daily distinct recipients limit exceeded")
+	ErrInvalidLimits        = errors.New("limits cannot be negative")
+)
+
+type LimitService struct {
+	limitRepo repository.LimitRepository
+	defaults  models.TransferLimits
+}
+
+func NewLimitService(limitRepo repository.LimitRepository, defaults models.TransferLimits) *LimitService {
+	return &LimitService{limitRepo: limitRepo, defaults: defaults}
+}
+
+// GetLimits возвращает действующие лимиты пользователя с учетом индивидуальных переопределений
+func (s *LimitService) GetLimits(ctx context.Context, userID int) (models.TransferLimits, error) {
+	ctx, span := tracing.Start(ctx, "LimitService.GetLimits")
+	defer span.End()
+
+	override, err := s.limitRepo.GetOverride(ctx, userID)
+	if err != nil {
+		return models.TransferLimits{}, err
+	}
+
+	limits := s.defaults
+	if override == nil {
+		return limits, nil
+	}
+	if override.MaxAmount != nil {
+		limits.MaxAmount = *override.MaxAmount
+	}
+	if override.MaxDailyVolume != nil {
+		limits.MaxDailyVolume = *override.MaxDailyVolume
+	}
+	if override.MaxPerHour != nil {
+		limits.MaxPerHour = *override.MaxPerHour
+	}
+	if override.MaxRecipientsPerDay != nil {
+		limits.MaxRecipientsPerDay = *override.MaxRecipientsPerDay
+	}
+	return limits, nil
+}
+
+// TransferCheck проверяет лимит суммы перевода и возвращает проверку скоростных лимитов,
+// которую репозиторий выполняет в транзакции перевода (nil, если скоростные лимиты не заданы)
+func (s *LimitService) TransferCheck(ctx context.Context, fromUserID, amount int) (models.TransferCheck, error) {
+	ctx, span := tracing.Start(ctx, "LimitService.TransferCheck")
+	defer span.End()
+
+	limits, err := s.GetLimits(ctx, fromUserID)
+	if err != nil {
+		return nil, err
+	}
+
+	if limits.MaxAmount > 0 && amount > limits.MaxAmount {
+		return nil, ErrTransferAmountLimit
+	}
+
+	// Статистика запрашивается, только если задан хотя бы один скоростной лимит
+	if limits.MaxDailyVolume == 0 && limits.MaxPerHour == 0 && limits.MaxRecipientsPerDay == 0 {
+		return nil, nil
+	}
+
+	return func(stats models.TransferStats) error {
+		if limits.MaxDailyVolume > 0 && stats.DailyVolume+amount > limits.MaxDailyVolume {
+			return ErrDailyVolumeLimit
+		}
+		if limits.MaxPerHour > 0 && stats.HourlyCount >= limits.MaxPerHour {
+			return ErrHourlyTransferLimit
+		}
+		if limits.MaxRecipientsPerDay > 0 && !stats.RecipientSeenToday && stats.DailyRecipients >= limits.MaxRecipientsPerDay {
+			return ErrDailyRecipientsLimit
+		}
+		return nil
+	}, nil
+}
+
+// SetOverride задает индивидуальные лимиты пользователя
+func (s *LimitService) SetOverride(ctx context.Context, adminID, userID int, override models.TransferLimitOverride) error {
+	ctx, span := tracing.Start(ctx, "LimitService.SetOverride")
+	defer span.End()
+
+	for _, v := range []*int{override.MaxAmount, override.MaxDailyVolume, override.MaxPerHour, override.MaxRecipientsPerDay} {
+		if v != nil && *v < 0 {
+			return ErrInvalidLimits
+		}
+	}
+	return s.limitRepo.SetOverride(ctx, userID, adminID, override)
+}
+
+// DeleteOverride сбрасывает индивидуальные лимиты пользователя
+func (s *LimitService) DeleteOverride(ctx context.Context, userID int) error {
+	ctx, span := tracing.Start(ctx, "LimitService.DeleteOverride")
+	defer span.End()
+
+	return s.limitRepo.DeleteOverride(ctx, userID)
+}
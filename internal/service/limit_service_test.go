@@ -0,0 +1,125 @@
+package service
+
+import (
+	"avito-shop-service/internal/models"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// Mock LimitRepository
+type MockLimitRepository struct {
+	mock.Mock
+}
+
+func (m *MockLimitRepository) GetOverride(ctx context.Context, userID int) (*models.TransferLimitOverride, error) {
+	args := m.Called(userID)
+	override := args.Get(0)
+	if override == nil {
+		return nil, args.Error(1)
+	}
+	return override.(*models.TransferLimitOverride), args.Error(1)
+}
+
+func (m *MockLimitRepository) SetOverride(ctx context.Context, userID, adminID int, override models.TransferLimitOverride) error {
+	args := m.Called(userID, adminID, override)
+	return args.Error(0)
+}
+
+func (m *MockLimitRepository) DeleteOverride(ctx context.Context, userID int) error {
+	args := m.Called(userID)
+	return args.Error(0)
+}
+
+// Сервис лимитов без ограничений и индивидуальных переопределений для тестов, не проверяющих лимиты
+func newUnlimitedLimitService() *LimitService {
+	limitRepo := new(MockLimitRepository)
+	limitRepo.On("GetOverride", mock.Anything).Return(nil, nil)
+	return NewLimitService(limitRepo, models.TransferLimits{})
+}
+
+func intPtr(v int) *int {
+	return &v
+}
+
+// Индивидуальный лимит заменяет значение по умолчанию только для заданных полей
+func TestGetLimitsMergesOverride(t *testing.T) {
+	mockRepo := new(MockLimitRepository)
+	service := NewLimitService(mockRepo, models.TransferLimits{MaxAmount: 500, MaxPerHour: 10})
+
+	mockRepo.On("GetOverride", 1).Return(&models.TransferLimitOverride{MaxAmount: intPtr(0)}, nil)
+
+	limits, err := service.GetLimits(context.Background(), 1)
+
+	assert.NoError(t, err)
+	assert.Equal(t, models.TransferLimits{MaxAmount: 0, MaxPerHour: 10}, limits)
+}
+
+func TestCheckTransferVelocityLimits(t *testing.T) {
+	defaults := models.TransferLimits{MaxDailyVolume: 1000, MaxPerHour: 5, MaxRecipientsPerDay: 3}
+
+	tests := []struct {
+		name     string
+		stats    models.TransferStats
+		amount   int
+		expected error
+	}{
+		{"within limits", models.TransferStats{HourlyCount: 1, DailyVolume: 100, DailyRecipients: 1}, 100, nil},
+		{"daily volume", models.TransferStats{DailyVolume: 950}, 100, ErrDailyVolumeLimit},
+		{"hourly count", models.TransferStats{HourlyCount: 5}, 10, ErrHourlyTransferLimit},
+		{"new recipient", models.TransferStats{DailyRecipients: 3}, 10, ErrDailyRecipientsLimit},
+		{"known recipient", models.TransferStats{DailyRecipients: 3, RecipientSeenToday: true}, 10, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(MockLimitRepository)
+			service := NewLimitService(mockRepo, defaults)
+
+			mockRepo.On("GetOverride", 1).Return(nil, nil)
+
+			check, err := service.TransferCheck(context.Background(), 1, tt.amount)
+			assert.NoError(t, err)
+			err = check(tt.stats)
+
+			if tt.expected == nil {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, tt.expected)
+			}
+		})
+	}
+}
+
+// Без скоростных лимитов статистика в транзакции перевода не читается
+func TestTransferCheckWithoutVelocityLimits(t *testing.T) {
+	mockRepo := new(MockLimitRepository)
+	service := NewLimitService(mockRepo, models.TransferLimits{MaxAmount: 500})
+
+	mockRepo.On("GetOverride", 1).Return(nil, nil)
+
+	check, err := service.TransferCheck(context.Background(), 1, 100)
+	assert.NoError(t, err)
+	assert.Nil(t, check)
+
+	_, err = service.TransferCheck(context.Background(), 1, 600)
+	assert.ErrorIs(t, err, ErrTransferAmountLimit)
+}
+
+// Сервис для тестов без лимитов пропускает любой перевод
+func TestUnlimitedLimitService(t *testing.T) {
+	check, err := newUnlimitedLimitService().TransferCheck(context.Background(), 1, 1_000_000)
+
+	assert.NoError(t, err)
+	assert.Nil(t, check)
+}
+
+func TestSetOverrideRejectsNegative(t *testing.T) {
+	service := newUnlimitedLimitService()
+
+	err := service.SetOverride(context.Background(), 1, 2, models.TransferLimitOverride{MaxPerHour: intPtr(-1)})
+
+	assert.ErrorIs(t, err, ErrInvalidLimits)
+}
@@ -0,0 +1,471 @@
+package service
+
+import (
+	"avito-shop-service/internal/models"
+	"avito-shop-service/internal/repository"
+	"avito-shop-service/internal/tracing"
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+)
+
+const (
+	OIDCLoginStateTTL = 10 * time.Minute
+
+	// Не чаще этого интервала неизвестный kid приводит к повторной загрузке JWKS
+	oidcJWKSRefetchInterval = time.Minute
+)
+
+var (
+	ErrOIDCDisabled    = errors.New("single sign-on is not configured")
+	ErrOIDCLoginFailed = errors.New("single sign-on login failed")
+	// Провайдер недоступен или ответил некорректно
+	ErrOIDCProvider = errors.New("single sign-on provider is unavailable")
+)
+
+// Настройки клиента OpenID Connect. Пустой Issuer отключает вход через OIDC.
+type OIDCConfig struct {
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	Scopes       []string
+}
+
+// Метаданные провайдера из /.well-known/openid-configuration
+type oidcDiscovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+type OIDCService struct {
+	cfg          OIDCConfig
+	identityRepo repository.IdentityRepository
+	authService  *AuthService
+	client       *http.Client
+
+	// mu защищает только кеш: запросы к провайдеру выполняются без блокировки
+	mu            sync.Mutex
+	discovery     *oidcDiscovery
+	keys          map[string]*rsa.PublicKey
+	keysFetchedAt time.Time
+	keysFetch     chan struct{} // закрывается по окончании текущей загрузки JWKS
+	now           func() time.Time
+}
+
+func NewOIDCService(cfg OIDCConfig, identityRepo repository.IdentityRepository, authService *AuthService) *OIDCService {
+	if len(cfg.Scopes) == 0 {
+		cfg.Scopes = []string{"openid", "profile", "email"}
+	}
+	return &OIDCService{
+		cfg:          cfg,
+		identityRepo: identityRepo,
+		authService:  authService,
+		client:       &http.Client{Timeout: 10 * time.Second},
+		now:          time.Now,
+	}
+}
+
+// AuthorizationURL начинает вход: сохраняет state, PKCE code_verifier и nonce
+// и возвращает адрес страницы входа провайдера и state, который нужно привязать к браузеру
+func (s *OIDCService) AuthorizationURL(ctx context.Context) (string, string, error) {
+	ctx, span := tracing.Start(ctx, "OIDCService.AuthorizationURL")
+	defer span.End()
+
+	if s.cfg.Issuer == "" {
+		return "", "", ErrOIDCDisabled
+	}
+
+	discovery, err := s.getDiscovery(ctx)
+	if err != nil {
+		return "", "", err
+	}
+
+	loginState := &models.OIDCLoginState{ExpiresAt: s.now().Add(OIDCLoginStateTTL)}
+	if loginState.State, err = randomToken(32); err != nil {
+		return "", "", err
+	}
+	if loginState.CodeVerifier, err = randomToken(32); err != nil {
+		return "", "", err
+	}
+	if loginState.Nonce, err = randomToken(16); err != nil {
+		return "", "", err
+	}
+
+	if err := s.identityRepo.SaveLoginState(ctx, loginState); err != nil {
+		return "", "", err
+	}
+
+	challenge := sha256.Sum256([]byte(loginState.CodeVerifier))
+	params := url.Values{}
+	params.Set("response_type", "code")
+	params.Set("client_id", s.cfg.ClientID)
+	params.Set("redirect_uri", s.cfg.RedirectURL)
+	params.Set("scope", strings.Join(s.cfg.Scopes, " "))
+	params.Set("state", loginState.State)
+	params.Set("nonce", loginState.Nonce)
+	params.Set("code_challenge", base64.RawURLEncoding.EncodeToString(challenge[:]))
+	params.Set("code_challenge_method", "S256")
+
+	separator := "?"
+	if strings.Contains(discovery.AuthorizationEndpoint, "?") {
+		separator = "&"
+	}
+	return discovery.AuthorizationEndpoint + separator + params.Encode(), loginState.State, nil
+}
+
+// Callback завершает вход: обменивает код на ID-токен, проверяет его, находит или создает
+// пользователя, связанного с subject, и выдает собственный токен сервиса.
+// browserState - state, сохраненный в браузере при начале входа; он должен совпасть с state из запроса,
+// иначе код авторизации мог быть подставлен чужим браузером (login CSRF)
+func (s *OIDCService) Callback(ctx context.Context, state, browserState, code string, client models.ClientInfo) (*models.LoginResult, error) {
+	ctx, span := tracing.Start(ctx, "OIDCService.Callback")
+	defer span.End()
+
+	if s.cfg.Issuer == "" {
+		return nil, ErrOIDCDisabled
+	}
+
+	if browserState == "" || subtle.ConstantTimeCompare([]byte(state), []byte(browserState)) != 1 {
+		return nil, fmt.Errorf("%w: state does not belong to this browser", ErrOIDCLoginFailed)
+	}
+
+	loginState, err := s.identityRepo.ConsumeLoginState(ctx, state)
+	if err != nil {
+		return nil, err
+	}
+
+	rawIDToken, err := s.exchangeCode(ctx, code, loginState.CodeVerifier)
+	if err != nil {
+		return nil, err
+	}
+
+	claims, err := s.verifyIDToken(ctx, rawIDToken, loginState.Nonce)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := s.provisionUser(ctx, claims)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.authService.LoginUser(ctx, user, client)
+}
+
+type idTokenClaims struct {
+	Subject           string
+	Email             string
+	PreferredUsername string
+}
+
+// Обмен кода авторизации на токены с подтверждением PKCE
+func (s *OIDCService) exchangeCode(ctx context.Context, code, codeVerifier string) (string, error) {
+	discovery, err := s.getDiscovery(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("redirect_uri", s.cfg.RedirectURL)
+	form.Set("client_id", s.cfg.ClientID)
+	form.Set("code_verifier", codeVerifier)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, discovery.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if s.cfg.ClientSecret != "" {
+		req.SetBasicAuth(url.QueryEscape(s.cfg.ClientID), url.QueryEscape(s.cfg.ClientSecret))
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return "", providerError(ctx, "token request", err)
+	}
+	defer resp.Body.Close()
+
+	// Ошибка клиента (например, просроченный код) - неудачный вход, ошибка сервера - сбой провайдера
+	if resp.StatusCode >= http.StatusInternalServerError {
+		return "", fmt.Errorf("%w: token endpoint returned %s", ErrOIDCProvider, resp.Status)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("%w: token endpoint returned %s", ErrOIDCLoginFailed, resp.Status)
+	}
+
+	var tokens struct {
+		IDToken string `json:"id_token"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&tokens); err != nil {
+		return "", fmt.Errorf("%w: decode token response: %v", ErrOIDCProvider, err)
+	}
+	if tokens.IDToken == "" {
+		return "", fmt.Errorf("%w: no id_token in token response", ErrOIDCLoginFailed)
+	}
+	return tokens.IDToken, nil
+}
+
+// Проверка подписи и утверждений ID-токена
+func (s *OIDCService) verifyIDToken(ctx context.Context, rawIDToken, nonce string) (*idTokenClaims, error) {
+	// Ошибка получения ключа возвращается как есть: сбой провайдера не должен выглядеть как неверный токен
+	var keyErr error
+	token, err := jwt.Parse(rawIDToken, func(t *jwt.Token) (interface{}, error) {
+		if t.Method.Alg() != jwt.SigningMethodRS256.Alg() {
+			return nil, fmt.Errorf("unexpected signing algorithm %s", t.Method.Alg())
+		}
+		kid, _ := t.Header["kid"].(string)
+		var key *rsa.PublicKey
+		key, keyErr = s.getKey(ctx, kid)
+		return key, keyErr
+	})
+	if keyErr != nil {
+		return nil, keyErr
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrOIDCLoginFailed, err)
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok || !token.Valid {
+		return nil, fmt.Errorf("%w: invalid id_token", ErrOIDCLoginFailed)
+	}
+
+	if iss, _ := claims["iss"].(string); iss != s.cfg.Issuer {
+		return nil, fmt.Errorf("%w: unexpected issuer %q", ErrOIDCLoginFailed, iss)
+	}
+	if !audienceContains(claims["aud"], s.cfg.ClientID) {
+		return nil, fmt.Errorf("%w: id_token was issued for another client", ErrOIDCLoginFailed)
+	}
+	if _, ok := claims["exp"]; !ok {
+		return nil, fmt.Errorf("%w: id_token has no expiration", ErrOIDCLoginFailed)
+	}
+	if tokenNonce, _ := claims["nonce"].(string); tokenNonce != nonce {
+		return nil, fmt.Errorf("%w: nonce mismatch", ErrOIDCLoginFailed)
+	}
+
+	result := &idTokenClaims{}
+	result.Subject, _ = claims["sub"].(string)
+	result.Email, _ = claims["email"].(string)
+	result.PreferredUsername, _ = claims["preferred_username"].(string)
+	if result.Subject == "" {
+		return nil, fmt.Errorf("%w: id_token has no subject", ErrOIDCLoginFailed)
+	}
+	return result, nil
+}
+
+// Находит пользователя по subject или создает нового при первом входе
+func (s *OIDCService) provisionUser(ctx context.Context, claims *idTokenClaims) (*models.User, error) {
+	user, err := s.identityRepo.FindUserByIdentity(ctx, s.cfg.Issuer, claims.Subject)
+	if err != nil {
+		return nil, err
+	}
+	if user != nil {
+		return user, nil
+	}
+
+	identity := &models.Identity{Issuer: s.cfg.Issuer, Subject: claims.Subject, Email: claims.Email}
+
+	// Пароль не задается: вход по паролю для такого пользователя невозможен
+	user = &models.User{Username: ssoUsername(claims), Coins: initialCoins}
+	err = s.identityRepo.CreateUserWithIdentity(ctx, user, identity)
+	if errors.Is(err, repository.ErrUsernameTaken) {
+		// Имя занято другим пользователем: связывать учетные записи по имени небезопасно,
+		// поэтому к имени добавляется суффикс, устойчивый для данного subject
+		sum := sha256.Sum256([]byte(s.cfg.Issuer + "|" + claims.Subject))
+		user.Username += "-" + hex.EncodeToString(sum[:4])
+		err = s.identityRepo.CreateUserWithIdentity(ctx, user, identity)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+func ssoUsername(claims *idTokenClaims) string {
+	switch {
+	case claims.PreferredUsername != "":
+		return claims.PreferredUsername
+	case claims.Email != "":
+		return claims.Email
+	default:
+		return "sso-" + claims.Subject
+	}
+}
+
+func audienceContains(aud interface{}, clientID string) bool {
+	switch v := aud.(type) {
+	case string:
+		return v == clientID
+	case []interface{}:
+		for _, a := range v {
+			if a == clientID {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (s *OIDCService) getDiscovery(ctx context.Context) (*oidcDiscovery, error) {
+	s.mu.Lock()
+	cached := s.discovery
+	s.mu.Unlock()
+	if cached != nil {
+		return cached, nil
+	}
+
+	var discovery oidcDiscovery
+	if err := s.getJSON(ctx, strings.TrimSuffix(s.cfg.Issuer, "/")+"/.well-known/openid-configuration", &discovery); err != nil {
+		return nil, fmt.Errorf("oidc discovery: %w", err)
+	}
+	if discovery.Issuer != s.cfg.Issuer {
+		return nil, fmt.Errorf("%w: oidc discovery: issuer mismatch %q", ErrOIDCProvider, discovery.Issuer)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.discovery == nil {
+		s.discovery = &discovery
+	}
+	return s.discovery, nil
+}
+
+// Ключ подписи по kid. Неизвестный kid приводит к повторной загрузке JWKS (ротация ключей),
+// но не чаще oidcJWKSRefetchInterval. Одновременно идет не больше одной загрузки,
+// остальные запросы с неизвестным kid ждут ее результата
+func (s *OIDCService) getKey(ctx context.Context, kid string) (*rsa.PublicKey, error) {
+	discovery, err := s.getDiscovery(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		s.mu.Lock()
+		if key, ok := s.keys[kid]; ok {
+			s.mu.Unlock()
+			return key, nil
+		}
+		if fetch := s.keysFetch; fetch != nil {
+			s.mu.Unlock()
+			select {
+			case <-fetch:
+				continue
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			}
+		}
+		if !s.keysFetchedAt.IsZero() && s.now().Sub(s.keysFetchedAt) < oidcJWKSRefetchInterval {
+			s.mu.Unlock()
+			return nil, fmt.Errorf("%w: unknown signing key %q", ErrOIDCLoginFailed, kid)
+		}
+		fetch := make(chan struct{})
+		s.keysFetch = fetch
+		s.mu.Unlock()
+
+		keys, err := s.fetchKeys(ctx, discovery.JWKSURI)
+
+		s.mu.Lock()
+		if err == nil {
+			s.keys = keys
+			s.keysFetchedAt = s.now()
+		}
+		s.keysFetch = nil
+		close(fetch)
+		s.mu.Unlock()
+
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+func (s *OIDCService) fetchKeys(ctx context.Context, jwksURI string) (map[string]*rsa.PublicKey, error) {
+	var jwks struct {
+		Keys []struct {
+			Kty string `json:"kty"`
+			Kid string `json:"kid"`
+			N   string `json:"n"`
+			E   string `json:"e"`
+		} `json:"keys"`
+	}
+	if err := s.getJSON(ctx, jwksURI, &jwks); err != nil {
+		return nil, fmt.Errorf("fetch jwks: %w", err)
+	}
+
+	keys := make(map[string]*rsa.PublicKey, len(jwks.Keys))
+	for _, k := range jwks.Keys {
+		if k.Kty != "RSA" {
+			continue
+		}
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			continue
+		}
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil {
+			continue
+		}
+		keys[k.Kid] = &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+	}
+	return keys, nil
+}
+
+// GET-запрос к провайдеру. Сбои соединения и некорректные ответы оборачиваются в ErrOIDCProvider
+func (s *OIDCService) getJSON(ctx context.Context, url string, target interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return providerError(ctx, "request", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%w: unexpected status %s", ErrOIDCProvider, resp.Status)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(target); err != nil {
+		return fmt.Errorf("%w: %v", ErrOIDCProvider, err)
+	}
+	return nil
+}
+
+// Ошибка запроса к провайдеру. Отмена или истечение срока запроса клиента - не сбой провайдера
+func providerError(ctx context.Context, op string, err error) error {
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return ctxErr
+	}
+	return fmt.Errorf("%w: %s: %v", ErrOIDCProvider, op, err)
+}
+
+// Случайная строка из n байт в base64url
+func randomToken(n int) (string, error) {
+	raw := make([]byte, n)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(raw), nil
+}
@@ -0,0 +1,426 @@
+package service
+
+import (
+	"avito-shop-service/internal/models"
+	"avito-shop-service/internal/repository"
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// Mock IdentityRepository
+type MockIdentityRepository struct {
+	mock.Mock
+}
+
+func (m *MockIdentityRepository) SaveLoginState(ctx context.Context, state *models.OIDCLoginState) error {
+	args := m.Called(state)
+	return args.Error(0)
+}
+
+func (m *MockIdentityRepository) ConsumeLoginState(ctx context.Context, state string) (*models.OIDCLoginState, error) {
+	args := m.Called(state)
+	loginState := args.Get(0)
+	if loginState == nil {
+		return nil, args.Error(1)
+	}
+	return loginState.(*models.OIDCLoginState), args.Error(1)
+}
+
+func (m *MockIdentityRepository) FindUserByIdentity(ctx context.Context, issuer, subject string) (*models.User, error) {
+	args := m.Called(issuer, subject)
+	user := args.Get(0)
+	if user == nil {
+		return nil, args.Error(1)
+	}
+	return user.(*models.User), args.Error(1)
+}
+
+func (m *MockIdentityRepository) CreateUserWithIdentity(ctx context.Context, user *models.User, identity *models.Identity) error {
+	args := m.Called(user, identity)
+	return args.Error(0)
+}
+
+// Локальный провайдер OIDC: discovery, JWKS и token endpoint с проверкой PKCE
+type fakeIdP struct {
+	server   *httptest.Server
+	key      *rsa.PrivateKey
+	clientID string
+	secret   string
+	subject  string
+
+	mu    sync.Mutex
+	codes map[string]url.Values // code -> параметры запроса авторизации
+
+	jwksRequests atomic.Int64
+	jwksGate     chan struct{} // если задан, ответ JWKS ждет значения из канала
+}
+
+func newFakeIdP(t *testing.T, clientID, secret, subject string) *fakeIdP {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	idp := &fakeIdP{key: key, clientID: clientID, secret: secret, subject: subject, codes: map[string]url.Values{}}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, _ *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 idp.server.URL,
+			"authorization_endpoint": idp.server.URL + "/authorize",
+			"token_endpoint":         idp.server.URL + "/token",
+			"jwks_uri":               idp.server.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, _ *http.Request) {
+		idp.jwksRequests.Add(1)
+		if idp.jwksGate != nil {
+			<-idp.jwksGate
+		}
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"keys": []map[string]string{{
+				"kty": "RSA",
+				"kid": "test-key",
+				"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+			}},
+		})
+	})
+	mux.HandleFunc("/token", idp.token)
+
+	idp.server = httptest.NewServer(mux)
+	t.Cleanup(idp.server.Close)
+	return idp
+}
+
+// Имитирует вход пользователя на странице провайдера и возвращает код авторизации
+func (idp *fakeIdP) authorize(t *testing.T, authURL string) string {
+	parsed, err := url.Parse(authURL)
+	require.NoError(t, err)
+
+	idp.mu.Lock()
+	defer idp.mu.Unlock()
+	code := "code-" + parsed.Query().Get("state")
+	idp.codes[code] = parsed.Query()
+	return code
+}
+
+func (idp *fakeIdP) token(w http.ResponseWriter, r *http.Request) {
+	clientID, secret, ok := r.BasicAuth()
+	if !ok || clientID != idp.clientID || secret != idp.secret {
+		http.Error(w, "invalid_client", http.StatusUnauthorized)
+		return
+	}
+	_ = r.ParseForm()
+
+	idp.mu.Lock()
+	authRequest, ok := idp.codes[r.PostForm.Get("code")]
+	delete(idp.codes, r.PostForm.Get("code"))
+	idp.mu.Unlock()
+
+	challenge := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+	if !ok || authRequest.Get("code_challenge") != base64.RawURLEncoding.EncodeToString(challenge[:]) {
+		http.Error(w, "invalid_grant", http.StatusBadRequest)
+		return
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+		"iss":                idp.server.URL,
+		"aud":                idp.clientID,
+		"sub":                idp.subject,
+		"nonce":              authRequest.Get("nonce"),
+		"preferred_username": "alice",
+		"email":              "alice@example.com",
+		"exp":                time.Now().Add(time.Minute).Unix(),
+	})
+	token.Header["kid"] = "test-key"
+	idToken, _ := token.SignedString(idp.key)
+
+	_ = json.NewEncoder(w).Encode(map[string]string{"access_token": "opaque", "id_token": idToken})
+}
+
+func newTestOIDCService(idp *fakeIdP, identityRepo *MockIdentityRepository) *OIDCService {
+	authService := NewAuthService(new(MockUserRepository), "supersecretkey", &PasswordPolicy{}, NewBcryptHasher(0), &noTwoFactor{}, noSessions{}, NewUserStateCache(0))
+	return NewOIDCService(OIDCConfig{
+		Issuer:       idp.server.URL,
+		ClientID:     idp.clientID,
+		ClientSecret: idp.secret,
+		RedirectURL:  "http://shop.local/api/auth/oidc/callback",
+	}, identityRepo, authService)
+}
+
+type noTwoFactor struct{}
+
+func (noTwoFactor) IsEnabled(_ context.Context, _ int) (bool, error) { return false, nil }
+func (noTwoFactor) Verify(_ context.Context, _ int, _ string) error  { return ErrInvalidTOTPCode }
+
+type noSessions struct{}
+
+func (noSessions) Create(_ context.Context, _ int, _ models.ClientInfo, _ time.Time) (string, error) {
+	return "sid", nil
+}
+func (noSessions) Validate(_ context.Context, _ string, _ int) error { return nil }
+
+// При первом входе пользователь создается и связывается с subject; имя занято - добавляется суффикс
+func TestOIDCLoginProvisionsUser(t *testing.T) {
+	idp := newFakeIdP(t, "shop", "secret", "employee-42")
+	identityRepo := new(MockIdentityRepository)
+	service := newTestOIDCService(idp, identityRepo)
+
+	var saved *models.OIDCLoginState
+	identityRepo.On("SaveLoginState", mock.Anything).Run(func(args mock.Arguments) {
+		saved = args.Get(0).(*models.OIDCLoginState)
+	}).Return(nil)
+
+	authURL, state, err := service.AuthorizationURL(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, saved.State, state)
+
+	parsed, _ := url.Parse(authURL)
+	query := parsed.Query()
+	assert.Equal(t, idp.server.URL+"/authorize", parsed.Scheme+"://"+parsed.Host+parsed.Path)
+	assert.Equal(t, "S256", query.Get("code_challenge_method"))
+	assert.Equal(t, saved.State, query.Get("state"))
+	assert.NotContains(t, authURL, saved.CodeVerifier)
+
+	code := idp.authorize(t, authURL)
+
+	identityRepo.On("ConsumeLoginState", saved.State).Return(saved, nil)
+	identityRepo.On("FindUserByIdentity", idp.server.URL, "employee-42").Return(nil, nil)
+	identityRepo.On("CreateUserWithIdentity", mock.MatchedBy(func(u *models.User) bool { return u.Username == "alice" }), mock.Anything).
+		Return(repository.ErrUsernameTaken).Once()
+	identityRepo.On("CreateUserWithIdentity", mock.MatchedBy(func(u *models.User) bool { return u.Username != "alice" }), mock.Anything).
+		Run(func(args mock.Arguments) {
+			user := args.Get(0).(*models.User)
+			user.ID, user.Status = 7, models.StatusActive
+			identity := args.Get(1).(*models.Identity)
+			assert.Equal(t, "employee-42", identity.Subject)
+			assert.Equal(t, "alice@example.com", identity.Email)
+			assert.Empty(t, user.PasswordHash)
+		}).Return(nil)
+
+	result, err := service.Callback(context.Background(), saved.State, saved.State, code, models.ClientInfo{})
+
+	require.NoError(t, err)
+	assert.NotEmpty(t, result.Token)
+	identityRepo.AssertExpectations(t)
+}
+
+// Повторный вход находит пользователя по subject, новый не создается
+func TestOIDCLoginExistingUser(t *testing.T) {
+	idp := newFakeIdP(t, "shop", "secret", "employee-42")
+	identityRepo := new(MockIdentityRepository)
+	service := newTestOIDCService(idp, identityRepo)
+
+	state := &models.OIDCLoginState{State: "s1", CodeVerifier: "verifier", Nonce: "n1"}
+	challenge := sha256.Sum256([]byte("verifier"))
+	code := idp.authorize(t, idp.server.URL+"/authorize?state=s1&nonce=n1&code_challenge="+
+		base64.RawURLEncoding.EncodeToString(challenge[:]))
+
+	identityRepo.On("ConsumeLoginState", "s1").Return(state, nil)
+	identityRepo.On("FindUserByIdentity", idp.server.URL, "employee-42").
+		Return(&models.User{ID: 3, Username: "alice", Status: models.StatusActive}, nil)
+
+	result, err := service.Callback(context.Background(), "s1", "s1", code, models.ClientInfo{})
+
+	require.NoError(t, err)
+	assert.NotEmpty(t, result.Token)
+	identityRepo.AssertNotCalled(t, "CreateUserWithIdentity", mock.Anything, mock.Anything)
+}
+
+// ID-токен с чужим nonce отклоняется
+func TestOIDCLoginNonceMismatch(t *testing.T) {
+	idp := newFakeIdP(t, "shop", "secret", "employee-42")
+	identityRepo := new(MockIdentityRepository)
+	service := newTestOIDCService(idp, identityRepo)
+
+	challenge := sha256.Sum256([]byte("verifier"))
+	code := idp.authorize(t, idp.server.URL+"/authorize?state=s1&nonce=other&code_challenge="+
+		base64.RawURLEncoding.EncodeToString(challenge[:]))
+
+	identityRepo.On("ConsumeLoginState", "s1").Return(&models.OIDCLoginState{State: "s1", CodeVerifier: "verifier", Nonce: "n1"}, nil)
+
+	_, err := service.Callback(context.Background(), "s1", "s1", code, models.ClientInfo{})
+
+	assert.ErrorIs(t, err, ErrOIDCLoginFailed)
+}
+
+// Без правильного code_verifier провайдер не выдает токены
+func TestOIDCLoginPKCEMismatch(t *testing.T) {
+	idp := newFakeIdP(t, "shop", "secret", "employee-42")
+	identityRepo := new(MockIdentityRepository)
+	service := newTestOIDCService(idp, identityRepo)
+
+	challenge := sha256.Sum256([]byte("verifier"))
+	code := idp.authorize(t, idp.server.URL+"/authorize?state=s1&nonce=n1&code_challenge="+
+		base64.RawURLEncoding.EncodeToString(challenge[:]))
+
+	identityRepo.On("ConsumeLoginState", "s1").Return(&models.OIDCLoginState{State: "s1", CodeVerifier: "stolen", Nonce: "n1"}, nil)
+
+	_, err := service.Callback(context.Background(), "s1", "s1", code, models.ClientInfo{})
+
+	assert.ErrorIs(t, err, ErrOIDCLoginFailed)
+}
+
+func TestOIDCDisabled(t *testing.T) {
+	service := NewOIDCService(OIDCConfig{}, new(MockIdentityRepository), nil)
+
+	_, _, err := service.AuthorizationURL(context.Background())
+	assert.ErrorIs(t, err, ErrOIDCDisabled)
+}
+
+// Недоступный провайдер - сбой провайдера, а не неудачный вход
+func TestOIDCProviderUnavailable(t *testing.T) {
+	idp := newFakeIdP(t, "shop", "secret", "employee-42")
+	service := newTestOIDCService(idp, new(MockIdentityRepository))
+	idp.server.Close()
+
+	_, _, err := service.AuthorizationURL(context.Background())
+
+	assert.ErrorIs(t, err, ErrOIDCProvider)
+	assert.NotErrorIs(t, err, ErrOIDCLoginFailed)
+}
+
+// Срок действия state отсчитывается по часам сервиса
+func TestOIDCLoginStateExpiry(t *testing.T) {
+	idp := newFakeIdP(t, "shop", "secret", "employee-42")
+	identityRepo := new(MockIdentityRepository)
+	service := newTestOIDCService(idp, identityRepo)
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	service.now = func() time.Time { return now }
+
+	identityRepo.On("SaveLoginState", mock.MatchedBy(func(state *models.OIDCLoginState) bool {
+		return state.ExpiresAt.Equal(now.Add(OIDCLoginStateTTL))
+	})).Return(nil)
+
+	_, _, err := service.AuthorizationURL(context.Background())
+
+	require.NoError(t, err)
+	identityRepo.AssertExpectations(t)
+}
+
+// Код авторизации, полученный в другом браузере (без cookie со state), не принимается
+func TestOIDCLoginStateNotBoundToBrowser(t *testing.T) {
+	idp := newFakeIdP(t, "shop", "secret", "employee-42")
+	identityRepo := new(MockIdentityRepository)
+	service := newTestOIDCService(idp, identityRepo)
+
+	for _, browserState := range []string{"", "s2"} {
+		_, err := service.Callback(context.Background(), "s1", browserState, "code-s1", models.ClientInfo{})
+		assert.ErrorIs(t, err, ErrOIDCLoginFailed)
+	}
+	// State злоумышленника не расходуется
+	identityRepo.AssertNotCalled(t, "ConsumeLoginState", mock.Anything)
+}
+
+// Неизвестный kid не приводит к загрузке JWKS чаще oidcJWKSRefetchInterval
+func TestOIDCUnknownKeyRefetchThrottled(t *testing.T) {
+	idp := newFakeIdP(t, "shop", "secret", "employee-42")
+	service := newTestOIDCService(idp, new(MockIdentityRepository))
+	now := time.Now()
+	service.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	_, err := service.getKey(ctx, "test-key")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), idp.jwksRequests.Load())
+
+	for i := 0; i < 3; i++ {
+		_, err = service.getKey(ctx, "forged-key")
+		assert.ErrorIs(t, err, ErrOIDCLoginFailed)
+	}
+	assert.Equal(t, int64(1), idp.jwksRequests.Load())
+
+	// По истечении интервала ключи загружаются заново (ротация у провайдера)
+	now = now.Add(oidcJWKSRefetchInterval)
+	_, err = service.getKey(ctx, "forged-key")
+	assert.Error(t, err)
+	assert.Equal(t, int64(2), idp.jwksRequests.Load())
+
+	_, err = service.getKey(ctx, "test-key")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), idp.jwksRequests.Load())
+}
+
+// Одновременные входы при пустом кеше загружают JWKS один раз
+func TestOIDCConcurrentKeyFetch(t *testing.T) {
+	idp := newFakeIdP(t, "shop", "secret", "employee-42")
+	idp.jwksGate = make(chan struct{})
+	service := newTestOIDCService(idp, new(MockIdentityRepository))
+	_, err := service.getDiscovery(context.Background())
+	require.NoError(t, err)
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 10)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := service.getKey(context.Background(), "test-key")
+			errs <- err
+		}()
+	}
+
+	require.Eventually(t, func() bool { return idp.jwksRequests.Load() == 1 }, time.Second, time.Millisecond)
+	close(idp.jwksGate)
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		assert.NoError(t, err)
+	}
+	assert.Equal(t, int64(1), idp.jwksRequests.Load())
+}
+
+// Медленная загрузка JWKS не задерживает проверку токенов уже известными ключами
+func TestOIDCSlowKeyFetchDoesNotBlockCachedKeys(t *testing.T) {
+	idp := newFakeIdP(t, "shop", "secret", "employee-42")
+	service := newTestOIDCService(idp, new(MockIdentityRepository))
+	now := time.Now()
+	var nowMu sync.Mutex
+	service.now = func() time.Time {
+		nowMu.Lock()
+		defer nowMu.Unlock()
+		return now
+	}
+
+	_, err := service.getKey(context.Background(), "test-key")
+	require.NoError(t, err)
+
+	idp.jwksGate = make(chan struct{})
+	defer close(idp.jwksGate)
+	nowMu.Lock()
+	now = now.Add(oidcJWKSRefetchInterval)
+	nowMu.Unlock()
+
+	go func() { _, _ = service.getKey(context.Background(), "rotated-key") }()
+	require.Eventually(t, func() bool { return idp.jwksRequests.Load() == 2 }, time.Second, time.Millisecond)
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := service.getKey(context.Background(), "test-key")
+		done <- err
+	}()
+
+	select {
+	case err := <-done:
+		assert.NoError(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("cached key lookup waited for the JWKS request")
+	}
+}
@@ -0,0 +1,246 @@
+package service
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	HashAlgorithmBcrypt   = "bcrypt"
+	HashAlgorithmArgon2id = "argon2id"
+)
+
+var (
+	ErrUnknownHashAlgorithm = errors.New("unknown password hash algorithm")
+	ErrMalformedHash        = errors.New("malformed password hash")
+)
+
+// PasswordHasher хеширует и проверяет пароли. Хеши хранятся в формате PHC ($id$params$salt$hash),
+// bcrypt использует собственную совместимую запись ($2a$cost$...).
+type PasswordHasher interface {
+	// Hash возвращает закодированный хеш пароля
+	Hash(password string) (string, error)
+	// Verify проверяет пароль по хешу. Для хешей чужого формата возвращает ErrMalformedHash.
+	Verify(encoded, password string) (bool, error)
+	// Supports сообщает, что хеш записан в формате этого алгоритма
+	Supports(encoded string) bool
+	// NeedsRehash сообщает, что хеш получен с устаревшими параметрами или другим алгоритмом
+	NeedsRehash(encoded string) bool
+}
+
+// BcryptHasher хеширует пароли bcrypt с заданной стоимостью
+type BcryptHasher struct {
+	Cost int
+}
+
+// NewBcryptHasher создает хешер bcrypt; стоимость вне допустимого диапазона заменяется стандартной
+func NewBcryptHasher(cost int) *BcryptHasher {
+	if cost < bcrypt.MinCost || cost > bcrypt.MaxCost {
+		cost = bcrypt.DefaultCost
+	}
+	return &BcryptHasher{Cost: cost}
+}
+
+func (h *BcryptHasher) Hash(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), h.Cost)
+	if err != nil {
+		return "", err
+	}
+	return string(hash), nil
+}
+
+func (h *BcryptHasher) Verify(encoded, password string) (bool, error) {
+	if !h.Supports(encoded) {
+		return false, ErrMalformedHash
+	}
+
+	err := bcrypt.CompareHashAndPassword([]byte(encoded), []byte(password))
+	switch {
+	case err == nil:
+		return true, nil
+	case errors.Is(err, bcrypt.ErrMismatchedHashAndPassword):
+		return false, nil
+	default:
+		return false, ErrMalformedHash
+	}
+}
+
+func (h *BcryptHasher) Supports(encoded string) bool {
+	return strings.HasPrefix(encoded, "$2a$") || strings.HasPrefix(encoded, "$2b$") || strings.HasPrefix(encoded, "$2y$")
+}
+
+func (h *BcryptHasher) NeedsRehash(encoded string) bool {
+	if !h.Supports(encoded) {
+		return true
+	}
+	cost, err := bcrypt.Cost([]byte(encoded))
+	return err != nil || cost != h.Cost
+}
+
+// Argon2idHasher хеширует пароли argon2id. Memory задается в КиБ.
+type Argon2idHasher struct {
+	Memory      uint32
+	Iterations  uint32
+	Parallelism uint8
+	SaltLength  uint32
+	KeyLength   uint32
+}
+
+// NewArgon2idHasher создает хешер argon2id; нулевые параметры заменяются рекомендованными OWASP
+func NewArgon2idHasher(memory, iterations uint32, parallelism uint8) *Argon2idHasher {
+	if memory == 0 {
+		memory = 64 * 1024
+	}
+	if iterations == 0 {
+		iterations = 3
+	}
+	if parallelism == 0 {
+		parallelism = 2
+	}
+	return &Argon2idHasher{Memory: memory, Iterations: iterations, Parallelism: parallelism, SaltLength: 16, KeyLength: 32}
+}
+
+type argon2idParams struct {
+	memory      uint32
+	iterations  uint32
+	parallelism uint8
+	salt        []byte
+	key         []byte
+}
+
+func (h *Argon2idHasher) Hash(password string) (string, error) {
+	salt := make([]byte, h.SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+
+	key := argon2.IDKey([]byte(password), salt, h.Iterations, h.Memory, h.Parallelism, h.KeyLength)
+
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version, h.Memory, h.Iterations, h.Parallelism,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	), nil
+}
+
+func (h *Argon2idHasher) Verify(encoded, password string) (bool, error) {
+	params, err := decodeArgon2id(encoded)
+	if err != nil {
+		return false, err
+	}
+
+	key := argon2.IDKey([]byte(password), params.salt, params.iterations, params.memory, params.parallelism, uint32(len(params.key)))
+	return subtle.ConstantTimeCompare(key, params.key) == 1, nil
+}
+
+func (h *Argon2idHasher) Supports(encoded string) bool {
+	return strings.HasPrefix(encoded, "$argon2id$")
+}
+
+func (h *Argon2idHasher) NeedsRehash(encoded string) bool {
+	params, err := decodeArgon2id(encoded)
+	if err != nil {
+		return true
+	}
+	return params.memory != h.Memory || params.iterations != h.Iterations ||
+		params.parallelism != h.Parallelism || uint32(len(params.key)) != h.KeyLength
+}
+
+// Разбирает хеш вида $argon2id$v=19$m=65536,t=3,p=2$<salt>$<hash>
+func decodeArgon2id(encoded string) (*argon2idParams, error) {
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 6 || parts[1] != HashAlgorithmArgon2id {
+		return nil, ErrMalformedHash
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return nil, ErrMalformedHash
+	}
+
+	params := &argon2idParams{}
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.memory, &params.iterations, &params.parallelism); err != nil {
+		return nil, ErrMalformedHash
+	}
+
+	var err error
+	if params.salt, err = base64.RawStdEncoding.DecodeString(parts[4]); err != nil {
+		return nil, ErrMalformedHash
+	}
+	if params.key, err = base64.RawStdEncoding.DecodeString(parts[5]); err != nil || len(params.key) == 0 {
+		return nil, ErrMalformedHash
+	}
+	return params, nil
+}
+
+// MultiHasher создает хеши основным алгоритмом и проверяет хеши всех известных алгоритмов,
+// что позволяет перейти на новый алгоритм без сброса паролей
+type MultiHasher struct {
+	primary PasswordHasher
+	legacy  []PasswordHasher
+}
+
+func NewMultiHasher(primary PasswordHasher, legacy ...PasswordHasher) *MultiHasher {
+	return &MultiHasher{primary: primary, legacy: legacy}
+}
+
+func (h *MultiHasher) Hash(password string) (string, error) {
+	return h.primary.Hash(password)
+}
+
+func (h *MultiHasher) Verify(encoded, password string) (bool, error) {
+	for _, hasher := range append([]PasswordHasher{h.primary}, h.legacy...) {
+		if hasher.Supports(encoded) {
+			return hasher.Verify(encoded, password)
+		}
+	}
+	return false, ErrMalformedHash
+}
+
+func (h *MultiHasher) Supports(encoded string) bool {
+	if h.primary.Supports(encoded) {
+		return true
+	}
+	for _, hasher := range h.legacy {
+		if hasher.Supports(encoded) {
+			return true
+		}
+	}
+	return false
+}
+
+func (h *MultiHasher) NeedsRehash(encoded string) bool {
+	return h.primary.NeedsRehash(encoded)
+}
+
+// NewPasswordHasher создает хешер с основным алгоритмом algorithm, понимающий хеши обоих алгоритмов
+func NewPasswordHasher(algorithm string, bcryptHasher *BcryptHasher, argon2Hasher *Argon2idHasher) (*MultiHasher, error) {
+	switch algorithm {
+	case HashAlgorithmBcrypt, "":
+		return NewMultiHasher(bcryptHasher, argon2Hasher), nil
+	case HashAlgorithmArgon2id:
+		return NewMultiHasher(argon2Hasher, bcryptHasher), nil
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnknownHashAlgorithm, algorithm)
+	}
+}
+
+// checkPassword проверяет пароль по хешу; пустой или нераспознанный хеш (например, у закрытого аккаунта)
+// считается несовпадением
+func checkPassword(hasher PasswordHasher, encoded, password string) error {
+	ok, err := hasher.Verify(encoded, password)
+	if err != nil && !errors.Is(err, ErrMalformedHash) {
+		return err
+	}
+	if !ok {
+		return ErrInvalidPassword
+	}
+	return nil
+}
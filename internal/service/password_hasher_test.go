@@ -0,0 +1,70 @@
+package service
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestArgon2idHasher(t *testing.T) {
+	hasher := NewArgon2idHasher(1024, 1, 1)
+
+	hash, err := hasher.Hash("password")
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(hash, "$argon2id$v=19$m=1024,t=1,p=1$"))
+
+	ok, err := hasher.Verify(hash, "password")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
+	ok, err = hasher.Verify(hash, "wrong")
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	assert.False(t, hasher.NeedsRehash(hash))
+	assert.True(t, NewArgon2idHasher(2048, 1, 1).NeedsRehash(hash))
+
+	_, err = hasher.Verify("$argon2id$v=19$m=1024$broken", "password")
+	assert.ErrorIs(t, err, ErrMalformedHash)
+}
+
+func TestBcryptHasherNeedsRehash(t *testing.T) {
+	hasher := NewBcryptHasher(bcrypt.MinCost)
+
+	hash, err := hasher.Hash("password")
+	assert.NoError(t, err)
+
+	ok, err := hasher.Verify(hash, "password")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
+	assert.False(t, hasher.NeedsRehash(hash))
+	assert.True(t, NewBcryptHasher(bcrypt.MinCost+1).NeedsRehash(hash))
+	assert.True(t, hasher.NeedsRehash("$argon2id$v=19$m=1024,t=1,p=1$c2FsdA$a2V5"))
+}
+
+// Основной алгоритм argon2id проверяет старые bcrypt-хеши и требует их пересчета
+func TestMultiHasherLegacy(t *testing.T) {
+	bcryptHasher := NewBcryptHasher(bcrypt.MinCost)
+	hasher, err := NewPasswordHasher(HashAlgorithmArgon2id, bcryptHasher, NewArgon2idHasher(1024, 1, 1))
+	assert.NoError(t, err)
+
+	legacyHash, _ := bcryptHasher.Hash("password")
+
+	ok, err := hasher.Verify(legacyHash, "password")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.True(t, hasher.NeedsRehash(legacyHash))
+
+	hash, _ := hasher.Hash("password")
+	assert.True(t, strings.HasPrefix(hash, "$argon2id$"))
+	assert.False(t, hasher.NeedsRehash(hash))
+
+	// Пустой хеш закрытого аккаунта не совпадает ни с одним паролем
+	assert.ErrorIs(t, checkPassword(hasher, "", "password"), ErrInvalidPassword)
+
+	_, err = NewPasswordHasher("md5", bcryptHasher, nil)
+	assert.ErrorIs(t, err, ErrUnknownHashAlgorithm)
+}
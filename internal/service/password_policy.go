@@ -0,0 +1,67 @@
+package service
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"unicode/utf8"
+)
+
+var (
+	ErrPasswordTooShort = errors.New("password is too short")
+	ErrPasswordTooLong  = errors.New("password is too long")
+	ErrPasswordBreached = errors.New("password is too common or has appeared in a data breach")
+)
+
+// Ограничение bcrypt: байты сверх 72 не участвуют в хешировании
+const maxPasswordBytes = 72
+
+// PasswordPolicy задает требования к паролям. Нулевое значение не накладывает ограничений, кроме длины bcrypt.
+type PasswordPolicy struct {
+	MinLength int
+	blocklist map[string]struct{}
+}
+
+// NewPasswordPolicy создает политику паролей. blocklistPath - необязательный файл
+// с запрещенными паролями (по одному в строке, без учета регистра).
+func NewPasswordPolicy(minLength int, blocklistPath string) (*PasswordPolicy, error) {
+	policy := &PasswordPolicy{MinLength: minLength}
+	if blocklistPath == "" {
+		return policy, nil
+	}
+
+	file, err := os.Open(blocklistPath)
+	if err != nil {
+		return nil, fmt.Errorf("open password blocklist: %w", err)
+	}
+	defer file.Close()
+
+	policy.blocklist = make(map[string]struct{})
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); line != "" {
+			policy.blocklist[strings.ToLower(line)] = struct{}{}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read password blocklist: %w", err)
+	}
+
+	return policy, nil
+}
+
+// Validate проверяет пароль на соответствие политике
+func (p *PasswordPolicy) Validate(password string) error {
+	if utf8.RuneCountInString(password) < p.MinLength {
+		return ErrPasswordTooShort
+	}
+	if len(password) > maxPasswordBytes {
+		return ErrPasswordTooLong
+	}
+	if _, blocked := p.blocklist[strings.ToLower(password)]; blocked {
+		return ErrPasswordBreached
+	}
+	return nil
+}
@@ -0,0 +1,30 @@
+package service
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPasswordPolicy(t *testing.T) {
+	blocklist := filepath.Join(t.TempDir(), "blocklist.txt")
+	require.NoError(t, os.WriteFile(blocklist, []byte("123456\nQwerty123\n\n"), 0o600))
+
+	policy, err := NewPasswordPolicy(8, blocklist)
+	require.NoError(t, err)
+
+	assert.ErrorIs(t, policy.Validate("short"), ErrPasswordTooShort)
+	assert.ErrorIs(t, policy.Validate("qwerty123"), ErrPasswordBreached)
+	assert.ErrorIs(t, policy.Validate(strings.Repeat("a", 73)), ErrPasswordTooLong)
+	assert.NoError(t, policy.Validate("correct-horse-battery"))
+}
+
+func TestPasswordPolicyZeroValue(t *testing.T) {
+	var policy PasswordPolicy
+
+	assert.NoError(t, policy.Validate("x"))
+}
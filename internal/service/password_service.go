@@ -0,0 +1,129 @@
+package service
+
+import (
+	"avito-shop-service/internal/repository"
+	"avito-shop-service/internal/tracing"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"time"
+)
+
+// Срок действия токена сброса пароля по умолчанию
+const DefaultResetTokenTTL = time.Hour
+
+var ErrSamePassword = errors.New("new password must differ from the current one")
+
+type PasswordService struct {
+	userRepo  repository.UserRepositoryInterface
+	resetRepo repository.PasswordResetRepository
+	policy    *PasswordPolicy
+	hasher    PasswordHasher
+	resetTTL  time.Duration
+	userCache *UserStateCache
+}
+
+func NewPasswordService(
+	userRepo repository.UserRepositoryInterface,
+	resetRepo repository.PasswordResetRepository,
+	policy *PasswordPolicy,
+	hasher PasswordHasher,
+	resetTTL time.Duration,
+	userCache *UserStateCache,
+) *PasswordService {
+	if resetTTL <= 0 {
+		resetTTL = DefaultResetTokenTTL
+	}
+	return &PasswordService{userRepo: userRepo, resetRepo: resetRepo, policy: policy, hasher: hasher, resetTTL: resetTTL, userCache: userCache}
+}
+
+// ChangePassword меняет пароль пользователя после проверки текущего.
+// Все ранее выданные токены пользователя перестают действовать.
+func (s *PasswordService) ChangePassword(ctx context.Context, userID int, currentPassword, newPassword string) error {
+	ctx, span := tracing.Start(ctx, "PasswordService.ChangePassword")
+	defer span.End()
+
+	user, err := s.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if user == nil {
+		return repository.ErrUserNotFound
+	}
+
+	if err := checkPassword(s.hasher, user.PasswordHash, currentPassword); err != nil {
+		return err
+	}
+	if currentPassword == newPassword {
+		return ErrSamePassword
+	}
+
+	hash, err := s.hashPassword(newPassword)
+	if err != nil {
+		return err
+	}
+	if err := s.userRepo.UpdatePassword(ctx, userID, hash); err != nil {
+		return err
+	}
+	// Старые токены перестают действовать на этом экземпляре сразу
+	s.userCache.Forget(userID)
+	return nil
+}
+
+// IssueResetToken выдает одноразовый токен сброса пароля пользователя. В базе хранится только хеш токена.
+func (s *PasswordService) IssueResetToken(ctx context.Context, adminID, userID int) (string, time.Time, error) {
+	ctx, span := tracing.Start(ctx, "PasswordService.IssueResetToken")
+	defer span.End()
+
+	token, err := randomToken(32)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	expiresAt := time.Now().Add(s.resetTTL)
+
+	if err := s.resetRepo.CreateResetToken(ctx, userID, adminID, hashResetToken(token), expiresAt); err != nil {
+		return "", time.Time{}, err
+	}
+	return token, expiresAt, nil
+}
+
+// ResetPassword устанавливает новый пароль по токену сброса
+func (s *PasswordService) ResetPassword(ctx context.Context, token, newPassword string) error {
+	ctx, span := tracing.Start(ctx, "PasswordService.ResetPassword")
+	defer span.End()
+
+	if token == "" {
+		return repository.ErrInvalidResetToken
+	}
+
+	hash, err := s.hashPassword(newPassword)
+	if err != nil {
+		return err
+	}
+
+	userID, err := s.resetRepo.ResetPassword(ctx, hashResetToken(token), hash)
+	if err != nil {
+		return err
+	}
+	s.userCache.Forget(userID)
+	return nil
+}
+
+func (s *PasswordService) hashPassword(password string) (string, error) {
+	if err := s.policy.Validate(password); err != nil {
+		return "", err
+	}
+
+	return s.hasher.Hash(password)
+}
+
+func hashResetToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// IsPasswordPolicyError сообщает, что пароль отклонен политикой паролей
+func IsPasswordPolicyError(err error) bool {
+	return errors.Is(err, ErrPasswordTooShort) || errors.Is(err, ErrPasswordTooLong) || errors.Is(err, ErrPasswordBreached)
+}
@@ -0,0 +1,85 @@
+package service
+
+import (
+	"avito-shop-service/internal/models"
+	"avito-shop-service/internal/repository"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Mock PasswordResetRepository
+type MockPasswordResetRepository struct {
+	mock.Mock
+}
+
+func (m *MockPasswordResetRepository) CreateResetToken(ctx context.Context, userID, adminID int, tokenHash string, expiresAt time.Time) error {
+	args := m.Called(userID, adminID, tokenHash, expiresAt)
+	return args.Error(0)
+}
+
+func (m *MockPasswordResetRepository) ResetPassword(ctx context.Context, tokenHash, passwordHash string) (int, error) {
+	args := m.Called(tokenHash, passwordHash)
+	return args.Int(0), args.Error(1)
+}
+
+func newTestPasswordService(userRepo *MockUserRepository, resetRepo *MockPasswordResetRepository) *PasswordService {
+	return NewPasswordService(userRepo, resetRepo, &PasswordPolicy{MinLength: 8}, NewBcryptHasher(bcrypt.MinCost), time.Hour, NewUserStateCache(time.Minute))
+}
+
+func TestChangePassword(t *testing.T) {
+	userRepo := new(MockUserRepository)
+	service := newTestPasswordService(userRepo, new(MockPasswordResetRepository))
+
+	hash, _ := bcrypt.GenerateFromPassword([]byte("old-password"), bcrypt.MinCost)
+	userRepo.On("GetUserByID", 1).Return(&models.User{ID: 1, PasswordHash: string(hash)}, nil)
+	userRepo.On("UpdatePassword", 1, mock.MatchedBy(func(h string) bool {
+		return bcrypt.CompareHashAndPassword([]byte(h), []byte("new-password")) == nil
+	})).Return(nil)
+
+	err := service.ChangePassword(context.Background(), 1, "old-password", "new-password")
+
+	assert.NoError(t, err)
+	userRepo.AssertExpectations(t)
+}
+
+func TestChangePasswordRejected(t *testing.T) {
+	userRepo := new(MockUserRepository)
+	service := newTestPasswordService(userRepo, new(MockPasswordResetRepository))
+
+	hash, _ := bcrypt.GenerateFromPassword([]byte("old-password"), bcrypt.MinCost)
+	userRepo.On("GetUserByID", 1).Return(&models.User{ID: 1, PasswordHash: string(hash)}, nil)
+
+	assert.ErrorIs(t, service.ChangePassword(context.Background(), 1, "wrong-password", "new-password"), ErrInvalidPassword)
+	assert.ErrorIs(t, service.ChangePassword(context.Background(), 1, "old-password", "short"), ErrPasswordTooShort)
+	assert.ErrorIs(t, service.ChangePassword(context.Background(), 1, "old-password", "old-password"), ErrSamePassword)
+	userRepo.AssertNotCalled(t, "UpdatePassword", mock.Anything, mock.Anything)
+}
+
+// В базу попадает только хеш токена, погашение выполняется по тому же хешу
+func TestResetTokenRoundTrip(t *testing.T) {
+	resetRepo := new(MockPasswordResetRepository)
+	service := newTestPasswordService(new(MockUserRepository), resetRepo)
+
+	var storedHash string
+	resetRepo.On("CreateResetToken", 2, 1, mock.AnythingOfType("string"), mock.AnythingOfType("time.Time")).
+		Run(func(args mock.Arguments) { storedHash = args.String(2) }).
+		Return(nil)
+
+	token, expiresAt, err := service.IssueResetToken(context.Background(), 1, 2)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, token)
+	assert.NotEqual(t, token, storedHash)
+	assert.WithinDuration(t, time.Now().Add(time.Hour), expiresAt, time.Minute)
+
+	resetRepo.On("ResetPassword", storedHash, mock.AnythingOfType("string")).Return(2, nil)
+	assert.NoError(t, service.ResetPassword(context.Background(), token, "brand-new-password"))
+
+	resetRepo.On("ResetPassword", hashResetToken("unknown"), mock.AnythingOfType("string")).Return(0, repository.ErrInvalidResetToken)
+	assert.ErrorIs(t, service.ResetPassword(context.Background(), "unknown", "brand-new-password"), repository.ErrInvalidResetToken)
+	resetRepo.AssertExpectations(t)
+}
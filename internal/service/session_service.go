@@ -0,0 +1,161 @@
+package service
+
+import (
+	"avito-shop-service/internal/models"
+	"avito-shop-service/internal/repository"
+	"avito-shop-service/internal/tracing"
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+)
+
+var ErrSessionRevoked = errors.New("session has been revoked or expired")
+
+// Верхняя граница размера кеша проверенных сессий
+const maxSessionCacheEntries = 10000
+
+// Проверенная активная сессия; отозванные сессии в кеш не попадают
+type sessionCacheEntry struct {
+	userID    int
+	expiresAt time.Time
+	checkedAt time.Time
+}
+
+// SessionService хранит сессии, созданные при входе.
+// Результат проверки активной сессии кешируется на cacheTTL, поэтому запросы не обращаются к базе
+// каждый раз. Отзыв действует на этом экземпляре сразу, на остальных - не позднее чем через cacheTTL.
+type SessionService struct {
+	sessionRepo repository.SessionRepository
+	cacheTTL    time.Duration
+	now         func() time.Time
+
+	mu    sync.Mutex
+	cache map[string]sessionCacheEntry
+}
+
+func NewSessionService(sessionRepo repository.SessionRepository, cacheTTL time.Duration) *SessionService {
+	return &SessionService{
+		sessionRepo: sessionRepo,
+		cacheTTL:    cacheTTL,
+		now:         time.Now,
+		cache:       make(map[string]sessionCacheEntry),
+	}
+}
+
+// Create сохраняет новую сессию и возвращает ее идентификатор для токена
+func (s *SessionService) Create(ctx context.Context, userID int, client models.ClientInfo, expiresAt time.Time) (string, error) {
+	ctx, span := tracing.Start(ctx, "SessionService.Create")
+	defer span.End()
+
+	id, err := randomToken(24)
+	if err != nil {
+		return "", err
+	}
+
+	session := &models.Session{
+		ID:        id,
+		UserID:    userID,
+		UserAgent: truncate(client.UserAgent, 255),
+		IP:        client.IP,
+		ExpiresAt: expiresAt,
+	}
+	if err := s.sessionRepo.CreateSession(ctx, session); err != nil {
+		return "", err
+	}
+	return id, nil
+}
+
+// Validate проверяет, что сессия принадлежит пользователю, не отозвана и не истекла
+func (s *SessionService) Validate(ctx context.Context, sessionID string, userID int) error {
+	ctx, span := tracing.Start(ctx, "SessionService.Validate")
+	defer span.End()
+
+	now := s.now()
+
+	s.mu.Lock()
+	entry, ok := s.cache[sessionID]
+	s.mu.Unlock()
+	if ok && now.Sub(entry.checkedAt) < s.cacheTTL {
+		if entry.userID != userID || !entry.expiresAt.After(now) {
+			return ErrSessionRevoked
+		}
+		return nil
+	}
+
+	// Обращение к базе заодно обновляет время последней активности
+	state, err := s.sessionRepo.TouchSession(ctx, sessionID)
+	if err != nil {
+		if errors.Is(err, repository.ErrSessionNotFound) {
+			return ErrSessionRevoked
+		}
+		return err
+	}
+	if state.Revoked || state.UserID != userID || !state.ExpiresAt.After(now) {
+		s.forget(sessionID)
+		return ErrSessionRevoked
+	}
+
+	s.remember(sessionID, sessionCacheEntry{userID: state.UserID, expiresAt: state.ExpiresAt, checkedAt: now})
+	return nil
+}
+
+// List возвращает активные сессии пользователя, отмечая текущую
+func (s *SessionService) List(ctx context.Context, userID int, currentSessionID string) ([]models.Session, error) {
+	ctx, span := tracing.Start(ctx, "SessionService.List")
+	defer span.End()
+
+	sessions, err := s.sessionRepo.GetActiveSessions(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range sessions {
+		sessions[i].Current = currentSessionID != "" && sessions[i].ID == currentSessionID
+	}
+	return sessions, nil
+}
+
+// Revoke отзывает сессию пользователя
+func (s *SessionService) Revoke(ctx context.Context, userID int, sessionID string) error {
+	ctx, span := tracing.Start(ctx, "SessionService.Revoke")
+	defer span.End()
+
+	if err := s.sessionRepo.RevokeSession(ctx, sessionID, userID); err != nil {
+		return err
+	}
+	s.forget(sessionID)
+	return nil
+}
+
+func (s *SessionService) remember(sessionID string, entry sessionCacheEntry) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.cache) >= maxSessionCacheEntries {
+		for id, cached := range s.cache {
+			if entry.checkedAt.Sub(cached.checkedAt) >= s.cacheTTL {
+				delete(s.cache, id)
+			}
+		}
+		// Все записи свежие - кеш сбрасывается целиком, следующие проверки пройдут через базу
+		if len(s.cache) >= maxSessionCacheEntries {
+			s.cache = make(map[string]sessionCacheEntry)
+		}
+	}
+	s.cache[sessionID] = entry
+}
+
+func (s *SessionService) forget(sessionID string) {
+	s.mu.Lock()
+	delete(s.cache, sessionID)
+	s.mu.Unlock()
+}
+
+func truncate(value string, maxLen int) string {
+	if len(value) <= maxLen {
+		return value
+	}
+	// Обрезанный многобайтовый символ отбрасывается
+	return strings.ToValidUTF8(value[:maxLen], "")
+}
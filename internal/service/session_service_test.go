@@ -0,0 +1,108 @@
+package service
+
+import (
+	"avito-shop-service/internal/models"
+	"avito-shop-service/internal/repository"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockSessionRepository struct {
+	mock.Mock
+}
+
+func (m *MockSessionRepository) CreateSession(ctx context.Context, session *models.Session) error {
+	args := m.Called(session)
+	return args.Error(0)
+}
+
+func (m *MockSessionRepository) GetActiveSessions(ctx context.Context, userID int) ([]models.Session, error) {
+	args := m.Called(userID)
+	return args.Get(0).([]models.Session), args.Error(1)
+}
+
+func (m *MockSessionRepository) TouchSession(ctx context.Context, sessionID string) (*repository.SessionState, error) {
+	args := m.Called(sessionID)
+	state := args.Get(0)
+	if state == nil {
+		return nil, args.Error(1)
+	}
+	return state.(*repository.SessionState), args.Error(1)
+}
+
+func (m *MockSessionRepository) RevokeSession(ctx context.Context, sessionID string, userID int) error {
+	args := m.Called(sessionID, userID)
+	return args.Error(0)
+}
+
+func TestSessionCreate(t *testing.T) {
+	repo := new(MockSessionRepository)
+	service := NewSessionService(repo, time.Minute)
+	expiresAt := time.Now().Add(time.Hour)
+
+	repo.On("CreateSession", mock.MatchedBy(func(s *models.Session) bool {
+		return s.UserID == 1 && s.UserAgent == "curl/8.0" && s.IP == "10.0.0.1" && s.ExpiresAt.Equal(expiresAt) && s.ID != ""
+	})).Return(nil)
+
+	id, err := service.Create(context.Background(), 1, models.ClientInfo{UserAgent: "curl/8.0", IP: "10.0.0.1"}, expiresAt)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, id)
+	repo.AssertExpectations(t)
+}
+
+// Повторная проверка в пределах cacheTTL не обращается к базе
+func TestSessionValidateUsesCache(t *testing.T) {
+	repo := new(MockSessionRepository)
+	service := NewSessionService(repo, time.Minute)
+	now := time.Now()
+	service.now = func() time.Time { return now }
+
+	repo.On("TouchSession", "s1").Return(&repository.SessionState{UserID: 1, ExpiresAt: now.Add(time.Hour)}, nil).Once()
+
+	assert.NoError(t, service.Validate(context.Background(), "s1", 1))
+	assert.NoError(t, service.Validate(context.Background(), "s1", 1))
+	// Чужая сессия отклоняется и из кеша
+	assert.ErrorIs(t, service.Validate(context.Background(), "s1", 2), ErrSessionRevoked)
+	repo.AssertNumberOfCalls(t, "TouchSession", 1)
+
+	// По истечении cacheTTL сессия проверяется заново
+	now = now.Add(2 * time.Minute)
+	repo.On("TouchSession", "s1").Return(&repository.SessionState{UserID: 1, ExpiresAt: now.Add(time.Hour), Revoked: true}, nil).Once()
+	assert.ErrorIs(t, service.Validate(context.Background(), "s1", 1), ErrSessionRevoked)
+	repo.AssertNumberOfCalls(t, "TouchSession", 2)
+}
+
+// Отзыв сразу убирает сессию из кеша
+func TestSessionRevokeInvalidatesCache(t *testing.T) {
+	repo := new(MockSessionRepository)
+	service := NewSessionService(repo, time.Hour)
+	expiresAt := time.Now().Add(time.Hour)
+
+	repo.On("TouchSession", "s1").Return(&repository.SessionState{UserID: 1, ExpiresAt: expiresAt}, nil).Once()
+	assert.NoError(t, service.Validate(context.Background(), "s1", 1))
+
+	repo.On("RevokeSession", "s1", 1).Return(nil)
+	assert.NoError(t, service.Revoke(context.Background(), 1, "s1"))
+
+	repo.On("TouchSession", "s1").Return(&repository.SessionState{UserID: 1, ExpiresAt: expiresAt, Revoked: true}, nil).Once()
+	assert.ErrorIs(t, service.Validate(context.Background(), "s1", 1), ErrSessionRevoked)
+
+	repo.On("TouchSession", "missing").Return(nil, repository.ErrSessionNotFound)
+	assert.ErrorIs(t, service.Validate(context.Background(), "missing", 1), ErrSessionRevoked)
+}
+
+func TestSessionListMarksCurrent(t *testing.T) {
+	repo := new(MockSessionRepository)
+	service := NewSessionService(repo, time.Minute)
+
+	repo.On("GetActiveSessions", 1).Return([]models.Session{{ID: "s1"}, {ID: "s2"}}, nil)
+
+	sessions, err := service.List(context.Background(), 1, "s2")
+	assert.NoError(t, err)
+	assert.False(t, sessions[0].Current)
+	assert.True(t, sessions[1].Current)
+}
@@ -0,0 +1,246 @@
+package service
+
+import (
+	"avito-shop-service/internal/models"
+	"avito-shop-service/internal/repository"
+	"avito-shop-service/internal/tracing"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Параметры TOTP (RFC 6238) в значениях по умолчанию, которые понимают все приложения-аутентификаторы
+const (
+	totpPeriod       = 30 * time.Second
+	totpDigits       = 6
+	totpSkew         = 1 // Допустимое расхождение часов, в шагах
+	totpSecretLength = 20
+
+	recoveryCodeCount = 10
+
+	// Неверные коды подряд, после которых проверка приостанавливается на twoFactorLockout
+	maxTwoFactorAttempts = 5
+	twoFactorLockout     = 15 * time.Minute
+)
+
+var (
+	ErrInvalidTOTPCode = errors.New("invalid two-factor code")
+	ErrTwoFactorLocked = errors.New("too many invalid two-factor codes, try again later")
+)
+
+var base32NoPadding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+type TOTPService struct {
+	totpRepo repository.TOTPRepository
+	userRepo repository.UserRepositoryInterface
+	issuer   string
+	now      func() time.Time
+}
+
+func NewTOTPService(totpRepo repository.TOTPRepository, userRepo repository.UserRepositoryInterface, issuer string) *TOTPService {
+	return &TOTPService{totpRepo: totpRepo, userRepo: userRepo, issuer: issuer, now: time.Now}
+}
+
+// Enroll создает новый секрет. Двухфакторная аутентификация включается только после Confirm.
+func (s *TOTPService) Enroll(ctx context.Context, userID int) (*models.TOTPEnrollment, error) {
+	ctx, span := tracing.Start(ctx, "TOTPService.Enroll")
+	defer span.End()
+
+	user, err := s.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, repository.ErrUserNotFound
+	}
+
+	raw := make([]byte, totpSecretLength)
+	if _, err := rand.Read(raw); err != nil {
+		return nil, err
+	}
+	secret := base32NoPadding.EncodeToString(raw)
+
+	if err := s.totpRepo.SaveSecret(ctx, userID, secret); err != nil {
+		return nil, err
+	}
+
+	return &models.TOTPEnrollment{Secret: secret, URI: s.otpauthURI(user.Username, secret)}, nil
+}
+
+// Confirm включает двухфакторную аутентификацию после проверки первого кода
+// и возвращает одноразовые коды восстановления. Коды показываются только один раз.
+func (s *TOTPService) Confirm(ctx context.Context, userID int, code string) ([]string, error) {
+	ctx, span := tracing.Start(ctx, "TOTPService.Confirm")
+	defer span.End()
+
+	totp, err := s.totpRepo.GetTOTP(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if totp == nil {
+		return nil, repository.ErrTOTPNotEnrolled
+	}
+	if totp.EnabledAt != nil {
+		return nil, repository.ErrTOTPAlreadyEnabled
+	}
+
+	step, ok := s.matchStep(totp.Secret, code)
+	if !ok {
+		return nil, ErrInvalidTOTPCode
+	}
+
+	codes := make([]string, recoveryCodeCount)
+	hashes := make([]string, recoveryCodeCount)
+	for i := range codes {
+		codes[i], err = generateRecoveryCode()
+		if err != nil {
+			return nil, err
+		}
+		hashes[i] = hashRecoveryCode(codes[i])
+	}
+
+	if err := s.totpRepo.Enable(ctx, userID, step, hashes); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// Disable отключает двухфакторную аутентификацию; требует действующий код или код восстановления
+func (s *TOTPService) Disable(ctx context.Context, userID int, code string) error {
+	ctx, span := tracing.Start(ctx, "TOTPService.Disable")
+	defer span.End()
+
+	if err := s.Verify(ctx, userID, code); err != nil {
+		return err
+	}
+	return s.totpRepo.Disable(ctx, userID)
+}
+
+// IsEnabled сообщает, подтверждена ли у пользователя двухфакторная аутентификация
+func (s *TOTPService) IsEnabled(ctx context.Context, userID int) (bool, error) {
+	ctx, span := tracing.Start(ctx, "TOTPService.IsEnabled")
+	defer span.End()
+
+	totp, err := s.totpRepo.GetTOTP(ctx, userID)
+	if err != nil {
+		return false, err
+	}
+	return totp != nil && totp.EnabledAt != nil, nil
+}
+
+// Verify проверяет код из приложения или код восстановления. Каждый код принимается один раз;
+// после maxTwoFactorAttempts неверных кодов подряд проверка приостанавливается на twoFactorLockout
+func (s *TOTPService) Verify(ctx context.Context, userID int, code string) error {
+	ctx, span := tracing.Start(ctx, "TOTPService.Verify")
+	defer span.End()
+
+	totp, err := s.totpRepo.GetTOTP(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if totp == nil || totp.EnabledAt == nil {
+		return repository.ErrTOTPNotEnrolled
+	}
+	if totp.LockedUntil != nil && s.now().Before(*totp.LockedUntil) {
+		return ErrTwoFactorLocked
+	}
+
+	ok, err := s.useCode(ctx, totp, strings.TrimSpace(code))
+	if err != nil {
+		return err
+	}
+	if !ok {
+		if err := s.totpRepo.RecordFailure(ctx, userID, maxTwoFactorAttempts, s.now().Add(twoFactorLockout)); err != nil {
+			return err
+		}
+		return ErrInvalidTOTPCode
+	}
+	return nil
+}
+
+// Погашает код приложения или код восстановления. Возвращает false для неверного или уже использованного кода
+func (s *TOTPService) useCode(ctx context.Context, totp *models.TOTP, code string) (bool, error) {
+	if len(code) == totpDigits {
+		step, ok := s.matchStep(totp.Secret, code)
+		if !ok || step <= totp.LastUsedStep {
+			return false, nil
+		}
+		return s.totpRepo.UseStep(ctx, totp.UserID, step)
+	}
+
+	return s.totpRepo.UseRecoveryCode(ctx, totp.UserID, hashRecoveryCode(code))
+}
+
+// Ищет временной шаг в пределах допустимого расхождения часов, для которого код совпадает
+func (s *TOTPService) matchStep(secret, code string) (int64, bool) {
+	key, err := base32NoPadding.DecodeString(secret)
+	if err != nil || len(code) != totpDigits {
+		return 0, false
+	}
+
+	current := s.now().Unix() / int64(totpPeriod/time.Second)
+	for offset := -totpSkew; offset <= totpSkew; offset++ {
+		step := current + int64(offset)
+		if hmac.Equal([]byte(totpCode(key, step)), []byte(code)) {
+			return step, true
+		}
+	}
+	return 0, false
+}
+
+func (s *TOTPService) otpauthURI(username, secret string) string {
+	params := url.Values{}
+	params.Set("secret", secret)
+	params.Set("issuer", s.issuer)
+	params.Set("algorithm", "SHA1")
+	params.Set("digits", fmt.Sprint(totpDigits))
+	params.Set("period", fmt.Sprint(int(totpPeriod/time.Second)))
+
+	label := url.PathEscape(s.issuer + ":" + username)
+	return "otpauth://totp/" + label + "?" + params.Encode()
+}
+
+// Код HOTP (RFC 4226) для временного шага
+func totpCode(key []byte, step int64) string {
+	var msg [8]byte
+	binary.BigEndian.PutUint64(msg[:], uint64(step))
+
+	mac := hmac.New(sha1.New, key)
+	mac.Write(msg[:])
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	mod := uint32(1)
+	for i := 0; i < totpDigits; i++ {
+		mod *= 10
+	}
+	return fmt.Sprintf("%0*d", totpDigits, value%mod)
+}
+
+// Код восстановления вида xxxxx-xxxxx
+func generateRecoveryCode() (string, error) {
+	raw := make([]byte, 7)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+	code := strings.ToLower(base32NoPadding.EncodeToString(raw))[:10]
+	return code[:5] + "-" + code[5:], nil
+}
+
+func hashRecoveryCode(code string) string {
+	normalized := strings.ToLower(strings.ReplaceAll(strings.TrimSpace(code), "-", ""))
+	sum := sha256.Sum256([]byte(normalized))
+	return hex.EncodeToString(sum[:])
+}
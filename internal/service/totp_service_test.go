@@ -0,0 +1,168 @@
+package service
+
+import (
+	"avito-shop-service/internal/models"
+	"avito-shop-service/internal/repository"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// Mock TOTPRepository
+type MockTOTPRepository struct {
+	mock.Mock
+}
+
+func (m *MockTOTPRepository) SaveSecret(ctx context.Context, userID int, secret string) error {
+	args := m.Called(userID, secret)
+	return args.Error(0)
+}
+
+func (m *MockTOTPRepository) GetTOTP(ctx context.Context, userID int) (*models.TOTP, error) {
+	args := m.Called(userID)
+	totp := args.Get(0)
+	if totp == nil {
+		return nil, args.Error(1)
+	}
+	return totp.(*models.TOTP), args.Error(1)
+}
+
+func (m *MockTOTPRepository) Enable(ctx context.Context, userID int, step int64, recoveryCodeHashes []string) error {
+	args := m.Called(userID, step, recoveryCodeHashes)
+	return args.Error(0)
+}
+
+func (m *MockTOTPRepository) UseStep(ctx context.Context, userID int, step int64) (bool, error) {
+	args := m.Called(userID, step)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockTOTPRepository) UseRecoveryCode(ctx context.Context, userID int, codeHash string) (bool, error) {
+	args := m.Called(userID, codeHash)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockTOTPRepository) RecordFailure(ctx context.Context, userID, maxAttempts int, lockedUntil time.Time) error {
+	args := m.Called(userID, maxAttempts, lockedUntil)
+	return args.Error(0)
+}
+
+func (m *MockTOTPRepository) Disable(ctx context.Context, userID int) error {
+	args := m.Called(userID)
+	return args.Error(0)
+}
+
+// Тестовый вектор RFC 6238 (SHA1, T = 59): 94287082, последние шесть цифр - 287082
+func TestTOTPCodeRFCVector(t *testing.T) {
+	assert.Equal(t, "287082", totpCode([]byte("12345678901234567890"), 1))
+}
+
+func newTestTOTPService(totpRepo *MockTOTPRepository, userRepo *MockUserRepository, now time.Time) *TOTPService {
+	service := NewTOTPService(totpRepo, userRepo, "Avito Shop")
+	service.now = func() time.Time { return now }
+	return service
+}
+
+func TestTOTPEnroll(t *testing.T) {
+	totpRepo := new(MockTOTPRepository)
+	userRepo := new(MockUserRepository)
+	service := newTestTOTPService(totpRepo, userRepo, time.Now())
+
+	userRepo.On("GetUserByID", 1).Return(&models.User{ID: 1, Username: "alice"}, nil)
+	totpRepo.On("SaveSecret", 1, mock.AnythingOfType("string")).Return(nil)
+
+	enrollment, err := service.Enroll(context.Background(), 1)
+
+	assert.NoError(t, err)
+	assert.Len(t, enrollment.Secret, 32)
+	assert.True(t, strings.HasPrefix(enrollment.URI, "otpauth://totp/Avito%20Shop:alice?"))
+	assert.Contains(t, enrollment.URI, "secret="+enrollment.Secret)
+	totpRepo.AssertExpectations(t)
+}
+
+func TestTOTPConfirm(t *testing.T) {
+	totpRepo := new(MockTOTPRepository)
+	now := time.Unix(1_700_000_000, 0)
+	service := newTestTOTPService(totpRepo, new(MockUserRepository), now)
+
+	secret := "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+	key, _ := base32NoPadding.DecodeString(secret)
+	step := now.Unix() / 30
+
+	totpRepo.On("GetTOTP", 1).Return(&models.TOTP{UserID: 1, Secret: secret}, nil)
+	totpRepo.On("Enable", 1, step-1, mock.MatchedBy(func(hashes []string) bool { return len(hashes) == recoveryCodeCount })).Return(nil)
+
+	_, err := service.Confirm(context.Background(), 1, "000000")
+	assert.ErrorIs(t, err, ErrInvalidTOTPCode)
+
+	// Код предыдущего шага принимается с учетом расхождения часов
+	codes, err := service.Confirm(context.Background(), 1, totpCode(key, step-1))
+	assert.NoError(t, err)
+	assert.Len(t, codes, recoveryCodeCount)
+	assert.Regexp(t, `^[a-z2-7]{5}-[a-z2-7]{5}$`, codes[0])
+	totpRepo.AssertExpectations(t)
+}
+
+func TestTOTPVerify(t *testing.T) {
+	totpRepo := new(MockTOTPRepository)
+	now := time.Unix(1_700_000_000, 0)
+	service := newTestTOTPService(totpRepo, new(MockUserRepository), now)
+
+	secret := "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+	key, _ := base32NoPadding.DecodeString(secret)
+	step := now.Unix() / 30
+	enabledAt := now.Add(-time.Hour)
+
+	totpRepo.On("GetTOTP", 1).Return(&models.TOTP{UserID: 1, Secret: secret, EnabledAt: &enabledAt, LastUsedStep: step - 1}, nil)
+	totpRepo.On("UseStep", 1, step).Return(true, nil)
+	totpRepo.On("UseRecoveryCode", 1, hashRecoveryCode("abcde-fghij")).Return(true, nil)
+	totpRepo.On("UseRecoveryCode", 1, mock.Anything).Return(false, nil)
+	totpRepo.On("RecordFailure", 1, maxTwoFactorAttempts, now.Add(twoFactorLockout)).Return(nil)
+
+	assert.NoError(t, service.Verify(context.Background(), 1, totpCode(key, step)))
+
+	// Код уже использованного шага отклоняется без обращения к базе
+	assert.ErrorIs(t, service.Verify(context.Background(), 1, totpCode(key, step-1)), ErrInvalidTOTPCode)
+
+	// Код восстановления принимается без учета регистра и дефиса
+	assert.NoError(t, service.Verify(context.Background(), 1, "ABCDEFGHIJ"))
+	assert.ErrorIs(t, service.Verify(context.Background(), 1, "zzzzz-zzzzz"), ErrInvalidTOTPCode)
+	totpRepo.AssertExpectations(t)
+	totpRepo.AssertNumberOfCalls(t, "RecordFailure", 2)
+}
+
+// Пока проверка приостановлена, коды не проверяются, даже верные
+func TestTOTPVerifyLocked(t *testing.T) {
+	totpRepo := new(MockTOTPRepository)
+	now := time.Unix(1_700_000_000, 0)
+	service := newTestTOTPService(totpRepo, new(MockUserRepository), now)
+
+	secret := "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+	key, _ := base32NoPadding.DecodeString(secret)
+	step := now.Unix() / 30
+	enabledAt := now.Add(-time.Hour)
+	lockedUntil := now.Add(time.Minute)
+
+	totpRepo.On("GetTOTP", 1).Return(&models.TOTP{UserID: 1, Secret: secret, EnabledAt: &enabledAt, LockedUntil: &lockedUntil}, nil)
+
+	assert.ErrorIs(t, service.Verify(context.Background(), 1, totpCode(key, step)), ErrTwoFactorLocked)
+	totpRepo.AssertNotCalled(t, "UseStep", mock.Anything, mock.Anything)
+
+	// После окончания блокировки код снова проверяется
+	service.now = func() time.Time { return lockedUntil }
+	totpRepo.On("UseStep", 1, lockedUntil.Unix()/30).Return(true, nil)
+	assert.NoError(t, service.Verify(context.Background(), 1, totpCode(key, lockedUntil.Unix()/30)))
+}
+
+func TestTOTPVerifyNotEnrolled(t *testing.T) {
+	totpRepo := new(MockTOTPRepository)
+	service := newTestTOTPService(totpRepo, new(MockUserRepository), time.Now())
+
+	totpRepo.On("GetTOTP", 1).Return(nil, nil)
+
+	assert.ErrorIs(t, service.Verify(context.Background(), 1, "123456"), repository.ErrTOTPNotEnrolled)
+}
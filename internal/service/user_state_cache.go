@@ -0,0 +1,87 @@
+package service
+
+import (
+	"avito-shop-service/internal/models"
+	"context"
+	"sync"
+	"time"
+)
+
+// Верхняя граница размера кеша состояния пользователей
+const maxUserStateCacheEntries = 10000
+
+// Поля пользователя, от которых зависит проверка токена
+type userStateEntry struct {
+	role         string
+	status       string
+	tokenVersion int
+	checkedAt    time.Time
+}
+
+// UserStateCache хранит роль, состояние аккаунта и версию токенов пользователей на ttl (0 - отключен),
+// чтобы проверка токена и прав администратора не читала пользователя из базы на каждый запрос.
+// Смена пароля и состояния аккаунта на этом экземпляре сбрасывает запись сразу (Forget),
+// на остальных экземплярах изменение становится видно не позже чем через ttl
+type UserStateCache struct {
+	ttl time.Duration
+	now func() time.Time
+
+	mu      sync.Mutex
+	entries map[int]userStateEntry
+	// Увеличивается при каждом сбросе; чтение, начатое до сброса, не попадает в кеш
+	generation uint64
+}
+
+func NewUserStateCache(ttl time.Duration) *UserStateCache {
+	return &UserStateCache{
+		ttl:     ttl,
+		now:     time.Now,
+		entries: make(map[int]userStateEntry),
+	}
+}
+
+// Forget сбрасывает запись пользователя после изменения его пароля или состояния
+func (c *UserStateCache) Forget(userID int) {
+	c.mu.Lock()
+	delete(c.entries, userID)
+	c.generation++
+	c.mu.Unlock()
+}
+
+// Возвращает пользователя из кеша или загружает его через load. У пользователя из кеша
+// заполнены только ID, роль, состояние и версия токенов. Отсутствующие пользователи не кешируются
+func (c *UserStateCache) get(ctx context.Context, userID int, load func(ctx context.Context, userID int) (*models.User, error)) (*models.User, error) {
+	now := c.now()
+
+	c.mu.Lock()
+	entry, ok := c.entries[userID]
+	generation := c.generation
+	c.mu.Unlock()
+	if ok && now.Sub(entry.checkedAt) < c.ttl {
+		return &models.User{ID: userID, Role: entry.role, Status: entry.status, TokenVersion: entry.tokenVersion}, nil
+	}
+
+	user, err := load(ctx, userID)
+	if err != nil || user == nil || c.ttl <= 0 {
+		return user, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.generation != generation {
+		return user, nil
+	}
+	if len(c.entries) >= maxUserStateCacheEntries {
+		for id, cached := range c.entries {
+			if now.Sub(cached.checkedAt) >= c.ttl {
+				delete(c.entries, id)
+			}
+		}
+		// Все записи свежие - кеш сбрасывается целиком
+		if len(c.entries) >= maxUserStateCacheEntries {
+			c.entries = make(map[int]userStateEntry)
+		}
+	}
+	c.entries[userID] = userStateEntry{role: user.Role, status: user.Status, tokenVersion: user.TokenVersion, checkedAt: now}
+	return user, nil
+}
@@ -0,0 +1,143 @@
+package service
+
+import (
+	"avito-shop-service/internal/models"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// Кеш с управляемыми часами
+func newTestUserStateCache(ttl time.Duration) (*UserStateCache, *time.Time) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	cache := NewUserStateCache(ttl)
+	cache.now = func() time.Time { return now }
+	return cache, &now
+}
+
+func newCachedAuthService(userRepo *MockUserRepository, cache *UserStateCache) *AuthService {
+	return NewAuthService(userRepo, "supersecretkey", &PasswordPolicy{}, NewBcryptHasher(0), &noTwoFactor{}, noSessions{}, cache)
+}
+
+func issueTestToken(t *testing.T, authService *AuthService, user *models.User) string {
+	t.Helper()
+	result, err := authService.LoginUser(context.Background(), user, models.ClientInfo{})
+	require.NoError(t, err)
+	return result.Token
+}
+
+// Проверка токена и прав администратора в пределах ttl не обращается к базе
+func TestUserStateCacheSkipsDatabase(t *testing.T) {
+	userRepo := new(MockUserRepository)
+	cache, _ := newTestUserStateCache(time.Minute)
+	authService := newCachedAuthService(userRepo, cache)
+
+	user := &models.User{ID: 1, Role: models.RoleAdmin, Status: models.StatusActive}
+	userRepo.On("GetUserByID", 1).Return(user, nil)
+	token := issueTestToken(t, authService, user)
+
+	for i := 0; i < 3; i++ {
+		userID, _, err := authService.Authenticate(context.Background(), token)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, userID)
+
+		isAdmin, err := authService.IsAdmin(context.Background(), 1)
+		assert.NoError(t, err)
+		assert.True(t, isAdmin)
+	}
+	userRepo.AssertNumberOfCalls(t, "GetUserByID", 1)
+}
+
+func TestUserStateCacheExpires(t *testing.T) {
+	userRepo := new(MockUserRepository)
+	cache, now := newTestUserStateCache(time.Minute)
+	authService := newCachedAuthService(userRepo, cache)
+
+	user := &models.User{ID: 1, Status: models.StatusActive}
+	userRepo.On("GetUserByID", 1).Return(user, nil)
+
+	assert.NoError(t, authService.ValidateUser(context.Background(), 1))
+	*now = now.Add(time.Minute)
+	assert.NoError(t, authService.ValidateUser(context.Background(), 1))
+
+	userRepo.AssertNumberOfCalls(t, "GetUserByID", 2)
+}
+
+// Нулевой ttl отключает кеш
+func TestUserStateCacheDisabled(t *testing.T) {
+	userRepo := new(MockUserRepository)
+	authService := newCachedAuthService(userRepo, NewUserStateCache(0))
+
+	userRepo.On("GetUserByID", 1).Return(&models.User{ID: 1, Status: models.StatusActive}, nil)
+
+	assert.NoError(t, authService.ValidateUser(context.Background(), 1))
+	assert.NoError(t, authService.ValidateUser(context.Background(), 1))
+
+	userRepo.AssertNumberOfCalls(t, "GetUserByID", 2)
+}
+
+// Блокировка аккаунта действует сразу, несмотря на кеш
+func TestStatusChangeDropsCachedUser(t *testing.T) {
+	userRepo := new(MockUserRepository)
+	accountRepo := new(MockAccountRepository)
+	cache, _ := newTestUserStateCache(time.Minute)
+	authService := newCachedAuthService(userRepo, cache)
+	accountService := NewAccountService(accountRepo, userRepo, new(MockWalletRepository), new(MockHoldRepository), cache)
+
+	user := &models.User{ID: 2, Status: models.StatusActive}
+	userRepo.On("GetUserByID", 2).Return(user, nil)
+	token := issueTestToken(t, authService, user)
+
+	_, _, err := authService.Authenticate(context.Background(), token)
+	require.NoError(t, err)
+
+	accountRepo.On("SetStatus", 2, 1, models.StatusSuspended, "left the company").Run(func(mock.Arguments) {
+		user.Status = models.StatusSuspended
+	}).Return(&models.StatusChange{UserID: 2, NewStatus: models.StatusSuspended}, nil)
+	_, err = accountService.SetStatus(context.Background(), 1, 2, models.StatusSuspended, "left the company")
+	require.NoError(t, err)
+
+	_, _, err = authService.Authenticate(context.Background(), token)
+	assert.ErrorIs(t, err, ErrAccountDisabled)
+}
+
+// Сброс пароля отзывает выданные токены сразу, несмотря на кеш
+func TestPasswordResetDropsCachedUser(t *testing.T) {
+	userRepo := new(MockUserRepository)
+	resetRepo := new(MockPasswordResetRepository)
+	cache, _ := newTestUserStateCache(time.Minute)
+	authService := newCachedAuthService(userRepo, cache)
+	passwordService := NewPasswordService(userRepo, resetRepo, &PasswordPolicy{MinLength: 8}, NewBcryptHasher(0), time.Hour, cache)
+
+	user := &models.User{ID: 2, Status: models.StatusActive}
+	userRepo.On("GetUserByID", 2).Return(user, nil)
+	token := issueTestToken(t, authService, user)
+
+	_, _, err := authService.Authenticate(context.Background(), token)
+	require.NoError(t, err)
+
+	resetRepo.On("ResetPassword", mock.Anything, mock.Anything).Run(func(mock.Arguments) {
+		user.TokenVersion++
+	}).Return(2, nil)
+	require.NoError(t, passwordService.ResetPassword(context.Background(), "reset-token", "brand-new-password"))
+
+	_, _, err = authService.Authenticate(context.Background(), token)
+	assert.ErrorIs(t, err, ErrTokenRevoked)
+}
+
+// Чтение, начатое до сброса записи, не сохраняет устаревшее состояние
+func TestUserStateCacheIgnoresReadRacingForget(t *testing.T) {
+	cache, _ := newTestUserStateCache(time.Minute)
+
+	_, err := cache.get(context.Background(), 1, func(_ context.Context, userID int) (*models.User, error) {
+		cache.Forget(userID) // состояние изменилось, пока шло чтение
+		return &models.User{ID: userID, Status: models.StatusActive}, nil
+	})
+	require.NoError(t, err)
+
+	assert.NotContains(t, cache.entries, 1)
+}
@@ -1,61 +1,150 @@
 package service
 
 import (
+	"avito-shop-service/internal/metrics"
 	"avito-shop-service/internal/models"
 	"avito-shop-service/internal/repository"
+	"avito-shop-service/internal/tracing"
+	"context"
 	"errors"
 )
 
+var (
+	ErrInvalidAmount   = errors.New("amount must be positive")
+	ErrInvalidQuantity = errors.New("quantity must be positive")
+	ErrSelfTransfer    = errors.New("cannot transfer to yourself")
+	ErrInvalidPrice    = errors.New("price must be positive")
+)
+
 type WalletService struct {
-	walletRepo repository.WalletRepository
+	walletRepo   repository.WalletRepository
+	limitService *LimitService
 }
 
-func NewWalletService(walletRepo repository.WalletRepository) *WalletService {
-	return &WalletService{walletRepo: walletRepo}
+func NewWalletService(walletRepo repository.WalletRepository, limitService *LimitService) *WalletService {
+	return &WalletService{walletRepo: walletRepo, limitService: limitService}
 }
 
 // Получение баланса пользователя
-func (s *WalletService) GetBalance(userID int) (int, error) {
-	return s.walletRepo.GetBalance(userID)
+func (s *WalletService) GetBalance(ctx context.Context, userID int) (int, error) {
+	ctx, span := tracing.Start(ctx, "WalletService.GetBalance")
+	defer span.End()
+
+	return s.walletRepo.GetBalance(ctx, userID)
+}
+
+// Получение доступного баланса (без зарезервированных монет)
+func (s *WalletService) GetAvailableBalance(ctx context.Context, userID int) (int, error) {
+	ctx, span := tracing.Start(ctx, "WalletService.GetAvailableBalance")
+	defer span.End()
+
+	return s.walletRepo.GetAvailableBalance(ctx, userID)
 }
 
 // Перевод монет между пользователями
-func (s *WalletService) Transfer(fromUserID, toUserID, amount int) error {
+func (s *WalletService) Transfer(ctx context.Context, fromUserID, toUserID, amount int) error {
+	ctx, span := tracing.Start(ctx, "WalletService.Transfer")
+	defer span.End()
+
+	if fromUserID == toUserID {
+		return ErrSelfTransfer
+	}
 	if amount <= 0 {
-		return errors.New("invalid transfer amount")
+		return ErrInvalidAmount
+	}
+	check, err := s.limitService.TransferCheck(ctx, fromUserID, amount)
+	if err != nil {
+		return err
 	}
-	return s.walletRepo.Transfer(fromUserID, toUserID, amount)
+	if err := s.walletRepo.Transfer(ctx, fromUserID, toUserID, amount, check); err != nil {
+		countInsufficientFunds(err, metrics.OperationTransfer)
+		return err
+	}
+
+	metrics.CoinsTransferred.Add(float64(amount))
+	return nil
 }
 
 // Получение истории транзакций
-func (s *WalletService) GetTransactions(userID int) ([]models.Transaction, error) {
-	return s.walletRepo.GetTransactions(userID)
+func (s *WalletService) GetTransactions(ctx context.Context, userID int) ([]models.Transaction, error) {
+	ctx, span := tracing.Start(ctx, "WalletService.GetTransactions")
+	defer span.End()
+
+	return s.walletRepo.GetTransactions(ctx, userID)
 }
 
 // Покупка товара
-func (s *WalletService) PurchaseItem(userID int, itemName string, price int, quantity int) error {
-	// Проверка на достаточность средств
-	balance, err := s.walletRepo.GetBalance(userID)
-	if err != nil {
+func (s *WalletService) PurchaseItem(ctx context.Context, userID int, itemName string, quantity int) error {
+	ctx, span := tracing.Start(ctx, "WalletService.PurchaseItem")
+	defer span.End()
+
+	if quantity <= 0 {
+		return ErrInvalidQuantity
+	}
+
+	// Неизвестный товар отклоняется по кешу цен без транзакции
+	if _, err := s.walletRepo.GetItemPrice(ctx, itemName); err != nil {
 		return err
 	}
 
-	totalCost := price * quantity
-	if balance < totalCost {
-		return errors.New("insufficient funds")
+	// Цена и баланс читаются в транзакции покупки под блокировкой строк товара и пользователя,
+	// поэтому цена из кеша не списывается, а отдельное чтение баланса заранее не нужно
+	if err := s.walletRepo.PurchaseItem(ctx, userID, itemName, quantity); err != nil {
+		countInsufficientFunds(err, metrics.OperationPurchase)
+		return err
 	}
 
-	// Обновление баланса и добавление записи о покупке
-	return s.walletRepo.PurchaseItem(userID, itemName, price, quantity)
+	metrics.Purchases.WithLabelValues(itemName).Add(float64(quantity))
+	return nil
 }
 
 // Получение цены товара
-func (s *WalletService) GetItemPrice(itemName string) (int, error) {
-	return s.walletRepo.GetItemPrice(itemName)
+func (s *WalletService) GetItemPrice(ctx context.Context, itemName string) (int, error) {
+	ctx, span := tracing.Start(ctx, "WalletService.GetItemPrice")
+	defer span.End()
+
+	return s.walletRepo.GetItemPrice(ctx, itemName)
+}
+
+// SetItemPrice добавляет товар в магазин или меняет его цену
+func (s *WalletService) SetItemPrice(ctx context.Context, itemName string, price int) error {
+	ctx, span := tracing.Start(ctx, "WalletService.SetItemPrice")
+	defer span.End()
+
+	if price <= 0 {
+		return ErrInvalidPrice
+	}
+	return s.walletRepo.SetItemPrice(ctx, itemName, price)
 }
 
 // Получение инвентаря пользователя
-func (s *WalletService) GetInventory(userID int) ([]models.Item, error) {
-	items, err := s.walletRepo.GetInventory(userID)
+func (s *WalletService) GetInventory(ctx context.Context, userID int) ([]models.Item, error) {
+	ctx, span := tracing.Start(ctx, "WalletService.GetInventory")
+	defer span.End()
+
+	items, err := s.walletRepo.GetInventory(ctx, userID)
 	return items, err
 }
+
+// Получение административных корректировок баланса
+func (s *WalletService) GetAdjustments(ctx context.Context, userID int) ([]models.Adjustment, error) {
+	ctx, span := tracing.Start(ctx, "WalletService.GetAdjustments")
+	defer span.End()
+
+	return s.walletRepo.GetAdjustments(ctx, userID)
+}
+
+// Баланс, инвентарь и история пользователя, согласованные между собой
+func (s *WalletService) GetInfo(ctx context.Context, userID int) (*models.InfoResponse, error) {
+	ctx, span := tracing.Start(ctx, "WalletService.GetInfo")
+	defer span.End()
+
+	return s.walletRepo.GetInfo(ctx, userID)
+}
+
+// Нехватка монет, обнаруженная в транзакции (баланс изменился после проверки), тоже учитывается
+func countInsufficientFunds(err error, operation string) {
+	if errors.Is(err, repository.ErrInsufficientFunds) {
+		metrics.InsufficientFunds.WithLabelValues(operation).Inc()
+	}
+}
@@ -1,9 +1,15 @@
 package service
 
 import (
+	"avito-shop-service/internal/metrics"
 	"avito-shop-service/internal/models"
+	"avito-shop-service/internal/repository"
+	"context"
+	"errors"
+	"sync"
 	"testing"
 
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 )
@@ -13,44 +19,65 @@ type MockWalletRepository struct {
 	mock.Mock
 }
 
-func (m *MockWalletRepository) GetBalance(userID int) (int, error) {
+func (m *MockWalletRepository) GetBalance(ctx context.Context, userID int) (int, error) {
 	args := m.Called(userID)
 	return args.Int(0), args.Error(1)
 }
 
-func (m *MockWalletRepository) Transfer(fromUserID, toUserID, amount int) error {
+func (m *MockWalletRepository) GetAvailableBalance(ctx context.Context, userID int) (int, error) {
+	args := m.Called(userID)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockWalletRepository) Transfer(ctx context.Context, fromUserID, toUserID, amount int, check models.TransferCheck) error {
 	args := m.Called(fromUserID, toUserID, amount)
 	return args.Error(0)
 }
 
-func (m *MockWalletRepository) GetTransactions(userID int) ([]models.Transaction, error) {
+func (m *MockWalletRepository) GetTransactions(ctx context.Context, userID int) ([]models.Transaction, error) {
 	args := m.Called(userID)
 	return args.Get(0).([]models.Transaction), args.Error(1)
 }
 
-func (m *MockWalletRepository) PurchaseItem(userID int, itemName string, price int, quantity int) error {
-	args := m.Called(userID, itemName, price, quantity)
+func (m *MockWalletRepository) PurchaseItem(ctx context.Context, userID int, itemName string, quantity int) error {
+	args := m.Called(userID, itemName, quantity)
 	return args.Error(0)
 }
 
-func (m *MockWalletRepository) GetItemPrice(itemName string) (int, error) {
+func (m *MockWalletRepository) GetItemPrice(ctx context.Context, itemName string) (int, error) {
 	args := m.Called(itemName)
 	return args.Int(0), args.Error(1)
 }
 
-func (m *MockWalletRepository) GetInventory(userID int) ([]models.Item, error) {
+func (m *MockWalletRepository) SetItemPrice(ctx context.Context, itemName string, price int) error {
+	args := m.Called(itemName, price)
+	return args.Error(0)
+}
+
+func (m *MockWalletRepository) GetInfo(ctx context.Context, userID int) (*models.InfoResponse, error) {
+	args := m.Called(userID)
+	info, _ := args.Get(0).(*models.InfoResponse)
+	return info, args.Error(1)
+}
+
+func (m *MockWalletRepository) GetInventory(ctx context.Context, userID int) ([]models.Item, error) {
 	args := m.Called(userID)
 	return args.Get(0).([]models.Item), args.Error(1)
 }
 
+func (m *MockWalletRepository) GetAdjustments(ctx context.Context, userID int) ([]models.Adjustment, error) {
+	args := m.Called(userID)
+	return args.Get(0).([]models.Adjustment), args.Error(1)
+}
+
 // Получение баланса
 func TestGetBalance(t *testing.T) {
 	mockRepo := new(MockWalletRepository)
-	service := NewWalletService(mockRepo)
+	service := NewWalletService(mockRepo, newUnlimitedLimitService())
 
 	mockRepo.On("GetBalance", 10).Return(1000, nil)
 
-	balance, err := service.GetBalance(10)
+	balance, err := service.GetBalance(context.Background(), 10)
 
 	assert.NoError(t, err)
 	assert.Equal(t, 1000, balance)
@@ -61,33 +88,102 @@ func TestGetBalance(t *testing.T) {
 // Перевод монет
 func TestTransfer(t *testing.T) {
 	mockRepo := new(MockWalletRepository)
-	service := NewWalletService(mockRepo)
+	mockLimits := new(MockLimitRepository)
+	service := NewWalletService(mockRepo, NewLimitService(mockLimits, models.TransferLimits{}))
 
+	mockLimits.On("GetOverride", 1).Return(nil, nil)
 	mockRepo.On("Transfer", 1, 2, 300).Return(nil)
 
-	err := service.Transfer(1, 2, 300)
+	err := service.Transfer(context.Background(), 1, 2, 300)
 
 	assert.NoError(t, err)
 	mockRepo.AssertExpectations(t)
+	mockLimits.AssertExpectations(t)
+}
+
+// Перевод сверх лимита не доходит до репозитория
+func TestTransferExceedsLimit(t *testing.T) {
+	mockRepo := new(MockWalletRepository)
+	mockLimits := new(MockLimitRepository)
+	service := NewWalletService(mockRepo, NewLimitService(mockLimits, models.TransferLimits{MaxAmount: 200}))
+
+	mockLimits.On("GetOverride", 1).Return(nil, nil)
+
+	err := service.Transfer(context.Background(), 1, 2, 300)
+
+	assert.ErrorIs(t, err, ErrTransferAmountLimit)
+	mockRepo.AssertNotCalled(t, "Transfer", 1, 2, 300)
 }
 
 // ПокупкА товара
 func TestPurchaseItem(t *testing.T) {
 	mockRepo := new(MockWalletRepository)
-	service := NewWalletService(mockRepo)
+	service := NewWalletService(mockRepo, newUnlimitedLimitService())
 
-	mockRepo.On("GetBalance", 1).Return(1000, nil)
-	mockRepo.On("PurchaseItem", 1, "T-Shirt", 200, 2).Return(nil)
+	mockRepo.On("GetItemPrice", "T-Shirt").Return(200, nil)
+	mockRepo.On("PurchaseItem", 1, "T-Shirt", 2).Return(nil)
 
-	err := service.PurchaseItem(1, "T-Shirt", 200, 2)
+	err := service.PurchaseItem(context.Background(), 1, "T-Shirt", 2)
 
 	assert.NoError(t, err)
 	mockRepo.AssertExpectations(t)
 }
 
+// Неизвестный товар отклоняется без транзакции покупки
+func TestPurchaseUnknownItem(t *testing.T) {
+	mockRepo := new(MockWalletRepository)
+	service := NewWalletService(mockRepo, newUnlimitedLimitService())
+
+	mockRepo.On("GetItemPrice", "yacht").Return(0, repository.ErrItemNotFound)
+
+	err := service.PurchaseItem(context.Background(), 1, "yacht", 1)
+
+	assert.ErrorIs(t, err, repository.ErrItemNotFound)
+	mockRepo.AssertNotCalled(t, "PurchaseItem", 1, "yacht", 1)
+}
+
+// Успешная покупка и отказ из-за нехватки монет попадают в метрики
+func TestPurchaseItemMetrics(t *testing.T) {
+	mockRepo := new(MockWalletRepository)
+	service := NewWalletService(mockRepo, newUnlimitedLimitService())
+
+	purchased := testutil.ToFloat64(metrics.Purchases.WithLabelValues("umbrella"))
+	rejected := testutil.ToFloat64(metrics.InsufficientFunds.WithLabelValues(metrics.OperationPurchase))
+
+	mockRepo.On("GetItemPrice", "umbrella").Return(200, nil)
+	mockRepo.On("PurchaseItem", 1, "umbrella", 2).Return(nil)
+	mockRepo.On("PurchaseItem", 2, "umbrella", 1).Return(repository.ErrInsufficientFunds)
+
+	assert.NoError(t, service.PurchaseItem(context.Background(), 1, "umbrella", 2))
+	assert.ErrorIs(t, service.PurchaseItem(context.Background(), 2, "umbrella", 1), repository.ErrInsufficientFunds)
+
+	assert.Equal(t, purchased+2, testutil.ToFloat64(metrics.Purchases.WithLabelValues("umbrella")))
+	assert.Equal(t, rejected+1, testutil.ToFloat64(metrics.InsufficientFunds.WithLabelValues(metrics.OperationPurchase)))
+}
+
+func TestGetInfo(t *testing.T) {
+	mockRepo := new(MockWalletRepository)
+	service := NewWalletService(mockRepo, newUnlimitedLimitService())
+
+	snapshot := &models.InfoResponse{Balance: 900, AvailableBalance: 800, Inventory: []models.Item{{Name: "cup", Price: 20, Quantity: 5}}}
+	mockRepo.On("GetInfo", 1).Return(snapshot, nil)
+	mockRepo.On("GetInfo", 2).Return(nil, repository.ErrUserNotFound)
+
+	info, err := service.GetInfo(context.Background(), 1)
+	assert.NoError(t, err)
+	assert.Equal(t, snapshot, info)
+
+	_, err = service.GetInfo(context.Background(), 2)
+	assert.ErrorIs(t, err, repository.ErrUserNotFound)
+
+	// Отдельные запросы баланса и инвентаря не выполняются
+	mockRepo.AssertNotCalled(t, "GetBalance", 1)
+	mockRepo.AssertNotCalled(t, "GetInventory", 1)
+}
+
 func TestGetInventory(t *testing.T) {
 	mockRepo := new(MockWalletRepository)
-	service := NewWalletService(mockRepo)
+	service := NewWalletService(mockRepo, newUnlimitedLimitService())
 
 	expectedInventory := []models.Item{
 		{Name: "book", Price: 50},
@@ -96,7 +192,7 @@ func TestGetInventory(t *testing.T) {
 
 	mockRepo.On("GetInventory", 1).Return(expectedInventory, nil)
 
-	inventory, err := service.GetInventory(1)
+	inventory, err := service.GetInventory(context.Background(), 1)
 
 	assert.NoError(t, err)
 	assert.Equal(t, expectedInventory, inventory)
@@ -106,7 +202,7 @@ func TestGetInventory(t *testing.T) {
 
 func TestGetTransactions(t *testing.T) {
 	mockRepo := new(MockWalletRepository)
-	service := NewWalletService(mockRepo)
+	service := NewWalletService(mockRepo, newUnlimitedLimitService())
 
 	expectedTransactions := []models.Transaction{
 		{FromUserID: 1, ToUserID: 2, Amount: 200},
@@ -115,10 +211,147 @@ func TestGetTransactions(t *testing.T) {
 
 	mockRepo.On("GetTransactions", 1).Return(expectedTransactions, nil)
 
-	transactions, err := service.GetTransactions(1)
+	transactions, err := service.GetTransactions(context.Background(), 1)
 
 	assert.NoError(t, err)
 	assert.Equal(t, expectedTransactions, transactions)
 
 	mockRepo.AssertExpectations(t)
 }
+
+// Ошибки перевода возвращаются как сентинелы, различимые через errors.Is
+func TestTransferErrors(t *testing.T) {
+	dbErr := errors.New("connection refused")
+
+	tests := []struct {
+		name    string
+		to      int
+		amount  int
+		repoErr error
+		want    error
+	}{
+		{name: "self transfer", to: 1, amount: 100, want: ErrSelfTransfer},
+		{name: "zero amount", to: 2, amount: 0, want: ErrInvalidAmount},
+		{name: "negative amount", to: 2, amount: -5, want: ErrInvalidAmount},
+		{name: "unknown recipient", to: 2, amount: 100, repoErr: repository.ErrUserNotFound, want: repository.ErrUserNotFound},
+		{name: "insufficient funds", to: 2, amount: 100, repoErr: repository.ErrInsufficientFunds, want: repository.ErrInsufficientFunds},
+		{name: "database failure", to: 2, amount: 100, repoErr: dbErr, want: dbErr},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(MockWalletRepository)
+			mockLimits := new(MockLimitRepository)
+			service := NewWalletService(mockRepo, NewLimitService(mockLimits, models.TransferLimits{}))
+
+			mockLimits.On("GetOverride", 1).Return(nil, nil)
+			mockRepo.On("Transfer", 1, tt.to, tt.amount).Return(tt.repoErr)
+
+			err := service.Transfer(context.Background(), 1, tt.to, tt.amount)
+			assert.ErrorIs(t, err, tt.want)
+		})
+	}
+}
+
+func TestPurchaseItemErrors(t *testing.T) {
+	mockRepo := new(MockWalletRepository)
+	service := NewWalletService(mockRepo, newUnlimitedLimitService())
+
+	assert.ErrorIs(t, service.PurchaseItem(context.Background(), 1, "T-Shirt", 0), ErrInvalidQuantity)
+
+	mockRepo.AssertNotCalled(t, "PurchaseItem", 1, "T-Shirt", 0)
+
+	mockRepo.On("GetItemPrice", "T-Shirt").Return(200, nil)
+	mockRepo.On("PurchaseItem", 1, "T-Shirt", 1).Return(repository.ErrInsufficientFunds)
+	assert.ErrorIs(t, service.PurchaseItem(context.Background(), 1, "T-Shirt", 1), repository.ErrInsufficientFunds)
+	mockRepo.AssertNotCalled(t, "GetAvailableBalance", 1)
+}
+
+func TestSetItemPrice(t *testing.T) {
+	mockRepo := new(MockWalletRepository)
+	service := NewWalletService(mockRepo, newUnlimitedLimitService())
+
+	assert.ErrorIs(t, service.SetItemPrice(context.Background(), "umbrella", 0), ErrInvalidPrice)
+	mockRepo.AssertNotCalled(t, "SetItemPrice", "umbrella", 0)
+
+	mockRepo.On("SetItemPrice", "umbrella", 250).Return(nil)
+	assert.NoError(t, service.SetItemPrice(context.Background(), "umbrella", 250))
+	mockRepo.AssertExpectations(t)
+}
+
+// Кошелек, переводящий монеты так же, как транзакция в базе: строка отправителя
+// блокируется, статистика читается и проверяется под блокировкой
+type lockingWalletRepository struct {
+	repository.WalletRepository
+
+	mu        sync.Mutex
+	transfers []models.Transaction
+}
+
+func (r *lockingWalletRepository) Transfer(_ context.Context, fromUserID, toUserID, amount int, check models.TransferCheck) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if check != nil {
+		var stats models.TransferStats
+		for _, t := range r.transfers {
+			if t.FromUserID == fromUserID {
+				stats.HourlyCount++
+				stats.DailyVolume += t.Amount
+			}
+		}
+		if err := check(stats); err != nil {
+			return err
+		}
+	}
+
+	r.transfers = append(r.transfers, models.Transaction{FromUserID: fromUserID, ToUserID: toUserID, Amount: amount})
+	return nil
+}
+
+// Параллельные переводы одного отправителя не превышают лимит вместе
+func TestConcurrentTransfersRespectLimits(t *testing.T) {
+	tests := []struct {
+		name    string
+		limits  models.TransferLimits
+		amount  int
+		want    int
+		wantErr error
+	}{
+		{name: "hourly count", limits: models.TransferLimits{MaxPerHour: 5}, amount: 10, want: 5, wantErr: ErrHourlyTransferLimit},
+		{name: "daily volume", limits: models.TransferLimits{MaxDailyVolume: 250}, amount: 100, want: 2, wantErr: ErrDailyVolumeLimit},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockLimits := new(MockLimitRepository)
+			mockLimits.On("GetOverride", 1).Return(nil, nil)
+			repo := &lockingWalletRepository{}
+			service := NewWalletService(repo, NewLimitService(mockLimits, tt.limits))
+
+			const requests = 20
+			errs := make(chan error, requests)
+			var wg sync.WaitGroup
+			for i := 0; i < requests; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					errs <- service.Transfer(context.Background(), 1, 2, tt.amount)
+				}()
+			}
+			wg.Wait()
+			close(errs)
+
+			succeeded := 0
+			for err := range errs {
+				if err == nil {
+					succeeded++
+					continue
+				}
+				assert.ErrorIs(t, err, tt.wantErr)
+			}
+			assert.Equal(t, tt.want, succeeded)
+			assert.Len(t, repo.transfers, tt.want)
+		})
+	}
+}
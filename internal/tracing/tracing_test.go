@@ -0,0 +1,56 @@
+package tracing
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gorilla/mux/otelmux"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+// Спаны запроса и вложенного вызова продолжают трассу из входящего заголовка traceparent
+func TestStartContinuesIncomingTrace(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() { otel.SetTracerProvider(noop.NewTracerProvider()) })
+
+	router := mux.NewRouter()
+	router.Use(otelmux.Middleware("avito-shop-service"))
+	router.HandleFunc("/api/sendCoin", func(w http.ResponseWriter, r *http.Request) {
+		_, span := Start(r.Context(), "WalletService.Transfer")
+		span.End()
+		w.WriteHeader(http.StatusOK)
+	}).Methods("POST")
+
+	req := httptest.NewRequest("POST", "/api/sendCoin", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	spans := exporter.GetSpans()
+	names := make([]string, 0, len(spans))
+	for _, span := range spans {
+		assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", span.SpanContext.TraceID().String())
+		names = append(names, span.Name)
+	}
+	assert.Contains(t, names, "/api/sendCoin")
+	assert.Contains(t, names, "WalletService.Transfer")
+}
+
+// Без экспортера провайдер не настраивается, неизвестный экспортер - ошибка
+func TestSetupExporters(t *testing.T) {
+	shutdown, err := Setup(context.Background(), ExporterNone, "avito-shop-service")
+	assert.NoError(t, err)
+	assert.NoError(t, shutdown(context.Background()))
+
+	_, err = Setup(context.Background(), "jaeger", "avito-shop-service")
+	assert.Error(t, err)
+}